@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
 	"dashboard/db/pgdb"
+	"dashboard/mailer"
 	"dashboard/token"
 	"dashboard/utils"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -17,32 +20,61 @@ import (
 )
 
 type Server struct {
-	app    *fiber.App
-	store  pgdb.Store
-	valid  *validator.Validate
-	config utils.Config
-	token  token.Maker
+	app     *fiber.App
+	store   pgdb.Store
+	valid   *validator.Validate
+	config  utils.Config
+	token   token.Maker
+	revoked *revocationStore
+	mailer  mailer.Mailer
+
+	loginThrottle *loginThrottle
+	oidcProviders *oidcProviderCache
 }
 
-func NewServer(config utils.Config, store pgdb.Store, tokenMaker token.Maker) (*Server, error) {
+func NewServer(config utils.Config, store pgdb.Store, tokenMaker token.Maker, mailer mailer.Mailer) (*Server, error) {
 	if store == nil {
 		return nil, errors.New("store cannot be nil")
 	}
 	if tokenMaker == nil {
 		return nil, errors.New("tokenMaker cannot be nil")
 	}
+	if mailer == nil {
+		return nil, errors.New("mailer cannot be nil")
+	}
+	// public feeds need absolute links; only development may fall back to
+	// the request's host
+	if config.PublicBaseURL == "" && config.Environment != utils.EnvDevelopment {
+		return nil, errors.New("PUBLIC_BASE_URL is not set")
+	}
+
+	valid := validator.New()
+	if err := valid.RegisterValidation("role", validateRole); err != nil {
+		return nil, err
+	}
+	if err := registerPasswordValidators(valid); err != nil {
+		return nil, err
+	}
 
 	server := &Server{
-		valid:  validator.New(),
-		config: config,
-		store:  store,
-		token:  tokenMaker,
+		valid:   valid,
+		config:  config,
+		store:   store,
+		token:   tokenMaker,
+		revoked: newRevocationStore(store, config.RevocationCacheTTL, config.TokenDuration),
+		mailer:  mailer,
+
+		loginThrottle: newLoginThrottle(store, config),
+		oidcProviders: newOIDCProviderCache(),
 	}
 	server.setupApi()
 	return server, nil
 }
 
 func (server *Server) Start(port int16) error {
+	go server.runHousekeeping(context.Background(), time.Hour)
+	go server.runScheduler(context.Background(), time.Minute)
+
 	return server.app.Listen(fmt.Sprintf(":%d", port))
 }
 
@@ -54,13 +86,26 @@ func (server *Server) setupApi() {
 	app := fiber.New(fiber.Config{
 		ServerHeader:  "Inflection-Fiber",
 		ErrorHandler:  errorHandler,
-		BodyLimit:     2 * 1024 * 1024,
+		BodyLimit:     2 * 1024 * 1024, // attachment uploads get more, see uploadBodyLimit
 		CaseSensitive: true,
+
+		// c.IP() only honours ProxyHeader from TRUSTED_PROXIES (IPs or
+		// CIDRs); use a header the proxy overwrites, e.g. X-Real-IP, since
+		// the first X-Forwarded-For entry is whatever the client sent
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          server.config.TrustedProxies,
+		ProxyHeader:             server.config.ProxyHeader,
+		EnableIPValidation:      true,
 	})
 
+	app.Server().HeaderReceived = uploadBodyLimit
+
 	app.Use(logger.New(logger.ConfigDefault))
 
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		// list endpoints page through these; browsers hide them otherwise
+		ExposeHeaders: HeaderTotalCount + ", " + HeaderNextCursor,
+	}))
 
 	app.Use(compress.New())
 
@@ -77,44 +122,121 @@ func (server *Server) setupApi() {
 	})
 
 	app.Post("/login", server.userLogin)
-	app.Post("/user", server.authMiddleware, server.createUser)
-	app.Put("/users/:id", server.authMiddleware, server.updateUser)
+	app.Post("/auth/refresh", server.refreshAccessToken)
+	app.Post("/logout", server.logout)
+	app.Get("/auth/public-keys", server.getPublicKeys)
+	app.Post("/auth/switch-institute", server.authMiddleware, server.switchInstitute)
+	app.Post("/password/forgot", server.forgotPassword)
+	app.Post("/password/reset", server.resetPassword)
+	app.Post("/login/2fa", server.verifyLoginChallenge)
+	app.Post("/login/2fa/enroll", server.enrollLoginChallenge)
+	app.Get("/me", server.authMiddleware, server.getMe)
+	app.Put("/me", server.authMiddleware, server.updateMe)
+	app.Put("/me/avatar", server.authMiddleware, server.uploadMyAvatar)
+	app.Delete("/me/avatar", server.authMiddleware, server.deleteMyAvatar)
+	app.Post("/me/email", server.authMiddleware, server.requestMyEmailChange)
+	app.Post("/email/confirm", server.confirmEmailChange)
+	app.Get("/me/2fa", server.authMiddleware, server.getTOTPStatus)
+	app.Post("/me/2fa/enroll", server.authMiddleware, server.enrollMyTOTP)
+	app.Post("/me/2fa/confirm", server.authMiddleware, server.confirmMyTOTP)
+	app.Post("/me/2fa/recovery-codes", server.authMiddleware, server.regenerateRecoveryCodes)
+	app.Post("/me/2fa/disable", server.authMiddleware, server.disableMyTOTP)
+	app.Get("/me/memberships", server.authMiddleware, server.getMyPendingMemberships)
+	app.Post("/me/memberships/:instituteId/accept", server.authMiddleware, server.acceptMyMembership)
+	app.Post("/me/memberships/:instituteId/decline", server.authMiddleware, server.declineMyMembership)
+	app.Get("/institute/password-policy", server.authMiddleware, server.require(PermSettingsWrite), server.getPasswordPolicy)
+	app.Put("/institute/password-policy", server.authMiddleware, server.require(PermSettingsWrite), server.updatePasswordPolicy)
+	app.Put("/institute/2fa", server.authMiddleware, server.require(PermSettingsWrite), server.setInstituteRequire2FA)
+	app.Get("/institute/timezone", server.authMiddleware, server.require(PermSettingsWrite), server.getInstituteTimezone)
+	app.Put("/institute/timezone", server.authMiddleware, server.require(PermSettingsWrite), server.setInstituteTimezone)
+
+	app.Get("/auth/oidc/callback", server.oidcCallbackHandler)
+	app.Get("/auth/oidc/:code/login", server.oidcLogin)
+	app.Get("/institute/oidc", server.authMiddleware, server.require(PermSettingsWrite), server.getOIDCProvider)
+	app.Put("/institute/oidc", server.authMiddleware, server.require(PermSettingsWrite), server.putOIDCProvider)
+	app.Delete("/institute/oidc", server.authMiddleware, server.require(PermSettingsWrite), server.deleteOIDCProvider)
+	app.Post("/me/oidc/link", server.authMiddleware, server.linkMyOIDCIdentity)
+	app.Delete("/me/oidc/link", server.authMiddleware, server.unlinkMyOIDCIdentity)
+
+	app.Get("/institute/members", server.authMiddleware, server.require(PermUserRead), server.getInstituteMembers)
+	app.Post("/institute/members", server.authMiddleware, server.require(PermUserWrite), server.addInstituteMember)
+	app.Delete("/institute/members/:userId", server.authMiddleware, server.require(PermUserWrite), server.removeInstituteMember)
+
+	app.Post("/api-keys", server.authMiddleware, server.require(PermSettingsWrite), server.createAPIKey)
+	app.Get("/api-keys", server.authMiddleware, server.require(PermSettingsWrite), server.getAPIKeys)
+	app.Delete("/api-keys/:id", server.authMiddleware, server.require(PermSettingsWrite), server.revokeAPIKey)
+	app.Post("/user", server.authMiddleware, server.require(PermUserWrite), server.createUser)
+	app.Post("/users/invites", server.authMiddleware, server.require(PermUserWrite), server.inviteUser)
+	app.Get("/users/invites", server.authMiddleware, server.require(PermUserWrite), server.getPendingInvites)
+	app.Post("/users/invites/:id/resend", server.authMiddleware, server.require(PermUserWrite), server.resendInvite)
+	app.Delete("/users/invites/:id", server.authMiddleware, server.require(PermUserWrite), server.revokeInvite)
+	app.Post("/invites/accept", server.acceptInvite)
+	app.Post("/users/import", server.authMiddleware, server.require(PermUserWrite), server.importUsers)
+	app.Get("/users/export", server.authMiddleware, server.require(PermUserRead), server.exportUsers)
+	app.Put("/users/:id", server.authMiddleware, server.require(PermUserWrite), server.updateUser)
 	app.Put("/users/:id/password", server.authMiddleware, server.UpdateUserPassword)
-	app.Put("/users/:id/disable", server.authMiddleware, server.DisableUser)
-
-	app.Get("/users/:id", server.authMiddleware, server.getUserByID)
-	app.Get("/users", server.authMiddleware, server.getUserByEmail)
-	app.Get("/institutes/users", server.authMiddleware, server.getUsersByInstitute)
+	app.Post("/users/:id/email", server.authMiddleware, server.require(PermUserWrite), server.requestUserEmailChange)
+	app.Put("/users/:id/disable", server.authMiddleware, server.require(PermUserWrite), server.DisableUser)
+	app.Put("/users/:id/enable", server.authMiddleware, server.require(PermUserWrite), server.EnableUser)
+	app.Get("/users/:id/status-history", server.authMiddleware, server.require(PermUserRead), server.getUserStatusHistory)
+	app.Delete("/users/:id/status-changes/:changeId", server.authMiddleware, server.require(PermUserWrite), server.cancelUserStatusChange)
+	app.Get("/users/:id/security-events", server.authMiddleware, server.getSecurityEvents)
+	app.Get("/users/:id/offboard", server.authMiddleware, server.require(PermUserWrite), server.previewOffboardUser)
+	app.Post("/users/:id/offboard", server.authMiddleware, server.require(PermUserWrite), server.offboardUser)
+
+	app.Get("/users/:id", server.authMiddleware, server.require(PermUserRead), server.getUserByID)
+	app.Get("/users", server.authMiddleware, server.require(PermUserRead), server.getUserByEmail)
+	app.Get("/institutes/users", server.authMiddleware, server.require(PermUserRead), server.getUsersByInstitute)
+
+	/////////////////////////////////   platform    //////////////////////////////////////
+
+	app.Post("/platform/institutes", server.authMiddleware, server.require(PermInstituteWrite), server.createInstitute)
+	app.Get("/platform/institutes", server.authMiddleware, server.require(PermInstituteRead), server.getInstitutes)
+	app.Get("/platform/institutes/code/:code", server.authMiddleware, server.require(PermInstituteRead), server.getInstituteByCode)
+	app.Get("/platform/institutes/:id", server.authMiddleware, server.require(PermInstituteRead), server.getInstituteByID)
+	app.Put("/platform/institutes/:id", server.authMiddleware, server.require(PermInstituteWrite), server.updateInstitute)
+	app.Put("/platform/institutes/:id/disable", server.authMiddleware, server.require(PermInstituteWrite), server.disableInstitute)
+	app.Delete("/platform/institutes/:id", server.authMiddleware, server.require(PermInstituteWrite), server.deleteInstitute)
+	app.Post("/platform/institutes/:id/admins", server.authMiddleware, server.require(PermInstituteWrite), server.createInstituteAdmin)
+	app.Put("/platform/institutes/:id/oidc/approval", server.authMiddleware, server.require(PermInstituteWrite), server.approveOIDCProvider)
 
 	/////////////////////////////////   notice    ////////////////////////////////////////
 
-	app.Post("/createNotice", server.authMiddleware, server.createNotice)
-	app.Get("/notices/:id", server.authMiddleware, server.getNoticeByID)
-	app.Get("/notices", server.authMiddleware, server.getNoticesByInstitute)
+	// public, read-only: live notices for the institute's website
+	app.Get("/public/institutes/:code/notices", server.getPublicNotices)
+	app.Get("/public/institutes/:code/notices/rss", server.getPublicNoticesRSS)
+	app.Get("/public/institutes/:code/notices/atom", server.getPublicNoticesAtom)
+	app.Get("/public/institutes/:code/notices/:id", server.getPublicNotice)
+
+	app.Post("/createNotice", server.authMiddleware, server.require(PermNoticeWrite), server.createNotice)
+	app.Get("/notices/:id", server.authMiddleware, server.require(PermNoticeRead), server.getNoticeByID)
+	app.Get("/notices", server.authMiddleware, server.require(PermNoticeRead), server.getNoticesByInstitute)
 
-	app.Post("/notices/update/:id", server.authMiddleware, server.updateNotice)
-	app.Post("/notices/:id/delete", server.authMiddleware, server.deleteNotice)
+	app.Post("/notices/update/:id", server.authMiddleware, server.require(PermNoticeWrite), server.updateNotice)
+	app.Post("/notices/:id/delete", server.authMiddleware, server.require(PermNoticeDelete), server.deleteNotice)
+	app.Post("/notices/:id/attachments", server.authMiddleware, server.require(PermNoticeWrite), server.uploadNoticeAttachments)
+	app.Delete("/notices/:id/attachments/:attachmentId", server.authMiddleware, server.require(PermNoticeWrite), server.deleteNoticeAttachment)
 
 	/////////////////////////////////   photos    ////////////////////////////////////////
 
-	app.Post("/photos", server.authMiddleware, server.createPhoto)
-	app.Get("/photos/:id", server.authMiddleware, server.getPhotoByID)
-	app.Get("/photos", server.authMiddleware, server.getPhotosByInstitute)
-	app.Post("/photos/:id/image", server.authMiddleware, server.replacePhoto)
-	app.Delete("/photos/:id", server.authMiddleware, server.deletePhoto)
+	app.Post("/photos", server.authMiddleware, server.require(PermPhotoWrite), server.createPhoto)
+	app.Get("/photos/:id", server.authMiddleware, server.require(PermPhotoRead), server.getPhotoByID)
+	app.Get("/photos", server.authMiddleware, server.require(PermPhotoRead), server.getPhotosByInstitute)
+	app.Post("/photos/:id/image", server.authMiddleware, server.require(PermPhotoWrite), server.replacePhoto)
+	app.Delete("/photos/:id", server.authMiddleware, server.require(PermPhotoDelete), server.deletePhoto)
 
 	////////////////////////////// carousel ////////////////////////////////////////////
 
-	app.Post("/create_carousel", server.authMiddleware, server.createCarousel)
-	app.Get("/carousels/:id", server.authMiddleware, server.getCarouselByID)
-	app.Get("/carousels", server.authMiddleware, server.getCarouselsByInstitute)
+	app.Post("/create_carousel", server.authMiddleware, server.require(PermCarouselWrite), server.createCarousel)
+	app.Get("/carousels/:id", server.authMiddleware, server.require(PermCarouselRead), server.getCarouselByID)
+	app.Get("/carousels", server.authMiddleware, server.require(PermCarouselRead), server.getCarouselsByInstitute)
 
 	/////////////////////////// carousel_photos ////////////////////////////////////////
 
-	app.Post("/carousels/:id/photos", server.authMiddleware, server.createCarouselPhoto)
-	app.Post("/carousel-photos/:id", server.authMiddleware, server.getCarouselPhotoByID)
-	app.Get("/carousels/:id/photos", server.authMiddleware, server.getCarouselPhotosByCarouselID)
-	app.Delete("/carousel-photos/:id", server.authMiddleware, server.deleteCarouselPhoto)
+	app.Post("/carousels/:id/photos", server.authMiddleware, server.require(PermCarouselWrite), server.createCarouselPhoto)
+	app.Post("/carousel-photos/:id", server.authMiddleware, server.require(PermCarouselRead), server.getCarouselPhotoByID)
+	app.Get("/carousels/:id/photos", server.authMiddleware, server.require(PermCarouselRead), server.getCarouselPhotosByCarouselID)
+	app.Delete("/carousel-photos/:id", server.authMiddleware, server.require(PermCarouselWrite), server.deleteCarouselPhoto)
 
 	server.app = app
 
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// apiKeyPrefix lets authMiddleware tell API keys apart from PASETO tokens
+	apiKeyPrefix        = "cdk_"
+	apiKeySize          = 32
+	apiKeyDisplayLength = 12
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,min=3"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// apiKeyResponse never exposes the key hash
+type apiKeyResponse struct {
+	ID          int32              `json:"id"`
+	InstituteID int32              `json:"institute_id"`
+	Name        string             `json:"name"`
+	Prefix      string             `json:"prefix"`
+	Scopes      []string           `json:"scopes"`
+	CreatedBy   pgtype.Int4        `json:"created_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+func newAPIKeyResponse(key pgdb.ApiKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:          key.ID,
+		InstituteID: key.InstituteID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Scopes:      key.Scopes,
+		CreatedBy:   key.CreatedBy,
+		ExpiresAt:   key.ExpiresAt,
+		LastUsedAt:  key.LastUsedAt,
+		RevokedAt:   key.RevokedAt,
+		CreatedAt:   key.CreatedAt,
+	}
+}
+
+func (server *Server) createAPIKey(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"expires_at must be in the future",
+		)
+	}
+
+	// 3️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 4️⃣ Resolve scopes (a key never gets more than its creator)
+	scopes, err := resolveAPIKeyScopes(req.Scopes, payload.Role)
+	if err != nil {
+		return err
+	}
+
+	// 5️⃣ Generate key
+	secret, err := token.GenerateRandomStringURLSafe(apiKeySize)
+	if err != nil {
+		return InternalServerError("failed to generate api key")
+	}
+	key := apiKeyPrefix + strings.TrimRight(secret, "=")
+
+	var expiresAt pgtype.Timestamptz
+	if req.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	// 6️⃣ Store only the hash
+	apiKey, err := server.store.CreateAPIKey(
+		c.Context(),
+		pgdb.CreateAPIKeyParams{
+			InstituteID: payload.InstituteID,
+			Name:        req.Name,
+			Prefix:      key[:apiKeyDisplayLength],
+			KeyHash:     token.GetTokenHash(key),
+			Scopes:      scopes,
+			CreatedBy:   pgtype.Int4{Int32: int32(payload.ID), Valid: true},
+			ExpiresAt:   expiresAt,
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 7️⃣ The plain key is only returned once
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"key":     key,
+		"api_key": newAPIKeyResponse(apiKey),
+	})
+}
+
+func (server *Server) getAPIKeys(c *fiber.Ctx) error {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	keys, err := server.store.GetAPIKeysByInstitute(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	response := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, newAPIKeyResponse(key))
+	}
+
+	return c.JSON(response)
+}
+
+func (server *Server) revokeAPIKey(c *fiber.Ctx) error {
+
+	// 1️⃣ Read key ID
+	keyID, err := c.ParamsInt("id")
+	if err != nil || keyID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid api key id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Revoke (same institute only)
+	apiKey, err := server.store.RevokeAPIKey(
+		c.Context(),
+		pgdb.RevokeAPIKeyParams{
+			ID:          int32(keyID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("api key not found or already revoked")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(newAPIKeyResponse(apiKey))
+}
+
+// verifyAPIKey turns a valid API key into a token payload carrying its scopes
+func (server *Server) verifyAPIKey(c *fiber.Ctx, key string) (*token.TokenPayload, error) {
+	apiKey, err := server.store.GetActiveAPIKeyByHash(c.Context(), token.GetTokenHash(key))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return nil, fiber.NewError(
+				fiber.StatusUnauthorized,
+				"invalid, expired or revoked api key",
+			)
+		}
+		return nil, InternalServerError(err.Error())
+	}
+
+	if err := server.store.TouchAPIKey(c.Context(), apiKey.ID); err != nil {
+		log.Printf("failed to update last_used_at of api key %d: %v", apiKey.ID, err)
+	}
+
+	return &token.TokenPayload{
+		Role:        RoleAPIKey,
+		Name:        apiKey.Name,
+		InstituteID: apiKey.InstituteID,
+		IssuedAt:    apiKey.CreatedAt.Time,
+		ExpiredAt:   apiKey.ExpiresAt.Time,
+		APIKeyID:    apiKey.ID,
+		Scopes:      apiKey.Scopes,
+	}, nil
+}
+
+// resolveAPIKeyScopes expands presets and rejects scopes an API key may not
+// carry or the creating role does not have
+func resolveAPIKeyScopes(requested []string, creatorRole string) ([]string, error) {
+	scopes := make([]string, 0, len(apiKeyScopes))
+
+	add := func(perm Permission) {
+		if !slices.Contains(scopes, string(perm)) {
+			scopes = append(scopes, string(perm))
+		}
+	}
+
+	for _, scope := range requested {
+		if scope == scopeReadOnly {
+			for _, perm := range apiKeyScopes {
+				if strings.HasSuffix(string(perm), ":read") && hasPermission(creatorRole, perm) {
+					add(perm)
+				}
+			}
+			continue
+		}
+
+		perm := Permission(scope)
+		if !slices.Contains(apiKeyScopes, perm) {
+			return nil, fiber.NewError(
+				fiber.StatusBadRequest,
+				"scope not allowed for api keys: "+scope,
+			)
+		}
+		if !hasPermission(creatorRole, perm) {
+			return nil, fiber.NewError(
+				fiber.StatusForbidden,
+				"cannot grant a scope you do not have: "+scope,
+			)
+		}
+		add(perm)
+	}
+
+	return scopes, nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"dashboard/token"
 	"fmt"
 	"strings"
 
@@ -40,22 +41,44 @@ func (server *Server) authMiddleware(c *fiber.Ctx) error {
 		}
 	}
 
-	// 4️⃣ Verify token
-	payload, err := server.token.VerifyToken(accessToken)
-	if err != nil {
-		return &fiber.Error{
-			Code:    fiber.StatusUnauthorized,
-			Message: "invalid or expired token",
+	// 4️⃣ API keys (machine clients) carry their own scopes and revocation
+	var payload *token.TokenPayload
+	if strings.HasPrefix(accessToken, apiKeyPrefix) {
+		apiKeyPayload, err := server.verifyAPIKey(c, accessToken)
+		if err != nil {
+			return err
+		}
+		payload = apiKeyPayload
+	} else {
+		// 5️⃣ Verify token and reject revoked ones
+		tokenPayload, err := server.token.VerifyToken(accessToken)
+		if err != nil {
+			return &fiber.Error{
+				Code:    fiber.StatusUnauthorized,
+				Message: "invalid or expired token",
+			}
+		}
+
+		revoked, err := server.revoked.IsRevoked(c.Context(), tokenPayload)
+		if err != nil {
+			return InternalServerError("failed to check token revocation")
+		}
+		if revoked {
+			return &fiber.Error{
+				Code:    fiber.StatusUnauthorized,
+				Message: "token has been revoked",
+			}
 		}
+		payload = tokenPayload
 	}
 
-	// 5️⃣ Store payload data in context
+	// 6️⃣ Store payload data in context
 	c.Locals("user_id", payload.ID)
 	c.Locals("email", payload.Email)
 	c.Locals("role", payload.Role)
 	c.Locals("name", payload.Name)
 	c.Locals(TokenPayloadKey, payload)
 
-	// 6️⃣ Continue request
+	// 7️⃣ Continue request
 	return c.Next()
 }
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"dashboard/db/pgdb"
+	"dashboard/mailer"
+	"dashboard/password"
+	"dashboard/token"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const emailChangeTokenSize = 32
+
+type ChangeMyEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangeUserEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// emailChangeUser is the part of a user an email change needs
+type emailChangeUser struct {
+	ID    int32
+	Name  string
+	Email string
+}
+
+func (server *Server) requestMyEmailChange(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req ChangeMyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Reload user (must still be active)
+	user, err := server.store.LoginUser(
+		c.Context(),
+		pgdb.LoginUserParams{
+			ID: int32(payload.ID),
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Re-authenticate with the current password
+	if _, err := password.CheckPassword(req.Password, user.Password); err != nil {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"password is incorrect",
+		)
+	}
+
+	return server.startEmailChange(c, emailChangeUser{ID: user.ID, Name: user.Name, Email: user.Email}, req.NewEmail, payload)
+}
+
+// requestUserEmailChange lets user managers fix a user's email; the new
+// address still has to confirm
+func (server *Server) requestUserEmailChange(c *fiber.Ctx) error {
+
+	// 1️⃣ Read user ID from URL
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+
+	// 2️⃣ Parse request body
+	var req ChangeUserEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 3️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 4️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 5️⃣ Fetch user (self, or Institute scoped)
+	user, err := server.targetUser(c.Context(), payload, int32(userID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return server.startEmailChange(c, emailChangeUser{ID: user.ID, Name: user.Name, Email: user.Email}, req.NewEmail, payload)
+}
+
+// startEmailChange mails a confirmation link to the new address and a notice
+// to the current one. users.email changes only on confirmation.
+func (server *Server) startEmailChange(c *fiber.Ctx, user emailChangeUser, newEmail string, payload *token.TokenPayload) error {
+	if strings.EqualFold(newEmail, user.Email) {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"new email is the same as the current one",
+		)
+	}
+
+	// 1️⃣ New address must be free (checked again on confirmation)
+	existing, err := server.store.GetExistingUserEmails(c.Context(), []string{newEmail})
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if len(existing) > 0 {
+		return fiber.NewError(
+			fiber.StatusConflict,
+			"email already exists",
+		)
+	}
+
+	// 2️⃣ Only the latest request stays usable
+	if err := server.store.InvalidateUserEmailChangeRequests(c.Context(), user.ID); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 3️⃣ Generate single-use token
+	changeToken, hash, err := token.GenerateTokenAndHash(emailChangeTokenSize)
+	if err != nil {
+		return InternalServerError("failed to generate confirmation token")
+	}
+
+	request, err := server.store.CreateEmailChangeRequest(
+		c.Context(),
+		pgdb.CreateEmailChangeRequestParams{
+			UserID:      user.ID,
+			NewEmail:    newEmail,
+			TokenHash:   hash,
+			RequestedBy: pgtype.Int4{Int32: int32(payload.ID), Valid: payload.ID != 0},
+			ExpiresAt: pgtype.Timestamptz{
+				Time:  time.Now().Add(server.config.EmailChangeDuration),
+				Valid: true,
+			},
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Confirmation to the new address
+	err = server.mailer.Send(c.Context(), mailer.Message{
+		To:      []string{newEmail},
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\r\n\r\nUse the link below to confirm this address for your dashboard account. It expires in %s.\r\n\r\n%s\r\n\r\nIf you did not request this, you can ignore this email.\r\n",
+			user.Name,
+			server.config.EmailChangeDuration,
+			server.emailChangeLink(changeToken),
+		),
+	})
+	if err != nil {
+		log.Printf("failed to send email change confirmation for user %d: %v", user.ID, err)
+	}
+
+	// 5️⃣ Notice to the current address
+	err = server.mailer.Send(c.Context(), mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Hi %s,\r\n\r\nA change of your dashboard email address to %s was requested. It takes effect once the new address is confirmed.\r\n\r\nIf this was not you, change your password and contact your admin.\r\n",
+			user.Name,
+			newEmail,
+		),
+	})
+	if err != nil {
+		log.Printf("failed to send email change notice to user %d: %v", user.ID, err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":    "confirmation sent to the new email address",
+		"new_email":  request.NewEmail,
+		"expires_at": request.ExpiresAt,
+	})
+}
+
+func (server *Server) confirmEmailChange(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req ConfirmEmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	invalidToken := fiber.NewError(
+		fiber.StatusBadRequest,
+		"invalid or expired confirmation token",
+	)
+
+	// 3️⃣ Look up request
+	request, err := server.store.GetEmailChangeRequestByHash(c.Context(), token.GetTokenHash(req.Token))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidToken
+		}
+		return InternalServerError(err.Error())
+	}
+
+	if request.UsedAt.Valid || time.Now().After(request.ExpiresAt.Time) {
+		return invalidToken
+	}
+
+	// 4️⃣ Consume request and swap email
+	user, err := server.store.ChangeEmailTx(
+		c.Context(),
+		pgdb.ChangeEmailTxParams{
+			RequestID: request.ID,
+			UserID:    request.UserID,
+			NewEmail:  request.NewEmail,
+		},
+	)
+	if err != nil {
+		switch pgdb.ErrorCode(err) {
+		case pgdb.ErrorNoRow:
+			return invalidToken
+		case pgdb.ErrorDuplicateKey:
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"email already exists",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Tokens carry the old email, sign out everywhere
+	if err := server.revokeUserAccess(c.Context(), user.ID); err != nil {
+		return InternalServerError(err.Error())
+	}
+	server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventEmailChanged, user.Email, "")
+
+	return c.JSON(fiber.Map{
+		"message": "email changed, please log in with the new address",
+		"id":      user.ID,
+		"email":   user.Email,
+	})
+}
+
+func (server *Server) emailChangeLink(changeToken string) string {
+	return tokenLink(server.config.EmailChangeURL, changeToken)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runHousekeeping periodically cleans up expired auth state
+func (server *Server) runHousekeeping(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			server.revoked.prune(ctx)
+			server.loginThrottle.prune(ctx)
+			if err := server.store.DeleteExpiredLoginChallenges(ctx); err != nil {
+				log.Printf("failed to delete expired login challenges: %v", err)
+			}
+			if err := server.store.DeleteExpiredOIDCLoginStates(ctx); err != nil {
+				log.Printf("failed to delete expired oidc login states: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/password"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CreateInstituteRequest struct {
+	Name     string `json:"name" validate:"required,min=3"`
+	Code     string `json:"code" validate:"required,alphanum"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Phone    string `json:"phone"`
+	Address  string `json:"address"`
+	IsActive *bool  `json:"is_active"`
+}
+
+type UpdateInstituteRequest struct {
+	Name     string `json:"name" validate:"required,min=3"`
+	Code     string `json:"code" validate:"required,alphanum"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Phone    string `json:"phone"`
+	Address  string `json:"address"`
+	IsActive *bool  `json:"is_active"` // nil keeps the current state
+}
+
+// ✅ First admin of a new institute (SUPER ADMIN)
+type CreateInstituteAdminRequest struct {
+	Name     string `json:"name" validate:"required,min=3"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+func (server *Server) createInstitute(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req CreateInstituteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Default to active
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	// 4️⃣ Create institute
+	institute, err := server.store.CreateInstitute(
+		c.Context(),
+		pgdb.CreateInstituteParams{
+			Name:     req.Name,
+			Code:     req.Code,
+			Email:    pgtype.Text{String: req.Email, Valid: req.Email != ""},
+			Phone:    pgtype.Text{String: req.Phone, Valid: req.Phone != ""},
+			Address:  pgtype.Text{String: req.Address, Valid: req.Address != ""},
+			IsActive: pgtype.Bool{Bool: isActive, Valid: true},
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorDuplicateKey {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"institute code already exists",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(institute)
+}
+
+func (server *Server) getInstitutes(c *fiber.Ctx) error {
+	institutes, err := server.store.GetAllInstitutesAnyStatus(c.Context())
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(institutes)
+}
+
+func (server *Server) getInstituteByID(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID
+	instituteID, err := c.ParamsInt("id")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Fetch institute, disabled ones too
+	institute, err := server.store.GetInstituteByIDAnyStatus(c.Context(), int32(instituteID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(institute)
+}
+
+func (server *Server) getInstituteByCode(c *fiber.Ctx) error {
+	institute, err := server.store.GetInstituteByCode(c.Context(), c.Params("code"))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(institute)
+}
+
+func (server *Server) updateInstitute(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID
+	instituteID, err := c.ParamsInt("id")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Parse request body
+	var req UpdateInstituteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 3️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 4️⃣ Update institute (is_active only if given)
+	var isActive pgtype.Bool
+	if req.IsActive != nil {
+		isActive = pgtype.Bool{Bool: *req.IsActive, Valid: true}
+	}
+
+	institute, err := server.store.UpdateInstitute(
+		c.Context(),
+		pgdb.UpdateInstituteParams{
+			ID:       int32(instituteID),
+			Name:     req.Name,
+			Code:     req.Code,
+			Email:    pgtype.Text{String: req.Email, Valid: req.Email != ""},
+			Phone:    pgtype.Text{String: req.Phone, Valid: req.Phone != ""},
+			Address:  pgtype.Text{String: req.Address, Valid: req.Address != ""},
+			IsActive: isActive,
+		},
+	)
+	if err != nil {
+		switch pgdb.ErrorCode(err) {
+		case pgdb.ErrorNoRow:
+			return NotFoundError("institute not found")
+		case pgdb.ErrorDuplicateKey:
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"institute code already exists",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Disabling here locks users out like disableInstitute does
+	if !institute.IsActive.Bool {
+		if err := server.revokeInstituteAccess(c.Context(), institute.ID); err != nil {
+			return InternalServerError(err.Error())
+		}
+	}
+
+	return c.JSON(institute)
+}
+
+// revokeInstituteAccess logs out the users and members of an institute
+func (server *Server) revokeInstituteAccess(ctx context.Context, instituteID int32) error {
+	userIDs, err := server.store.GetInstituteUserIDs(ctx, instituteID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		if err := server.revokeUserAccess(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (server *Server) disableInstitute(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID
+	instituteID, err := c.ParamsInt("id")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Must exist and be active
+	if _, err := server.store.GetInstituteByID(c.Context(), int32(instituteID)); err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 3️⃣ Disable institute
+	if err := server.store.DisableInstitute(c.Context(), int32(instituteID)); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Log out its users and members right away
+	if err := server.revokeInstituteAccess(c.Context(), int32(instituteID)); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "institute disabled successfully",
+		"institute_id": instituteID,
+	})
+}
+
+func (server *Server) deleteInstitute(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID
+	instituteID, err := c.ParamsInt("id")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Delete institute
+	deleted, err := server.store.DeleteInstitute(c.Context(), int32(instituteID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorForeignKey {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"institute still has users or content, disable it instead",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+	if deleted == 0 {
+		return NotFoundError("institute not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "institute deleted successfully",
+		"institute_id": instituteID,
+	})
+}
+
+func (server *Server) createInstituteAdmin(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID
+	instituteID, err := c.ParamsInt("id")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Parse request body
+	var req CreateInstituteAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 3️⃣ Validate request (against the institute password policy)
+	rules, err := server.passwordRulesFor(c.Context(), int32(instituteID), 0, "")
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if validationErrors := server.validateCtx(withPasswordRules(c.Context(), rules), req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 4️⃣ Institute must exist and be active
+	institute, err := server.store.GetInstituteByID(c.Context(), int32(instituteID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Hash password
+	hashedPassword, err := password.HashPassword(req.Password)
+	if err != nil {
+		return InternalServerError("failed to hash password")
+	}
+
+	// 6️⃣ Create admin user
+	user, err := server.store.CreateUser(
+		c.Context(),
+		pgdb.CreateUserParams{
+			InstituteID: institute.ID,
+			Name:        req.Name,
+			Email:       req.Email,
+			Password:    hashedPassword,
+			Role:        pgtype.Text{String: RoleAdmin, Valid: true},
+			IsActive:    pgtype.Bool{Bool: true, Valid: true},
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorDuplicateKey {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"email already exists",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+	server.recordPassword(c.Context(), user.ID, hashedPassword)
+
+	// 7️⃣ Response (NO password)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id":           user.ID,
+		"institute_id": user.InstituteID,
+		"name":         user.Name,
+		"email":        user.Email,
+		"role":         user.Role.String,
+		"is_active":    user.IsActive,
+		"created_at":   user.CreatedAt,
+	})
+}
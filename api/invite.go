@@ -0,0 +1,335 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/mailer"
+	"dashboard/password"
+	"dashboard/token"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const inviteTokenSize = 32
+
+type InviteUserRequest struct {
+	Name  string `json:"name" validate:"required,min=3"`
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,role"`
+}
+
+type AcceptInviteRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+func (server *Server) inviteUser(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req InviteUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 4️⃣ Placeholder password nobody knows, replaced on accept
+	secret, err := token.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		return InternalServerError("failed to generate password")
+	}
+	hashedPassword, err := password.HashPassword(secret)
+	if err != nil {
+		return InternalServerError("failed to hash password")
+	}
+
+	// 5️⃣ Generate single-use invite token
+	inviteToken, hash, err := token.GenerateTokenAndHash(inviteTokenSize)
+	if err != nil {
+		return InternalServerError("failed to generate invite token")
+	}
+
+	// 6️⃣ Create pending user and invite
+	result, err := server.store.InviteUserTx(
+		c.Context(),
+		pgdb.InviteUserTxParams{
+			User: pgdb.CreateUserParams{
+				InstituteID: payload.InstituteID,
+				Name:        req.Name,
+				Email:       req.Email,
+				Password:    hashedPassword,
+				Role:        pgtype.Text{String: req.Role, Valid: true},
+				IsActive:    pgtype.Bool{Bool: false, Valid: true},
+			},
+			TokenHash: hash,
+			InvitedBy: pgtype.Int4{Int32: int32(payload.ID), Valid: true},
+			ExpiresAt: pgtype.Timestamptz{
+				Time:  time.Now().Add(server.config.InviteDuration),
+				Valid: true,
+			},
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorDuplicateKey {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"email already exists",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 7️⃣ Send invite email
+	server.sendInvite(c.Context(), result.User.Name, result.User.Email, inviteToken)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"invite_id":  result.Invite.ID,
+		"user_id":    result.User.ID,
+		"name":       result.User.Name,
+		"email":      result.User.Email,
+		"role":       result.User.Role.String,
+		"expires_at": result.Invite.ExpiresAt,
+	})
+}
+
+func (server *Server) getPendingInvites(c *fiber.Ctx) error {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	invites, err := server.store.GetPendingUserInvitesByInstitute(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(invites)
+}
+
+func (server *Server) resendInvite(c *fiber.Ctx) error {
+
+	// 1️⃣ Read invite ID
+	inviteID, err := c.ParamsInt("id")
+	if err != nil || inviteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid invite id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Invite must still be pending
+	invite, err := server.store.GetPendingUserInvite(
+		c.Context(),
+		pgdb.GetPendingUserInviteParams{
+			ID:          int32(inviteID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("invite not found or already accepted")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ New token and expiry (old link stops working)
+	inviteToken, hash, err := token.GenerateTokenAndHash(inviteTokenSize)
+	if err != nil {
+		return InternalServerError("failed to generate invite token")
+	}
+
+	renewed, err := server.store.RenewUserInvite(
+		c.Context(),
+		pgdb.RenewUserInviteParams{
+			ID:          invite.ID,
+			InstituteID: payload.InstituteID,
+			TokenHash:   hash,
+			ExpiresAt: pgtype.Timestamptz{
+				Time:  time.Now().Add(server.config.InviteDuration),
+				Valid: true,
+			},
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("invite not found or already accepted")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Send invite email
+	server.sendInvite(c.Context(), invite.Name, invite.Email, inviteToken)
+
+	return c.JSON(fiber.Map{
+		"message":    "invite sent",
+		"invite_id":  renewed.ID,
+		"expires_at": renewed.ExpiresAt,
+	})
+}
+
+func (server *Server) revokeInvite(c *fiber.Ctx) error {
+
+	// 1️⃣ Read invite ID
+	inviteID, err := c.ParamsInt("id")
+	if err != nil || inviteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid invite id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Remove invite and pending user
+	userID, err := server.store.RevokeUserInvite(
+		c.Context(),
+		pgdb.RevokeUserInviteParams{
+			ID:          int32(inviteID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("invite not found or already accepted")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "invite revoked",
+		"invite_id": inviteID,
+		"user_id":   userID,
+	})
+}
+
+func (server *Server) acceptInvite(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req AcceptInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	invalidToken := fiber.NewError(
+		fiber.StatusBadRequest,
+		"invalid or expired invite token",
+	)
+
+	// 3️⃣ Look up invite
+	invite, err := server.store.GetUserInviteByHash(c.Context(), token.GetTokenHash(req.Token))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidToken
+		}
+		return InternalServerError(err.Error())
+	}
+
+	if invite.AcceptedAt.Valid || time.Now().After(invite.ExpiresAt.Time) {
+		return invalidToken
+	}
+
+	// 4️⃣ Institute password policy
+	rules, err := server.passwordRulesFor(c.Context(), invite.InstituteID, 0, "")
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if validationErrors := server.validateCtx(withPasswordRules(c.Context(), rules), req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 5️⃣ Hash the invitee's password
+	hashedPassword, err := password.HashPassword(req.Password)
+	if err != nil {
+		return InternalServerError("failed to hash password")
+	}
+
+	// 6️⃣ Consume invite and activate user
+	user, err := server.store.AcceptInviteTx(
+		c.Context(),
+		pgdb.AcceptInviteTxParams{
+			InviteID: invite.ID,
+			UserID:   invite.UserID,
+			Password: hashedPassword,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidToken
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "invite accepted, you can now log in",
+		"id":      user.ID,
+		"email":   user.Email,
+		"name":    user.Name,
+	})
+}
+
+// sendInvite mails the invite link; failures are logged so the admin can resend
+func (server *Server) sendInvite(ctx context.Context, name string, email string, inviteToken string) {
+	err := server.mailer.Send(ctx, mailer.Message{
+		To:      []string{email},
+		Subject: "You have been invited to the dashboard",
+		Body: fmt.Sprintf(
+			"Hi %s,\r\n\r\nYou have been invited to the college dashboard. Use the link below to choose your password. It expires in %s.\r\n\r\n%s\r\n",
+			name,
+			server.config.InviteDuration,
+			server.inviteLink(inviteToken),
+		),
+	})
+	if err != nil {
+		log.Printf("failed to send invite email to %s: %v", email, err)
+	}
+}
+
+func (server *Server) inviteLink(inviteToken string) string {
+	return tokenLink(server.config.InviteURL, inviteToken)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/utils"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// loginThrottle tracks failed logins per email and per IP in Postgres, so
+// the state survives restarts and is shared by every replica.
+type loginThrottle struct {
+	store  pgdb.Store
+	config utils.Config
+}
+
+func newLoginThrottle(store pgdb.Store, config utils.Config) *loginThrottle {
+	return &loginThrottle{store: store, config: config}
+}
+
+func emailThrottleKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// RetryAfter returns how long the caller must wait before trying again,
+// or zero if the login attempt may proceed
+func (t *loginThrottle) RetryAfter(ctx context.Context, email string, ip string) (time.Duration, error) {
+	rows, err := t.store.GetLoginThrottles(ctx, []string{emailThrottleKey(email), ipThrottleKey(ip)})
+	if err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	for _, row := range rows {
+		if !row.BlockedUntil.Valid {
+			continue
+		}
+		if d := time.Until(row.BlockedUntil.Time); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// RecordFailure counts a failed attempt and blocks the key with exponential
+// backoff. It reports whether the email reached the lockout threshold.
+func (t *loginThrottle) RecordFailure(ctx context.Context, email string, ip string) (bool, error) {
+	emailLocked, err := t.recordFailure(ctx, emailThrottleKey(email), t.config.LoginMaxFailures)
+	if err != nil {
+		return false, err
+	}
+	if _, err := t.recordFailure(ctx, ipThrottleKey(ip), t.config.LoginIPMaxFailures); err != nil {
+		return false, err
+	}
+	return emailLocked, nil
+}
+
+func (t *loginThrottle) recordFailure(ctx context.Context, key string, maxFailures int) (bool, error) {
+	row, err := t.store.RecordLoginFailure(ctx, pgdb.RecordLoginFailureParams{
+		Key:         key,
+		WindowStart: pgtype.Timestamptz{Time: time.Now().Add(-t.config.LoginFailureWindow), Valid: true},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	failures := int(row.Failures)
+	locked := failures >= maxFailures
+
+	delay := t.config.LoginLockoutDuration
+	if !locked {
+		delay = t.backoff(failures)
+	}
+
+	err = t.store.SetLoginBlockedUntil(ctx, pgdb.SetLoginBlockedUntilParams{
+		Key:          key,
+		BlockedUntil: pgtype.Timestamptz{Time: time.Now().Add(delay), Valid: true},
+	})
+	return locked, err
+}
+
+// backoff doubles the wait for every failure: base, 2*base, 4*base, ... capped at max
+func (t *loginThrottle) backoff(failures int) time.Duration {
+	delay := t.config.LoginBackoffBase
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= t.config.LoginBackoffMax {
+			return t.config.LoginBackoffMax
+		}
+	}
+	return delay
+}
+
+// RecordSuccess clears the email counter. The IP counter is kept so one
+// valid account cannot be used to reset throttling for a guessing client.
+func (t *loginThrottle) RecordSuccess(ctx context.Context, email string) error {
+	return t.store.ResetLoginThrottle(ctx, emailThrottleKey(email))
+}
+
+func (t *loginThrottle) prune(ctx context.Context) {
+	err := t.store.DeleteStaleLoginThrottles(
+		ctx,
+		pgtype.Timestamptz{Time: time.Now().Add(-t.config.LoginFailureWindow), Valid: true},
+	)
+	if err != nil {
+		log.Printf("failed to delete stale login throttles: %v", err)
+	}
+}
+
+func tooManyLoginAttempts(c *fiber.Ctx, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return fiber.NewError(
+		fiber.StatusTooManyRequests,
+		"too many failed login attempts, try again in "+strconv.Itoa(seconds)+" seconds",
+	)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"dashboard/utils"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	throttle := newLoginThrottle(nil, utils.Config{
+		LoginBackoffBase: time.Second,
+		LoginBackoffMax:  time.Minute,
+	})
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{8, time.Minute},
+		{1000, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := throttle.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottleKeys(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"user@example.com", "email:user@example.com"},
+		{"  User@Example.COM ", "email:user@example.com"},
+	}
+
+	for _, tt := range tests {
+		if got := emailThrottleKey(tt.email); got != tt.want {
+			t.Errorf("emailThrottleKey(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+	if got := ipThrottleKey("203.0.113.7"); got != "ip:203.0.113.7" {
+		t.Errorf("ipThrottleKey = %q", got)
+	}
+}
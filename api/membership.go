@@ -0,0 +1,424 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/mailer"
+	"dashboard/token"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SwitchInstituteRequest struct {
+	InstituteID int32 `json:"institute_id" validate:"required,min=1"`
+}
+
+type AddMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,role"`
+}
+
+// instituteRole is the user's role in instituteID: the users row for the home
+// institute, a membership otherwise. Fails with ErrorNoRow when the user has
+// no (active) access to the institute.
+func (server *Server) instituteRole(ctx context.Context, user pgdb.User, instituteID int32) (string, error) {
+	if user.InstituteID.Valid && instituteID == user.InstituteID.Int32 {
+		if _, err := server.store.GetInstituteByID(ctx, instituteID); err != nil {
+			return "", err
+		}
+		return user.Role.String, nil
+	}
+
+	return server.store.GetInstituteMembershipRole(ctx, pgdb.GetInstituteMembershipRoleParams{
+		UserID:      user.ID,
+		InstituteID: instituteID,
+	})
+}
+
+// errTwoFactorRequired stops a refresh into an institute that requires 2FA
+// when the user has no TOTP, e.g. after the policy was turned on
+var errTwoFactorRequired = errors.New("institute requires two-factor authentication")
+
+// sessionInstitute resolves the institute and role a refresh session issues
+// access tokens for
+func (server *Server) sessionInstitute(ctx context.Context, user pgdb.User, session pgdb.Session) (int32, string, error) {
+	instituteID, role := user.InstituteID.Int32, user.Role.String
+	if session.InstituteID.Valid {
+		var err error
+		instituteID = session.InstituteID.Int32
+		role, err = server.instituteRole(ctx, user, instituteID)
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	required, err := server.isTOTPRequired(ctx, instituteID)
+	if err != nil {
+		return 0, "", err
+	}
+	if required {
+		enabled, err := server.isTOTPEnabled(ctx, user.ID)
+		if err != nil {
+			return 0, "", err
+		}
+		if !enabled {
+			return 0, "", errTwoFactorRequired
+		}
+	}
+	return instituteID, role, nil
+}
+
+// targetUser loads the user a handler acts on: another user of the caller's
+// institute, or the caller themselves. After switching to a membership
+// institute the caller's row still lives in the home institute, so self
+// lookups are not scoped to the token's institute.
+func (server *Server) targetUser(ctx context.Context, payload *token.TokenPayload, userID int32) (pgdb.User, error) {
+	if payload.ID != 0 && int64(userID) == payload.ID {
+		return server.store.GetUserByIDAnyInstitute(ctx, userID)
+	}
+
+	return server.store.GetUserByID(ctx, pgdb.GetUserByIDParams{
+		ID:          userID,
+		InstituteID: payload.InstituteID,
+	})
+}
+
+func (server *Server) switchInstitute(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req SwitchInstituteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload (API keys are bound to one institute)
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok || payload.ID == 0 {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 4️⃣ Reload user (must still be active)
+	user, err := server.store.LoginUser(
+		c.Context(),
+		pgdb.LoginUserParams{
+			ID: int32(payload.ID),
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"your account is disabled, please contact admin",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Must have access to the chosen institute
+	if _, err := server.instituteRole(c.Context(), user, req.InstituteID); err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusForbidden,
+				"you are not a member of this institute",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 6️⃣ Second factor, as a login to that institute would ask for it
+	totpEnabled, err := server.isTOTPEnabled(c.Context(), user.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	require2FA, err := server.isTOTPRequired(c.Context(), req.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if totpEnabled || require2FA {
+		return server.startLoginChallenge(
+			c,
+			user,
+			!totpEnabled,
+			pgtype.Int4{Int32: req.InstituteID, Valid: true},
+		)
+	}
+
+	// 7️⃣ New token and session scoped to the institute
+	return server.issueSwitch(c, user, req.InstituteID, nil)
+}
+
+// issueSwitch issues tokens scoped to instituteID once the switch has passed
+// any second factor; the membership is looked up again since a challenge
+// may have taken a while
+func (server *Server) issueSwitch(c *fiber.Ctx, user pgdb.User, instituteID int32, recoveryCodes []string) error {
+	role, err := server.instituteRole(c.Context(), user, instituteID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusForbidden,
+				"you are not a member of this institute",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return server.issueTokens(c, user, instituteID, role, recoveryCodes)
+}
+
+func (server *Server) getInstituteMembers(c *fiber.Ctx) error {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	members, err := server.store.GetInstituteMembers(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(members)
+}
+
+// addInstituteMember invites an existing user of another institute to a role
+// in the caller's institute (or changes the role of a member). The membership
+// grants nothing until the user accepts it, and the response is the same
+// whether or not the email belongs to anyone.
+func (server *Server) addInstituteMember(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req AddMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	accepted := func() error {
+		return c.Status(fiber.StatusAccepted).JSON(msgResponse{
+			Msg: "if the email belongs to a user of another institute, they have been invited",
+		})
+	}
+
+	// 4️⃣ Look up user (emails are unique across institutes)
+	user, err := server.store.GetUserByEmailAnyInstitute(c.Context(), req.Email)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return accepted()
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// super admins and disabled users can't be members; don't say so
+	if !user.InstituteID.Valid || !user.IsActive.Bool {
+		return accepted()
+	}
+	// the caller can already see the users of their own institute
+	if user.InstituteID.Int32 == payload.InstituteID {
+		return fiber.NewError(
+			fiber.StatusConflict,
+			"user already belongs to this institute",
+		)
+	}
+
+	// 5️⃣ Add a pending membership or update the role
+	membership, err := server.store.UpsertInstituteMembership(
+		c.Context(),
+		pgdb.UpsertInstituteMembershipParams{
+			UserID:      user.ID,
+			InstituteID: payload.InstituteID,
+			Role:        req.Role,
+			AddedBy:     pgtype.Int4{Int32: int32(payload.ID), Valid: payload.ID != 0},
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 6️⃣ Ask the user to accept
+	if !membership.AcceptedAt.Valid {
+		institute, err := server.store.GetInstituteByID(c.Context(), payload.InstituteID)
+		if err != nil {
+			return InternalServerError(err.Error())
+		}
+		server.sendMembershipInvite(c.Context(), user, institute, membership.Role)
+	}
+
+	return accepted()
+}
+
+func (server *Server) sendMembershipInvite(ctx context.Context, user pgdb.User, institute pgdb.Institute, role string) {
+	err := server.mailer.Send(ctx, mailer.Message{
+		To:      []string{user.Email},
+		Subject: "You have been invited to " + institute.Name,
+		Body: fmt.Sprintf(
+			"Hi %s,\r\n\r\nYou have been invited to %s (%s) as %s. Sign in to the dashboard and accept or decline the invitation under your memberships. Nothing changes until you accept.\r\n",
+			user.Name,
+			institute.Name,
+			institute.Code,
+			role,
+		),
+	})
+	if err != nil {
+		log.Printf("failed to send membership invite to %s: %v", user.Email, err)
+	}
+}
+
+func (server *Server) removeInstituteMember(c *fiber.Ctx) error {
+
+	// 1️⃣ Read user ID from URL
+	userID, err := c.ParamsInt("userId")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Remove membership (the home institute is not a membership)
+	removed, err := server.store.DeleteInstituteMembership(
+		c.Context(),
+		pgdb.DeleteInstituteMembershipParams{
+			UserID:      int32(userID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if removed == 0 {
+		return NotFoundError("membership not found")
+	}
+
+	// 4️⃣ Tokens scoped to the institute must stop working now
+	if err := server.revokeUserAccess(c.Context(), int32(userID)); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(msgResponse{Msg: "member removed, they have been signed out"})
+}
+
+func (server *Server) getMyPendingMemberships(c *fiber.Ctx) error {
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	memberships, err := server.store.GetPendingMemberships(c.Context(), int32(payload.ID))
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(memberships)
+}
+
+func (server *Server) acceptMyMembership(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID from URL
+	instituteID, err := c.ParamsInt("instituteId")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 3️⃣ Accept the pending membership
+	accepted, err := server.store.AcceptInstituteMembership(
+		c.Context(),
+		pgdb.AcceptInstituteMembershipParams{
+			UserID:      int32(payload.ID),
+			InstituteID: int32(instituteID),
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if accepted == 0 {
+		return NotFoundError("invitation not found")
+	}
+
+	return c.JSON(msgResponse{Msg: "membership accepted, you can now switch to this institute"})
+}
+
+func (server *Server) declineMyMembership(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID from URL
+	instituteID, err := c.ParamsInt("instituteId")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 3️⃣ Drop the pending membership
+	declined, err := server.store.DeclineInstituteMembership(
+		c.Context(),
+		pgdb.DeclineInstituteMembershipParams{
+			UserID:      int32(payload.ID),
+			InstituteID: int32(instituteID),
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if declined == 0 {
+		return NotFoundError("invitation not found")
+	}
+
+	return c.JSON(msgResponse{Msg: "invitation declined"})
+}
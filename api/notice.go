@@ -14,6 +14,8 @@ type CreateNoticeRequest struct {
 	Description string     `json:"description"`
 	IsPublished bool       `json:"is_published"`
 	PublishDate *time.Time `json:"publish_date"`
+	PublishAt   string     `json:"publish_at"` // institute timezone unless it has an offset
+	ExpireAt    string     `json:"expire_at"`
 }
 
 type UpdateNoticeRequest struct {
@@ -21,6 +23,9 @@ type UpdateNoticeRequest struct {
 	Description string     `json:"description"`
 	IsPublished *bool      `json:"is_published"`
 	PublishDate *time.Time `json:"publish_date"` // YYYY-MM-DD
+	// absent keeps the stored time, null or "" clears it
+	PublishAt optionalTime `json:"publish_at"`
+	ExpireAt  optionalTime `json:"expire_at"`
 }
 
 func (server *Server) createNotice(c *fiber.Ctx) error {
@@ -48,12 +53,15 @@ func (server *Server) createNotice(c *fiber.Ctx) error {
 		)
 	}
 
-	// 4️⃣ Admin-only access
-	if payload.Role != "admin" {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"admin access required",
-		)
+	// 4️⃣ Publish window in the institute's timezone
+	loc, err := server.instituteLocation(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	schedule, err := resolveNoticeSchedule(loc, req.IsPublished, req.PublishAt, req.ExpireAt, req.PublishDate)
+	if err != nil {
+		return err
 	}
 
 	// 5️⃣ Create notice
@@ -64,15 +72,10 @@ func (server *Server) createNotice(c *fiber.Ctx) error {
 			Title:       req.Title,
 			Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
 			IsPublished: pgtype.Bool{Bool: req.IsPublished, Valid: true},
-			PublishDate: pgtype.Date{
-				Time: func() time.Time {
-					if req.PublishDate != nil {
-						return *req.PublishDate
-					}
-					return time.Time{}
-				}(),
-				Valid: req.PublishDate != nil,
-			},
+			PublishDate: schedule.PublishDate,
+			PublishAt:   schedule.PublishAt,
+			ExpireAt:    schedule.ExpireAt,
+			Status:      schedule.Status,
 		},
 	)
 	if err != nil {
@@ -80,15 +83,7 @@ func (server *Server) createNotice(c *fiber.Ctx) error {
 	}
 
 	// 6️⃣ Response
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"id":           notice.ID,
-		"institute_id": notice.InstituteID,
-		"title":        notice.Title,
-		"description":  notice.Description,
-		"is_published": notice.IsPublished,
-		"publish_date": notice.PublishDate,
-		"created_at":   notice.CreatedAt,
-	})
+	return c.Status(fiber.StatusCreated).JSON(newNoticeResponse(notice, nil, loc))
 }
 
 func (server *Server) getNoticeByID(c *fiber.Ctx) error {
@@ -126,16 +121,23 @@ func (server *Server) getNoticeByID(c *fiber.Ctx) error {
 		return InternalServerError(err.Error())
 	}
 
-	// 4️⃣ Response
-	return c.JSON(fiber.Map{
-		"id":           notice.ID,
-		"institute_id": notice.InstituteID,
-		"title":        notice.Title,
-		"description":  notice.Description,
-		"is_published": notice.IsPublished,
-		"publish_date": notice.PublishDate,
-		"created_at":   notice.CreatedAt,
-	})
+	// 4️⃣ Only notice writers see notices that are not live
+	if notice.Status != NoticeLive && !payloadHasPermission(payload, PermNoticeWrite) {
+		return NotFoundError("notice not found")
+	}
+
+	loc, err := server.instituteLocation(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	attachments, err := server.store.GetNoticeAttachments(c.Context(), notice.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Response
+	return c.JSON(newNoticeResponse(notice, attachments, loc))
 }
 
 func (server *Server) getNoticesByInstitute(c *fiber.Ctx) error {
@@ -149,31 +151,42 @@ func (server *Server) getNoticesByInstitute(c *fiber.Ctx) error {
 		)
 	}
 
-	// 2️⃣ Fetch notices
+	// 2️⃣ State filter (?status=, live by default)
+	status, err := noticeStatusFilter(c, payload)
+	if err != nil {
+		return err
+	}
+
+	// 3️⃣ Fetch notices
 	notices, err := server.store.GetNoticesByInstitute(
 		c.Context(),
-		payload.InstituteID,
+		pgdb.GetNoticesByInstituteParams{
+			InstituteID: payload.InstituteID,
+			Status:      status,
+		},
 	)
 	if err != nil {
 		return InternalServerError(err.Error())
 	}
 
-	// 3️⃣ Build response
+	loc, err := server.instituteLocation(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	attachments, err := server.noticeAttachments(c.Context(), notices)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Build response
 	response := make([]fiber.Map, 0, len(notices))
 
 	for _, notice := range notices {
-		response = append(response, fiber.Map{
-			"id":           notice.ID,
-			"institute_id": notice.InstituteID,
-			"title":        notice.Title,
-			"description":  notice.Description,
-			"is_published": notice.IsPublished,
-			"publish_date": notice.PublishDate,
-			"created_at":   notice.CreatedAt,
-		})
-	}
-
-	// 4️⃣ Return response
+		response = append(response, newNoticeResponse(notice, attachments[notice.ID], loc))
+	}
+
+	// 5️⃣ Return response
 	return c.JSON(response)
 }
 
@@ -211,47 +224,63 @@ func (server *Server) updateNotice(c *fiber.Ctx) error {
 		)
 	}
 
-	// 🔐 ADMIN CHECK
-	if payload.Role != "admin" {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"admin access required",
-		)
+	// 5️⃣ Fetch notice (Institute scoped)
+	existing, err := server.store.GetNotice(
+		c.Context(),
+		pgdb.GetNoticeParams{
+			ID:          int32(noticeID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("notice not found")
+		}
+		return InternalServerError(err.Error())
 	}
 
-	// 5️⃣ Convert description to pgtype.Text
+	// 6️⃣ Convert description to pgtype.Text
 	desc := pgtype.Text{
 		String: req.Description,
 		Valid:  req.Description != "",
 	}
 
-	// 6️⃣ Convert is_published to pgtype.Bool
-	isPublished := pgtype.Bool{Valid: false}
+	// 7️⃣ is_published stays as it is unless given
+	isPublished := pgtype.Bool{Bool: !existing.IsPublished.Valid || existing.IsPublished.Bool, Valid: true}
 	if req.IsPublished != nil {
-		isPublished = pgtype.Bool{
-			Bool:  *req.IsPublished,
-			Valid: true,
-		}
+		isPublished.Bool = *req.IsPublished
 	}
 
-	// 7️⃣ Convert publish_date to pgtype.Date
-	publishDate := pgtype.Date{Valid: false}
-	if req.PublishDate != nil {
-		publishDate = pgtype.Date{
-			Time:  *req.PublishDate,
-			Valid: true,
-		}
+	// 8️⃣ Publish window in the institute's timezone
+	loc, err := server.instituteLocation(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// publish_date given on its own still replaces publish_at
+	publishAt := req.PublishAt.Value
+	if req.PublishDate == nil {
+		publishAt = req.PublishAt.orStored(existing.PublishAt)
+	}
+
+	schedule, err := resolveNoticeSchedule(loc, isPublished.Bool, publishAt, req.ExpireAt.orStored(existing.ExpireAt), req.PublishDate)
+	if err != nil {
+		return err
 	}
 
-	// 8️⃣ Update notice in DB
+	// 9️⃣ Update notice in DB
 	notice, err := server.store.UpdateNotice(
 		c.Context(),
 		pgdb.UpdateNoticeParams{
-			ID:          int32(noticeID),
+			ID:          existing.ID,
+			InstituteID: payload.InstituteID,
 			Title:       req.Title,
 			Description: desc,
 			IsPublished: isPublished,
-			PublishDate: publishDate,
+			PublishDate: schedule.PublishDate,
+			PublishAt:   schedule.PublishAt,
+			ExpireAt:    schedule.ExpireAt,
+			Status:      schedule.Status,
 		},
 	)
 	if err != nil {
@@ -261,24 +290,13 @@ func (server *Server) updateNotice(c *fiber.Ctx) error {
 		return InternalServerError(err.Error())
 	}
 
-	// 9️⃣ Institute ownership check (SECURITY)
-	if notice.InstituteID != payload.InstituteID {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"you are not allowed to update this notice",
-		)
+	attachments, err := server.store.GetNoticeAttachments(c.Context(), notice.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
 	}
 
 	// 🔟 Response
-	return c.JSON(fiber.Map{
-		"id":           notice.ID,
-		"institute_id": notice.InstituteID,
-		"title":        notice.Title,
-		"description":  notice.Description.String,
-		"is_published": notice.IsPublished.Bool,
-		"publish_date": notice.PublishDate.Time,
-		"created_at":   notice.CreatedAt,
-	})
+	return c.JSON(newNoticeResponse(notice, attachments, loc))
 }
 
 func (server *Server) deleteNotice(c *fiber.Ctx) error {
@@ -301,15 +319,7 @@ func (server *Server) deleteNotice(c *fiber.Ctx) error {
 		)
 	}
 
-	// 🔐 3️⃣ Admin-only access
-	if payload.Role != "admin" {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"admin access required",
-		)
-	}
-
-	// 4️⃣ Fetch notice first (SECURITY CHECK)
+	// 3️⃣ Fetch notice first (SECURITY CHECK)
 	notice, err := server.store.GetNotice(
 		c.Context(),
 		pgdb.GetNoticeParams{
@@ -324,7 +334,13 @@ func (server *Server) deleteNotice(c *fiber.Ctx) error {
 		return InternalServerError(err.Error())
 	}
 
-	// 5️⃣ Delete notice
+	// 4️⃣ Attachment files go with the notice
+	attachments, err := server.store.GetNoticeAttachments(c.Context(), notice.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Delete notice (attachment rows cascade)
 	if err := server.store.DeleteNotice(
 		c.Context(),
 		notice.ID,
@@ -332,7 +348,12 @@ func (server *Server) deleteNotice(c *fiber.Ctx) error {
 		return InternalServerError(err.Error())
 	}
 
-	// 6️⃣ Success response
+	// 6️⃣ Delete attachment files
+	for _, attachment := range attachments {
+		server.deleteAttachmentFile(c.Context(), attachment.PublicID, attachment.ResourceType)
+	}
+
+	// 7️⃣ Success response
 	return c.JSON(fiber.Map{
 		"message":   "notice deleted successfully",
 		"notice_id": notice.ID,
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"dashboard/utils"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	maxAttachmentSize    = 10 << 20 // 10 MB
+	maxNoticeAttachments = 10
+	maxAttachmentName    = 200
+
+	// body limit of an upload request: a full-size file plus the multipart
+	// overhead; every other route keeps the app's BodyLimit
+	maxAttachmentBody = maxAttachmentSize + 2<<20
+)
+
+// attachmentUploadURI matches POST /notices/:id/attachments and
+// avatarUploadURI PUT /me/avatar in the raw request URI (origin or absolute
+// form, with or without a query)
+var (
+	attachmentUploadURI = regexp.MustCompile(`^(https?://[^/]+)?/notices/[^/?]+/attachments/?(\?|$)`)
+	avatarUploadURI     = regexp.MustCompile(`^(https?://[^/]+)?/me/avatar/?(\?|$)`)
+)
+
+// uploadBodyLimit raises fasthttp's body limit for attachment and avatar
+// uploads. It runs on the request header, before the body is read and before
+// routing.
+func uploadBodyLimit(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	switch {
+	case header.IsPost() && attachmentUploadURI.Match(header.RequestURI()):
+		return fasthttp.RequestConfig{MaxRequestBodySize: maxAttachmentBody}
+	case header.IsPut() && avatarUploadURI.Match(header.RequestURI()):
+		return fasthttp.RequestConfig{MaxRequestBodySize: maxAvatarBody}
+	}
+	return fasthttp.RequestConfig{}
+}
+
+// attachmentType is an allowed attachment, recognised by extension and
+// checked against the sniffed content
+type attachmentType struct {
+	ContentType  string
+	ResourceType string // Cloudinary resource type
+	Sniffed      string // what http.DetectContentType reports
+}
+
+var attachmentTypes = map[string]attachmentType{
+	".pdf":  {"application/pdf", "raw", "application/pdf"},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "raw", "application/zip"},
+	".jpg":  {"image/jpeg", "image", "image/jpeg"},
+	".jpeg": {"image/jpeg", "image", "image/jpeg"},
+	".png":  {"image/png", "image", "image/png"},
+	".webp": {"image/webp", "image", "image/webp"},
+}
+
+type noticeAttachmentResponse struct {
+	ID          int32              `json:"id"`
+	FileName    string             `json:"file_name"`
+	ContentType string             `json:"content_type"`
+	Size        int64              `json:"size"`
+	URL         string             `json:"url"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+func newAttachmentResponses(attachments []pgdb.NoticeAttachment) []noticeAttachmentResponse {
+	response := make([]noticeAttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		response = append(response, noticeAttachmentResponse{
+			ID:          attachment.ID,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.SizeBytes,
+			URL:         attachment.Url,
+			CreatedAt:   attachment.CreatedAt,
+		})
+	}
+	return response
+}
+
+// noticeAttachments loads the attachments of several notices in one query,
+// keyed by notice ID
+func (server *Server) noticeAttachments(ctx context.Context, notices []pgdb.Notice) (map[int32][]pgdb.NoticeAttachment, error) {
+	byNotice := make(map[int32][]pgdb.NoticeAttachment, len(notices))
+	if len(notices) == 0 {
+		return byNotice, nil
+	}
+
+	ids := make([]int32, 0, len(notices))
+	for _, notice := range notices {
+		ids = append(ids, notice.ID)
+	}
+
+	attachments, err := server.store.GetNoticeAttachmentsByNotices(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		byNotice[attachment.NoticeID] = append(byNotice[attachment.NoticeID], attachment)
+	}
+	return byNotice, nil
+}
+
+// checkAttachment enforces the size and type limits; the content has to match
+// the extension, the client's Content-Type is not trusted
+func checkAttachment(fileHeader *multipart.FileHeader) (attachmentType, error) {
+	name := filepath.Base(fileHeader.Filename)
+
+	if fileHeader.Size > maxAttachmentSize {
+		return attachmentType{}, fiber.NewError(
+			fiber.StatusRequestEntityTooLarge,
+			fmt.Sprintf("%s: attachments must be at most 10 MB", name),
+		)
+	}
+	if len(name) > maxAttachmentName {
+		return attachmentType{}, fiber.NewError(
+			fiber.StatusBadRequest,
+			fmt.Sprintf("file names must be at most %d characters", maxAttachmentName),
+		)
+	}
+
+	unsupported := fiber.NewError(
+		fiber.StatusUnsupportedMediaType,
+		fmt.Sprintf("%s: attachments must be PDF, DOCX, JPEG, PNG or WebP", name),
+	)
+
+	kind, ok := attachmentTypes[strings.ToLower(filepath.Ext(name))]
+	if !ok {
+		return attachmentType{}, unsupported
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return attachmentType{}, InternalServerError("failed to open file")
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return attachmentType{}, InternalServerError("failed to read file")
+	}
+	if http.DetectContentType(head[:n]) != kind.Sniffed {
+		return attachmentType{}, unsupported
+	}
+
+	return kind, nil
+}
+
+func tooManyAttachments() error {
+	return fiber.NewError(
+		fiber.StatusBadRequest,
+		fmt.Sprintf("a notice can have at most %d attachments", maxNoticeAttachments),
+	)
+}
+
+func (server *Server) uploadNoticeAttachments(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse notice ID from URL
+	noticeID, err := c.ParamsInt("id")
+	if err != nil || noticeID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid notice id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Fetch notice (Institute scoped)
+	notice, err := server.store.GetNotice(
+		c.Context(),
+		pgdb.GetNoticeParams{
+			ID:          int32(noticeID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("notice not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Files (one or more "file" fields)
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"at least one file is required",
+		)
+	}
+	files := form.File["file"]
+
+	count, err := server.store.CountNoticeAttachments(c.Context(), notice.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if int(count)+len(files) > maxNoticeAttachments {
+		return tooManyAttachments()
+	}
+
+	// 5️⃣ Check every file before uploading any
+	kinds := make([]attachmentType, 0, len(files))
+	for _, fileHeader := range files {
+		kind, err := checkAttachment(fileHeader)
+		if err != nil {
+			return err
+		}
+		kinds = append(kinds, kind)
+	}
+
+	// 6️⃣ Upload to Cloudinary
+	params := make([]pgdb.CreateNoticeAttachmentParams, 0, len(files))
+	discard := func() {
+		for _, arg := range params {
+			server.deleteAttachmentFile(c.Context(), arg.PublicID, arg.ResourceType)
+		}
+	}
+
+	for i, fileHeader := range files {
+		name := filepath.Base(fileHeader.Filename)
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			discard()
+			return InternalServerError("failed to open file")
+		}
+
+		fileURL, publicID, err := utils.UploadFileStream(
+			c.Context(),
+			file,
+			server.config.AttachmentsFolder,
+			name,
+			kinds[i].ResourceType,
+		)
+		file.Close()
+		if err != nil {
+			discard()
+			return InternalServerError("cloudinary upload failed")
+		}
+
+		params = append(params, pgdb.CreateNoticeAttachmentParams{
+			NoticeID:     notice.ID,
+			InstituteID:  payload.InstituteID,
+			FileName:     name,
+			ContentType:  kinds[i].ContentType,
+			SizeBytes:    fileHeader.Size,
+			Url:          fileURL,
+			PublicID:     publicID,
+			ResourceType: kinds[i].ResourceType,
+			UploadedBy:   pgtype.Int4{Int32: int32(payload.ID), Valid: payload.ID != 0},
+		})
+	}
+
+	// 7️⃣ Save all or none; the limit is checked again under a lock, another
+	// upload may have finished meanwhile
+	attachments, err := server.store.AddNoticeAttachmentsTx(
+		c.Context(),
+		pgdb.AddNoticeAttachmentsTxParams{
+			NoticeID:       notice.ID,
+			MaxAttachments: maxNoticeAttachments,
+			Attachments:    params,
+		},
+	)
+	if err != nil {
+		// don't leave the uploads behind
+		discard()
+		if errors.Is(err, pgdb.ErrTooManyAttachments) {
+			return tooManyAttachments()
+		}
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("notice not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(newAttachmentResponses(attachments))
+}
+
+func (server *Server) deleteNoticeAttachment(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse IDs from URL
+	noticeID, err := c.ParamsInt("id")
+	if err != nil || noticeID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid notice id",
+		)
+	}
+	attachmentID, err := c.ParamsInt("attachmentId")
+	if err != nil || attachmentID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid attachment id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Fetch attachment (Institute scoped)
+	attachment, err := server.store.GetNoticeAttachment(
+		c.Context(),
+		pgdb.GetNoticeAttachmentParams{
+			ID:          int32(attachmentID),
+			NoticeID:    int32(noticeID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("attachment not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Delete DB record
+	if err := server.store.DeleteNoticeAttachment(
+		c.Context(),
+		pgdb.DeleteNoticeAttachmentParams{
+			ID:          attachment.ID,
+			InstituteID: payload.InstituteID,
+		},
+	); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Delete file
+	server.deleteAttachmentFile(c.Context(), attachment.PublicID, attachment.ResourceType)
+
+	return c.JSON(fiber.Map{
+		"message":       "attachment deleted successfully",
+		"attachment_id": attachment.ID,
+	})
+}
+
+// deleteAttachmentFile removes an attachment from Cloudinary; a leftover
+// asset is not worth failing the request for
+func (server *Server) deleteAttachmentFile(ctx context.Context, publicID string, resourceType string) {
+	if err := utils.DeleteFile(ctx, publicID, resourceType); err != nil {
+		log.Printf("failed to delete attachment %s: %v", publicID, err)
+	}
+}
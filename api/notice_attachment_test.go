@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestUploadBodyLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		uri    string
+		want   int // 0 keeps the app's BodyLimit
+	}{
+		{"attachment upload", fasthttp.MethodPost, "/notices/12/attachments", maxAttachmentBody},
+		{"trailing slash", fasthttp.MethodPost, "/notices/12/attachments/", maxAttachmentBody},
+		{"with a query", fasthttp.MethodPost, "/notices/12/attachments?lang=en", maxAttachmentBody},
+		{"absolute form", fasthttp.MethodPost, "https://dashboard.example.com/notices/12/attachments", maxAttachmentBody},
+		{"attachment listing", fasthttp.MethodGet, "/notices/12/attachments", 0},
+		{"single attachment", fasthttp.MethodPost, "/notices/12/attachments/3", 0},
+		{"longer path", fasthttp.MethodPost, "/notices/12/attachmentsx", 0},
+		{"nested notice path", fasthttp.MethodPost, "/notices/12/x/attachments", 0},
+		{"prefixed path", fasthttp.MethodPost, "/api/notices/12/attachments", 0},
+		{"path in the query", fasthttp.MethodPost, "/createNotice?next=/notices/12/attachments", 0},
+		{"notice creation", fasthttp.MethodPost, "/createNotice", 0},
+		{"avatar upload", fasthttp.MethodPut, "/me/avatar", maxAvatarBody},
+		{"avatar upload, absolute form", fasthttp.MethodPut, "http://dashboard.example.com/me/avatar?v=2", maxAvatarBody},
+		{"avatar removal", fasthttp.MethodDelete, "/me/avatar", 0},
+		{"avatar post", fasthttp.MethodPost, "/me/avatar", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header fasthttp.RequestHeader
+			header.SetMethod(tt.method)
+			header.SetRequestURI(tt.uri)
+
+			if got := uploadBodyLimit(&header).MaxRequestBodySize; got != tt.want {
+				t.Fatalf("body limit = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	NoticeDraft     = "draft"
+	NoticeScheduled = "scheduled"
+	NoticeLive      = "live"
+	NoticeExpired   = "expired"
+
+	// ?status=all lists notices in every state
+	noticeStatusAll = "all"
+)
+
+// noticeTimeLayouts are accepted for publish_at / expire_at without an
+// offset; such times are read in the institute's timezone
+var noticeTimeLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// optionalTime is a publish_at / expire_at field of an update. Set tells an
+// absent field (keep the stored time) from null or "" (clear it).
+type optionalTime struct {
+	Set   bool
+	Value string
+}
+
+func (t *optionalTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Value = ""
+		return nil
+	}
+	return json.Unmarshal(data, &t.Value)
+}
+
+// orStored is the value to resolve: the request's if given, the stored
+// time otherwise
+func (t optionalTime) orStored(stored pgtype.Timestamptz) string {
+	if t.Set || !stored.Valid {
+		return t.Value
+	}
+	return stored.Time.Format(time.RFC3339Nano)
+}
+
+type InstituteTimezoneRequest struct {
+	Timezone string `json:"timezone" validate:"required,timezone"`
+}
+
+// noticeSchedule is the stored form of a notice's publish window
+type noticeSchedule struct {
+	PublishDate pgtype.Date
+	PublishAt   pgtype.Timestamptz
+	ExpireAt    pgtype.Timestamptz
+	Status      string
+}
+
+// noticeStatus is the state of a notice at now. The scheduler applies the same
+// rules to stored notices as time passes.
+func noticeStatus(isPublished bool, publishAt, expireAt pgtype.Timestamptz, now time.Time) string {
+	switch {
+	case !isPublished:
+		return NoticeDraft
+	case expireAt.Valid && !now.Before(expireAt.Time):
+		return NoticeExpired
+	case publishAt.Valid && now.Before(publishAt.Time):
+		return NoticeScheduled
+	default:
+		return NoticeLive
+	}
+}
+
+// parseNoticeTime reads an RFC 3339 time, or a local time in loc
+func parseNoticeTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	var err error
+	for _, layout := range noticeTimeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// resolveNoticeSchedule turns the publish window of a request into stored
+// values. publish_date is still accepted and means the start of that day.
+func resolveNoticeSchedule(loc *time.Location, isPublished bool, publishAt, expireAt string, publishDate *time.Time) (noticeSchedule, error) {
+	var schedule noticeSchedule
+
+	if publishAt != "" {
+		t, err := parseNoticeTime(publishAt, loc)
+		if err != nil {
+			return noticeSchedule{}, fiber.NewError(
+				fiber.StatusBadRequest,
+				"publish_at must look like 2006-01-02T15:04",
+			)
+		}
+		schedule.PublishAt = pgtype.Timestamptz{Time: t, Valid: true}
+	} else if publishDate != nil {
+		year, month, day := publishDate.Date()
+		schedule.PublishAt = pgtype.Timestamptz{
+			Time:  time.Date(year, month, day, 0, 0, 0, 0, loc),
+			Valid: true,
+		}
+	}
+
+	if expireAt != "" {
+		t, err := parseNoticeTime(expireAt, loc)
+		if err != nil {
+			return noticeSchedule{}, fiber.NewError(
+				fiber.StatusBadRequest,
+				"expire_at must look like 2006-01-02T15:04",
+			)
+		}
+		schedule.ExpireAt = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+
+	if schedule.PublishAt.Valid && schedule.ExpireAt.Valid && !schedule.ExpireAt.Time.After(schedule.PublishAt.Time) {
+		return noticeSchedule{}, fiber.NewError(
+			fiber.StatusBadRequest,
+			"expire_at must be after publish_at",
+		)
+	}
+
+	// publish_date stays the local day the notice goes live
+	if schedule.PublishAt.Valid {
+		year, month, day := schedule.PublishAt.Time.In(loc).Date()
+		schedule.PublishDate = pgtype.Date{
+			Time:  time.Date(year, month, day, 0, 0, 0, 0, time.UTC),
+			Valid: true,
+		}
+	}
+
+	schedule.Status = noticeStatus(isPublished, schedule.PublishAt, schedule.ExpireAt, time.Now())
+	return schedule, nil
+}
+
+// noticeStatusFilter maps ?status= to the listing filter (NULL for all).
+// Only notice writers see notices that are not live.
+func noticeStatusFilter(c *fiber.Ctx, payload *token.TokenPayload) (pgtype.Text, error) {
+	status := c.Query("status", NoticeLive)
+
+	switch status {
+	case NoticeLive:
+		return pgtype.Text{String: status, Valid: true}, nil
+	case NoticeDraft, NoticeScheduled, NoticeExpired, noticeStatusAll:
+		if !payloadHasPermission(payload, PermNoticeWrite) {
+			return pgtype.Text{}, fiber.NewError(
+				fiber.StatusForbidden,
+				"permission denied: requires "+string(PermNoticeWrite),
+			)
+		}
+		if status == noticeStatusAll {
+			return pgtype.Text{}, nil
+		}
+		return pgtype.Text{String: status, Valid: true}, nil
+	default:
+		return pgtype.Text{}, fiber.NewError(
+			fiber.StatusBadRequest,
+			"status must be one of draft, scheduled, live, expired, all",
+		)
+	}
+}
+
+// instituteLocation is the timezone notice times of the institute are entered in
+func (server *Server) instituteLocation(ctx context.Context, instituteID int32) (*time.Location, error) {
+	timezone, err := server.store.GetInstituteTimezone(ctx, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(timezone)
+}
+
+// localTime shows a stored time in the institute's timezone
+func localTime(t pgtype.Timestamptz, loc *time.Location) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	local := t.Time.In(loc)
+	return &local
+}
+
+func newNoticeResponse(notice pgdb.Notice, attachments []pgdb.NoticeAttachment, loc *time.Location) fiber.Map {
+	return fiber.Map{
+		"id":           notice.ID,
+		"institute_id": notice.InstituteID,
+		"title":        notice.Title,
+		"description":  notice.Description,
+		"is_published": notice.IsPublished,
+		"publish_date": notice.PublishDate,
+		"publish_at":   localTime(notice.PublishAt, loc),
+		"expire_at":    localTime(notice.ExpireAt, loc),
+		"status":       notice.Status,
+		"timezone":     loc.String(),
+		"attachments":  newAttachmentResponses(attachments),
+		"created_at":   notice.CreatedAt,
+	}
+}
+
+func (server *Server) getInstituteTimezone(c *fiber.Ctx) error {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	timezone, err := server.store.GetInstituteTimezone(c.Context(), payload.InstituteID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"institute_id": payload.InstituteID,
+		"timezone":     timezone,
+	})
+}
+
+// setInstituteTimezone changes how notice times are read and shown; notices
+// already scheduled keep their absolute times
+func (server *Server) setInstituteTimezone(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req InstituteTimezoneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request (IANA name, e.g. Asia/Kolkata)
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 4️⃣ Update institute
+	institute, err := server.store.SetInstituteTimezone(
+		c.Context(),
+		pgdb.SetInstituteTimezoneParams{
+			ID:       payload.InstituteID,
+			Timezone: req.Timezone,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"institute_id": institute.ID,
+		"timezone":     institute.Timezone,
+	})
+}
+
+// advanceNoticeStatuses publishes scheduled notices and expires live ones
+// whose time has come
+func (server *Server) advanceNoticeStatuses(ctx context.Context) {
+	if _, err := server.store.AdvanceNoticeStatuses(ctx); err != nil {
+		log.Printf("failed to advance notice statuses: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNoticeStatus(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: now.Add(d), Valid: true}
+	}
+	unset := pgtype.Timestamptz{}
+
+	tests := []struct {
+		name        string
+		isPublished bool
+		publishAt   pgtype.Timestamptz
+		expireAt    pgtype.Timestamptz
+		want        string
+	}{
+		{"unpublished", false, unset, unset, NoticeDraft},
+		{"unpublished with a past window", false, at(-time.Hour), at(time.Hour), NoticeDraft},
+		{"published without a window", true, unset, unset, NoticeLive},
+		{"publish time ahead", true, at(time.Hour), unset, NoticeScheduled},
+		{"publish time reached", true, at(0), unset, NoticeLive},
+		{"expiry ahead", true, at(-time.Hour), at(time.Hour), NoticeLive},
+		{"expiry reached", true, at(-time.Hour), at(0), NoticeExpired},
+		{"expired without a publish time", true, unset, at(-time.Minute), NoticeExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := noticeStatus(tt.isPublished, tt.publishAt, tt.expireAt, now); got != tt.want {
+				t.Fatalf("status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveNoticeSchedule(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publishDate := time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		publishAt   string
+		expireAt    string
+		publishDate *time.Time
+		wantErr     bool
+		// UTC instants, zero when not set
+		wantPublish time.Time
+		wantExpire  time.Time
+		wantDay     string
+	}{
+		{
+			name: "no window",
+		},
+		{
+			name:        "local time in the institute timezone",
+			publishAt:   "2030-05-01T09:30",
+			wantPublish: time.Date(2030, 5, 1, 4, 0, 0, 0, time.UTC),
+			wantDay:     "2030-05-01",
+		},
+		{
+			name:        "offset given",
+			publishAt:   "2030-05-01T01:00:00Z",
+			expireAt:    "2030-05-02 18:00",
+			wantPublish: time.Date(2030, 5, 1, 1, 0, 0, 0, time.UTC),
+			wantExpire:  time.Date(2030, 5, 2, 12, 30, 0, 0, time.UTC),
+			wantDay:     "2030-05-01",
+		},
+		{
+			name:        "publish day is the local day",
+			publishAt:   "2030-05-01T20:00:00Z",
+			wantPublish: time.Date(2030, 5, 1, 20, 0, 0, 0, time.UTC),
+			wantDay:     "2030-05-02",
+		},
+		{
+			name:        "publish_date is the start of that local day",
+			publishDate: &publishDate,
+			wantPublish: time.Date(2030, 4, 30, 18, 30, 0, 0, time.UTC),
+			wantDay:     "2030-05-01",
+		},
+		{
+			name:        "publish_at wins over publish_date",
+			publishAt:   "2030-06-01",
+			publishDate: &publishDate,
+			wantPublish: time.Date(2030, 5, 31, 18, 30, 0, 0, time.UTC),
+			wantDay:     "2030-06-01",
+		},
+		{
+			name:      "unparsable publish_at",
+			publishAt: "next tuesday",
+			wantErr:   true,
+		},
+		{
+			name:     "unparsable expire_at",
+			expireAt: "01/05/2030",
+			wantErr:  true,
+		},
+		{
+			name:      "expiry before publishing",
+			publishAt: "2030-05-01T10:00",
+			expireAt:  "2030-05-01T09:00",
+			wantErr:   true,
+		},
+		{
+			name:      "expiry at publishing",
+			publishAt: "2030-05-01T10:00",
+			expireAt:  "2030-05-01T10:00",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := resolveNoticeSchedule(kolkata, true, tt.publishAt, tt.expireAt, tt.publishDate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", schedule)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checkTime := func(field string, got pgtype.Timestamptz, want time.Time) {
+				if got.Valid != !want.IsZero() || got.Valid && !got.Time.Equal(want) {
+					t.Fatalf("%s = %+v, want %v", field, got, want)
+				}
+			}
+			checkTime("publish_at", schedule.PublishAt, tt.wantPublish)
+			checkTime("expire_at", schedule.ExpireAt, tt.wantExpire)
+
+			day := ""
+			if schedule.PublishDate.Valid {
+				day = schedule.PublishDate.Time.Format(time.DateOnly)
+			}
+			if day != tt.wantDay {
+				t.Fatalf("publish_date = %q, want %q", day, tt.wantDay)
+			}
+		})
+	}
+}
+
+func TestOptionalTime(t *testing.T) {
+	stored := pgtype.Timestamptz{Time: time.Date(2030, 5, 1, 4, 0, 0, 0, time.UTC), Valid: true}
+
+	tests := []struct {
+		name   string
+		body   string
+		stored pgtype.Timestamptz
+		want   optionalTime
+		// value resolved for the update
+		wantValue string
+	}{
+		{"absent keeps the stored time", `{}`, stored, optionalTime{}, "2030-05-01T04:00:00Z"},
+		{"absent without a stored time", `{}`, pgtype.Timestamptz{}, optionalTime{}, ""},
+		{"null clears", `{"publish_at":null}`, stored, optionalTime{Set: true}, ""},
+		{"empty string clears", `{"publish_at":""}`, stored, optionalTime{Set: true}, ""},
+		{"new value", `{"publish_at":"2030-06-01T10:00"}`, stored, optionalTime{Set: true, Value: "2030-06-01T10:00"}, "2030-06-01T10:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req struct {
+				PublishAt optionalTime `json:"publish_at"`
+			}
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatal(err)
+			}
+			if req.PublishAt != tt.want {
+				t.Fatalf("decoded %+v, want %+v", req.PublishAt, tt.want)
+			}
+			if got := req.PublishAt.orStored(tt.stored); got != tt.wantValue {
+				t.Fatalf("value = %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+
+	var req struct {
+		PublishAt optionalTime `json:"publish_at"`
+	}
+	if err := json.Unmarshal([]byte(`{"publish_at":42}`), &req); err == nil {
+		t.Fatal("a number decoded as a time")
+	}
+}
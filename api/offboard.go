@@ -0,0 +1,299 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/password"
+	"dashboard/token"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	OffboardAnonymize = "anonymize"
+	OffboardDelete    = "delete"
+)
+
+type OffboardUserRequest struct {
+	Mode string `json:"mode" validate:"required,oneof=anonymize delete"`
+	// user taking over the photos, the institute system account if empty
+	ReassignTo int32 `json:"reassign_to" validate:"omitempty,min=1"`
+}
+
+type offboardTarget struct {
+	ID     int32  `json:"id,omitempty"` // 0 until the system account exists
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	System bool   `json:"system"`
+}
+
+// systemUserEmail names the inactive account that keeps the content of
+// offboarded users when no other owner is given
+func systemUserEmail(instituteID int32) string {
+	return fmt.Sprintf("system+%d@dashboard.invalid", instituteID)
+}
+
+// loadOffboarding checks that userID can be offboarded by the caller and
+// resolves who will own the user's photos afterwards
+func (server *Server) loadOffboarding(ctx context.Context, payload *token.TokenPayload, userID int32, reassignTo int32) (pgdb.User, offboardTarget, error) {
+	if int64(userID) == payload.ID {
+		return pgdb.User{}, offboardTarget{}, fiber.NewError(
+			fiber.StatusBadRequest,
+			"you cannot offboard your own account",
+		)
+	}
+
+	user, err := server.store.GetUserByID(ctx, pgdb.GetUserByIDParams{
+		ID:          userID,
+		InstituteID: payload.InstituteID,
+	})
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return pgdb.User{}, offboardTarget{}, NotFoundError("user not found")
+		}
+		return pgdb.User{}, offboardTarget{}, InternalServerError(err.Error())
+	}
+
+	// explicit new owner
+	if reassignTo != 0 {
+		if reassignTo == user.ID {
+			return pgdb.User{}, offboardTarget{}, fiber.NewError(
+				fiber.StatusBadRequest,
+				"cannot reassign content to the offboarded user",
+			)
+		}
+
+		owner, err := server.store.GetUserByID(ctx, pgdb.GetUserByIDParams{
+			ID:          reassignTo,
+			InstituteID: payload.InstituteID,
+		})
+		if err != nil {
+			if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+				return pgdb.User{}, offboardTarget{}, NotFoundError("reassign_to user not found")
+			}
+			return pgdb.User{}, offboardTarget{}, InternalServerError(err.Error())
+		}
+
+		return user, offboardTarget{ID: owner.ID, Name: owner.Name, Email: owner.Email}, nil
+	}
+
+	// institute system account
+	target := offboardTarget{
+		Name:   "System",
+		Email:  systemUserEmail(payload.InstituteID),
+		System: true,
+	}
+	if user.Email == target.Email {
+		return pgdb.User{}, offboardTarget{}, fiber.NewError(
+			fiber.StatusBadRequest,
+			"reassign_to is required to offboard the system account",
+		)
+	}
+
+	system, err := server.store.GetUserByEmail(ctx, pgdb.GetUserByEmailParams{
+		Email:       target.Email,
+		InstituteID: payload.InstituteID,
+	})
+	if err != nil && pgdb.ErrorCode(err) != pgdb.ErrorNoRow {
+		return pgdb.User{}, offboardTarget{}, InternalServerError(err.Error())
+	}
+	if err == nil {
+		target.ID = system.ID
+	}
+
+	return user, target, nil
+}
+
+func (server *Server) previewOffboardUser(c *fiber.Ctx) error {
+
+	// 1️⃣ Read user ID from URL
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ User and new owner (?reassign_to=)
+	user, target, err := server.loadOffboarding(c.Context(), payload, int32(userID), int32(c.QueryInt("reassign_to")))
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Content that changes hands
+	photos, err := server.store.GetPhotosByUser(
+		c.Context(),
+		pgdb.GetPhotosByUserParams{
+			UploadedBy:  user.ID,
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	photoList := make([]fiber.Map, 0, len(photos))
+	for _, photo := range photos {
+		photoList = append(photoList, fiber.Map{
+			"id":        photo.ID,
+			"image_url": photo.ImageUrl,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"user": fiber.Map{
+			"id":        user.ID,
+			"name":      user.Name,
+			"email":     user.Email,
+			"role":      user.Role.String,
+			"is_active": user.IsActive,
+		},
+		"reassign_to": target,
+		"photos":      photoList,
+		"effects": []string{
+			"all tokens and sessions of the user are revoked",
+			fmt.Sprintf("%d photos are reassigned to %s", len(photos), target.Email),
+			"anonymize: name, email, phone, avatar, password, two-factor and invites are wiped, the account stays disabled",
+			"delete: the user and everything that belongs to the account are removed",
+		},
+	})
+}
+
+func (server *Server) offboardUser(c *fiber.Ctx) error {
+
+	// 1️⃣ Read user ID from URL
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+
+	// 2️⃣ Parse request body
+	var req OffboardUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 3️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 4️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 5️⃣ User and new owner
+	user, target, err := server.loadOffboarding(c.Context(), payload, int32(userID), req.ReassignTo)
+	if err != nil {
+		return err
+	}
+
+	// 6️⃣ Lock the user out first, so a failed offboarding never leaves access behind
+	if err := server.revokeUserAccess(c.Context(), user.ID); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 7️⃣ Nobody can log in as the system account or an anonymized user
+	unusablePassword := func() (string, error) {
+		secret, err := token.GenerateRandomStringURLSafe(32)
+		if err != nil {
+			return "", err
+		}
+		return password.HashPassword(secret)
+	}
+
+	arg := pgdb.OffboardUserTxParams{
+		UserID:      user.ID,
+		InstituteID: payload.InstituteID,
+		ReassignTo:  req.ReassignTo,
+		Event:       securityEventParams(c, user.ID, payload.InstituteID, SecurityEventAccountOffboarded, "", fmt.Sprintf("user %d deleted", user.ID)),
+	}
+	if target.System {
+		hashedPassword, err := unusablePassword()
+		if err != nil {
+			return InternalServerError("failed to generate password")
+		}
+		arg.SystemUser = pgdb.CreateUserParams{
+			InstituteID: payload.InstituteID,
+			Name:        target.Name,
+			Email:       target.Email,
+			Password:    hashedPassword,
+			Role:        pgtype.Text{String: RoleViewer, Valid: true},
+			IsActive:    pgtype.Bool{Bool: false, Valid: true},
+		}
+	}
+	if req.Mode == OffboardAnonymize {
+		hashedPassword, err := unusablePassword()
+		if err != nil {
+			return InternalServerError("failed to generate password")
+		}
+		arg.Anonymize = &pgdb.AnonymizeUserParams{
+			ID:       user.ID,
+			Name:     "Deleted user",
+			Email:    fmt.Sprintf("deleted+%d@dashboard.invalid", user.ID),
+			Password: hashedPassword,
+		}
+		arg.Event.Detail = "anonymized"
+	}
+
+	// 8️⃣ Reassign, anonymize / delete and audit in one transaction
+	result, err := server.store.OffboardUserTx(c.Context(), arg)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		// photos the user uploaded as a member of another institute
+		if pgdb.ErrorCode(err) == pgdb.ErrorForeignKey {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"user still owns content in another institute, anonymize them instead",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 9️⃣ Avatar is no longer referenced
+	if user.AvatarPublicID.Valid {
+		server.deleteAvatar(c.Context(), user.AvatarPublicID.String)
+	}
+
+	message := "user deleted successfully"
+	if req.Mode == OffboardAnonymize {
+		message = "user anonymized successfully"
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"id":      user.ID,
+		"mode":    req.Mode,
+		"reassigned_to": fiber.Map{
+			"id":    result.ReassignedTo.ID,
+			"name":  result.ReassignedTo.Name,
+			"email": result.ReassignedTo.Email,
+		},
+		"reassigned_photos": result.ReassignedPhotos,
+	})
+}
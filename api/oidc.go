@@ -0,0 +1,738 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/password"
+	"dashboard/token"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcStateSize = 32
+	oidcNonceSize = 32
+	oidcCallback  = "/auth/oidc/callback"
+)
+
+type OIDCProviderRequest struct {
+	IssuerURL     string   `json:"issuer_url" validate:"required,url"`
+	ClientID      string   `json:"client_id" validate:"required"`
+	ClientSecret  string   `json:"client_secret"`
+	Scopes        []string `json:"scopes"`
+	AutoProvision bool     `json:"auto_provision"`
+	DefaultRole   string   `json:"default_role" validate:"omitempty,role"`
+	Enabled       *bool    `json:"enabled"`
+}
+
+// ApproveOIDCProviderRequest is a super admin's approval of the issuer an
+// institute configured, with the email domains it may sign in without linking
+type ApproveOIDCProviderRequest struct {
+	IssuerURL      string   `json:"issuer_url" validate:"required,url"`
+	AllowedDomains []string `json:"allowed_domains" validate:"dive,fqdn"`
+}
+
+// oidcProviderResponse never exposes the client secret
+type oidcProviderResponse struct {
+	InstituteID     int32              `json:"institute_id"`
+	IssuerURL       string             `json:"issuer_url"`
+	ClientID        string             `json:"client_id"`
+	HasClientSecret bool               `json:"has_client_secret"`
+	Scopes          []string           `json:"scopes"`
+	AutoProvision   bool               `json:"auto_provision"`
+	DefaultRole     string             `json:"default_role"`
+	Enabled         bool               `json:"enabled"`
+	Approved        bool               `json:"approved"`
+	AllowedDomains  []string           `json:"allowed_domains"`
+	RedirectURL     string             `json:"redirect_url"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
+
+// oidcProviderCache keeps discovered providers per issuer so discovery and
+// JWKS fetches are not repeated on every login
+type oidcProviderCache struct {
+	mu        sync.Mutex
+	providers map[string]*oidc.Provider
+}
+
+func newOIDCProviderCache() *oidcProviderCache {
+	return &oidcProviderCache{providers: make(map[string]*oidc.Provider)}
+}
+
+func (cache *oidcProviderCache) get(issuerURL string) (*oidc.Provider, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if provider, ok := cache.providers[issuerURL]; ok {
+		return provider, nil
+	}
+
+	// the provider (and its JWKS cache) outlives this request, so it must not
+	// hold on to the request context
+	provider, err := oidc.NewProvider(oidcContext(context.Background()), issuerURL)
+	if err != nil {
+		return nil, err
+	}
+	cache.providers[issuerURL] = provider
+	return provider, nil
+}
+
+func (cache *oidcProviderCache) forget(issuerURL string) {
+	cache.mu.Lock()
+	delete(cache.providers, issuerURL)
+	cache.mu.Unlock()
+}
+
+// oidcContext makes calls to the identity provider time out and keeps them
+// away from internal addresses
+func oidcContext(ctx context.Context) context.Context {
+	return oidc.ClientContext(ctx, oidcHTTPClient)
+}
+
+func (server *Server) oidcRedirectURL(c *fiber.Ctx) string {
+	if server.config.OIDCRedirectURL != "" {
+		return server.config.OIDCRedirectURL
+	}
+	return c.BaseURL() + oidcCallback
+}
+
+func (server *Server) oauth2Config(c *fiber.Ctx, provider *oidc.Provider, settings pgdb.InstituteOidcProvider) *oauth2.Config {
+	scopes := []string{oidc.ScopeOpenID, "email", "profile"}
+	for _, scope := range settings.Scopes {
+		if !strings.EqualFold(scope, oidc.ScopeOpenID) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &oauth2.Config{
+		ClientID:     settings.ClientID,
+		ClientSecret: settings.ClientSecret.String,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  server.oidcRedirectURL(c),
+		Scopes:       scopes,
+	}
+}
+
+// oidcLogin starts an authorization-code + PKCE login for the institute code
+func (server *Server) oidcLogin(c *fiber.Ctx) error {
+
+	// 1️⃣ Find institute and its identity provider
+	institute, err := server.store.GetInstituteByCode(c.Context(), c.Params("code"))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	settings, err := server.store.GetInstituteOIDCProvider(c.Context(), institute.ID)
+	if err != nil || !settings.Enabled || !oidcIssuerApproved(settings) {
+		if err == nil || pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("single sign-on is not configured for this institute")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 2️⃣ Send the browser to the identity provider
+	authURL, err := server.oidcAuthURL(c, settings, pgtype.Int4{})
+	if err != nil {
+		return err
+	}
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// oidcIssuerApproved tells whether a super admin approved the issuer that is
+// configured now
+func oidcIssuerApproved(settings pgdb.InstituteOidcProvider) bool {
+	return settings.ApprovedIssuerUrl.Valid && settings.ApprovedIssuerUrl.String == settings.IssuerUrl
+}
+
+// oidcAuthURL stores a login state and returns the IdP authorize URL for it.
+// linkUserID is set when a signed-in user links their identity.
+func (server *Server) oidcAuthURL(c *fiber.Ctx, settings pgdb.InstituteOidcProvider, linkUserID pgtype.Int4) (string, error) {
+	provider, err := server.oidcProviders.get(settings.IssuerUrl)
+	if err != nil {
+		return "", fiber.NewError(
+			fiber.StatusBadGateway,
+			"identity provider discovery failed",
+		)
+	}
+
+	// 1️⃣ Generate state, nonce and PKCE verifier
+	state, stateHash, err := token.GenerateTokenAndHash(oidcStateSize)
+	if err != nil {
+		return "", InternalServerError("failed to generate state")
+	}
+	nonce, err := token.GenerateRandomStringURLSafe(oidcNonceSize)
+	if err != nil {
+		return "", InternalServerError("failed to generate nonce")
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// 2️⃣ Remember them until the callback
+	err = server.store.CreateOIDCLoginState(
+		c.Context(),
+		pgdb.CreateOIDCLoginStateParams{
+			StateHash:    stateHash,
+			InstituteID:  settings.InstituteID,
+			CodeVerifier: verifier,
+			Nonce:        nonce,
+			ExpiresAt: pgtype.Timestamptz{
+				Time:  time.Now().Add(server.config.OIDCStateDuration),
+				Valid: true,
+			},
+			LinkUserID: linkUserID,
+		},
+	)
+	if err != nil {
+		return "", InternalServerError(err.Error())
+	}
+
+	return server.oauth2Config(c, provider, settings).AuthCodeURL(
+		state,
+		oidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(verifier),
+	), nil
+}
+
+// oidcCallbackHandler finishes the login and issues our own tokens
+func (server *Server) oidcCallbackHandler(c *fiber.Ctx) error {
+
+	// 1️⃣ Provider reported an error
+	if errCode := c.Query("error"); errCode != "" {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"identity provider error: "+errCode,
+		)
+	}
+
+	invalidState := fiber.NewError(
+		fiber.StatusBadRequest,
+		"invalid or expired login state",
+	)
+
+	// 2️⃣ Consume state (single use)
+	loginState, err := server.store.ConsumeOIDCLoginState(c.Context(), token.GetTokenHash(c.Query("state")))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidState
+		}
+		return InternalServerError(err.Error())
+	}
+
+	settings, err := server.store.GetInstituteOIDCProvider(c.Context(), loginState.InstituteID)
+	if err != nil || !settings.Enabled || !oidcIssuerApproved(settings) {
+		if err == nil || pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidState
+		}
+		return InternalServerError(err.Error())
+	}
+
+	provider, err := server.oidcProviders.get(settings.IssuerUrl)
+	if err != nil {
+		return fiber.NewError(
+			fiber.StatusBadGateway,
+			"identity provider discovery failed",
+		)
+	}
+
+	// 3️⃣ Exchange the code using the PKCE verifier
+	oauthToken, err := server.oauth2Config(c, provider, settings).Exchange(
+		oidcContext(c.Context()),
+		c.Query("code"),
+		oauth2.VerifierOption(loginState.CodeVerifier),
+	)
+	if err != nil {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"authorization code exchange failed",
+		)
+	}
+
+	// 4️⃣ Verify the ID token (signature, issuer, audience, expiry, nonce)
+	rawIDToken, ok := oauthToken.Extra("id_token").(string)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"identity provider did not return an id_token",
+		)
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: settings.ClientID}).Verify(c.Context(), rawIDToken)
+	if err != nil || idToken.Nonce != loginState.Nonce {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid id_token",
+		)
+	}
+
+	// 5️⃣ A signed-in user linking their identity
+	if loginState.LinkUserID.Valid {
+		return server.linkOIDCIdentity(c, settings, loginState.LinkUserID.Int32, idToken.Subject)
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid id_token claims",
+		)
+	}
+	if claims.Email == "" || !claims.EmailVerified {
+		return fiber.NewError(
+			fiber.StatusForbidden,
+			"identity provider did not return a verified email",
+		)
+	}
+
+	// 6️⃣ Map the identity to a user of this institute
+	user, err := server.oidcUser(c, settings, idToken.Subject, claims)
+	if err != nil {
+		return err
+	}
+
+	// 7️⃣ Same institute and second factor rules as password logins
+	institute, err := server.store.GetInstituteByID(c.Context(), settings.InstituteID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusForbidden,
+				"your institute is disabled, please contact support",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	totpEnabled, err := server.isTOTPEnabled(c.Context(), user.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if totpEnabled || institute.Require2fa {
+		return server.startLoginChallenge(c, user, !totpEnabled, pgtype.Int4{})
+	}
+
+	return server.issueLogin(c, user, nil)
+}
+
+// oidcUser finds the active user for an identity or provisions one when the
+// institute allows it. The IdP is chosen by institute admins, so an existing
+// account is only matched by email when a super admin allowed its domain;
+// otherwise the user has to link the identity first.
+func (server *Server) oidcUser(c *fiber.Ctx, settings pgdb.InstituteOidcProvider, subject string, claims oidcClaims) (pgdb.User, error) {
+	disabled := fiber.NewError(
+		fiber.StatusForbidden,
+		"your account is disabled, please contact admin",
+	)
+
+	// 1️⃣ Linked (or provisioned) identity
+	user, err := server.store.GetUserByOIDCIdentity(
+		c.Context(),
+		pgdb.GetUserByOIDCIdentityParams{
+			InstituteID: settings.InstituteID,
+			IssuerUrl:   settings.IssuerUrl,
+			Subject:     subject,
+		},
+	)
+	if err == nil {
+		if !user.IsActive.Bool {
+			return pgdb.User{}, disabled
+		}
+		return user, nil
+	}
+	if pgdb.ErrorCode(err) != pgdb.ErrorNoRow {
+		return pgdb.User{}, InternalServerError(err.Error())
+	}
+
+	// 2️⃣ Existing account with this email
+	user, err = server.store.GetUserByEmail(
+		c.Context(),
+		pgdb.GetUserByEmailParams{
+			Email:       claims.Email,
+			InstituteID: settings.InstituteID,
+		},
+	)
+	if err == nil {
+		if !emailDomainAllowed(settings.AllowedDomains, claims.Email) {
+			return pgdb.User{}, fiber.NewError(
+				fiber.StatusForbidden,
+				"sign in with your password and link single sign-on to your account first",
+			)
+		}
+		if !user.IsActive.Bool {
+			return pgdb.User{}, disabled
+		}
+		if err := server.saveOIDCIdentity(c.Context(), settings, subject, user.ID); err != nil {
+			return pgdb.User{}, err
+		}
+		return user, nil
+	}
+	if pgdb.ErrorCode(err) != pgdb.ErrorNoRow {
+		return pgdb.User{}, InternalServerError(err.Error())
+	}
+
+	// 3️⃣ New account
+	if !settings.AutoProvision {
+		return pgdb.User{}, fiber.NewError(
+			fiber.StatusForbidden,
+			"no dashboard account for this email, please contact admin",
+		)
+	}
+
+	// provisioned users sign in through the IdP; the random password is never shared
+	secret, err := token.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		return pgdb.User{}, InternalServerError("failed to generate password")
+	}
+	hashedPassword, err := password.HashPassword(secret)
+	if err != nil {
+		return pgdb.User{}, InternalServerError("failed to hash password")
+	}
+
+	email := strings.ToLower(claims.Email)
+	name := claims.Name
+	if name == "" {
+		name = email
+	}
+
+	user, err = server.store.CreateUser(
+		c.Context(),
+		pgdb.CreateUserParams{
+			InstituteID: settings.InstituteID,
+			Name:        name,
+			Email:       email,
+			Password:    hashedPassword,
+			Role:        pgtype.Text{String: settings.DefaultRole, Valid: true},
+			IsActive:    pgtype.Bool{Bool: true, Valid: true},
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorDuplicateKey {
+			return pgdb.User{}, fiber.NewError(
+				fiber.StatusConflict,
+				"email belongs to an account of another institute",
+			)
+		}
+		return pgdb.User{}, InternalServerError(err.Error())
+	}
+
+	if err := server.saveOIDCIdentity(c.Context(), settings, subject, user.ID); err != nil {
+		return pgdb.User{}, err
+	}
+
+	log.Printf("provisioned user %d for institute %d through single sign-on", user.ID, user.InstituteID.Int32)
+	return user, nil
+}
+
+// saveOIDCIdentity remembers which user an IdP subject signs in as
+func (server *Server) saveOIDCIdentity(ctx context.Context, settings pgdb.InstituteOidcProvider, subject string, userID int32) error {
+	err := server.store.CreateUserOIDCIdentity(ctx, pgdb.CreateUserOIDCIdentityParams{
+		InstituteID: settings.InstituteID,
+		IssuerUrl:   settings.IssuerUrl,
+		Subject:     subject,
+		UserID:      userID,
+	})
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorDuplicateKey {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"this identity is already linked to another account",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+	return nil
+}
+
+// linkOIDCIdentity finishes a link started by linkMyOIDCIdentity
+func (server *Server) linkOIDCIdentity(c *fiber.Ctx, settings pgdb.InstituteOidcProvider, userID int32, subject string) error {
+	user, err := server.store.GetUserByID(
+		c.Context(),
+		pgdb.GetUserByIDParams{
+			ID:          userID,
+			InstituteID: settings.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+	if !user.IsActive.Bool {
+		return fiber.NewError(
+			fiber.StatusForbidden,
+			"your account is disabled, please contact admin",
+		)
+	}
+
+	if err := server.saveOIDCIdentity(c.Context(), settings, subject, user.ID); err != nil {
+		return err
+	}
+
+	return c.JSON(msgResponse{Msg: "single sign-on linked to your account"})
+}
+
+// linkMyOIDCIdentity starts an IdP login that links the identity to the
+// signed-in user; the client sends the browser to authorization_url
+func (server *Server) linkMyOIDCIdentity(c *fiber.Ctx) error {
+
+	// 1️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 2️⃣ Identity providers belong to the home institute
+	user, err := server.store.GetUserByIDAnyInstitute(c.Context(), int32(payload.ID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	settings, err := server.store.GetInstituteOIDCProvider(c.Context(), user.InstituteID.Int32)
+	if err != nil || !settings.Enabled || !oidcIssuerApproved(settings) {
+		if err == nil || pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("single sign-on is not configured for your institute")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 3️⃣ Login state bound to the user
+	authURL, err := server.oidcAuthURL(c, settings, pgtype.Int4{Int32: user.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"authorization_url": authURL})
+}
+
+func (server *Server) unlinkMyOIDCIdentity(c *fiber.Ctx) error {
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	removed, err := server.store.DeleteUserOIDCIdentities(c.Context(), int32(payload.ID))
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if removed == 0 {
+		return NotFoundError("no single sign-on identity is linked")
+	}
+
+	return c.JSON(msgResponse{Msg: "single sign-on unlinked from your account"})
+}
+
+func (server *Server) getOIDCProvider(c *fiber.Ctx) error {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	settings, err := server.store.GetInstituteOIDCProvider(c.Context(), payload.InstituteID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("single sign-on is not configured")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(server.newOIDCProviderResponse(c, settings))
+}
+
+func (server *Server) putOIDCProvider(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req OIDCProviderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 4️⃣ The server fetches the issuer, so it must be a public https host
+	issuerURL := strings.TrimRight(req.IssuerURL, "/")
+	if err := checkIssuerURL(c.Context(), issuerURL); err != nil {
+		return err
+	}
+
+	// 5️⃣ Defaults
+	defaultRole := req.DefaultRole
+	if defaultRole == "" {
+		defaultRole = RoleViewer
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	scopes := req.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	// 6️⃣ Keep the stored secret when none is sent
+	clientSecret := pgtype.Text{String: req.ClientSecret, Valid: req.ClientSecret != ""}
+	current, err := server.store.GetInstituteOIDCProvider(c.Context(), payload.InstituteID)
+	if err != nil && pgdb.ErrorCode(err) != pgdb.ErrorNoRow {
+		return InternalServerError(err.Error())
+	}
+	if err == nil && !clientSecret.Valid && current.ClientID == req.ClientID {
+		clientSecret = current.ClientSecret
+	}
+
+	// 7️⃣ Save settings; a new issuer waits for super admin approval
+	settings, err := server.store.UpsertInstituteOIDCProvider(
+		c.Context(),
+		pgdb.UpsertInstituteOIDCProviderParams{
+			InstituteID:   payload.InstituteID,
+			IssuerUrl:     issuerURL,
+			ClientID:      req.ClientID,
+			ClientSecret:  clientSecret,
+			Scopes:        scopes,
+			AutoProvision: req.AutoProvision,
+			DefaultRole:   defaultRole,
+			Enabled:       enabled,
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	server.oidcProviders.forget(current.IssuerUrl)
+	server.oidcProviders.forget(settings.IssuerUrl)
+
+	return c.JSON(server.newOIDCProviderResponse(c, settings))
+}
+
+// approveOIDCProvider lets an institute's identity provider sign users in.
+// Only super admins approve issuers: the IdP can assert any email.
+func (server *Server) approveOIDCProvider(c *fiber.Ctx) error {
+
+	// 1️⃣ Read institute ID
+	instituteID, err := c.ParamsInt("id")
+	if err != nil || instituteID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid institute id",
+		)
+	}
+
+	// 2️⃣ Parse request body
+	var req ApproveOIDCProviderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 3️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+	issuerURL := strings.TrimRight(req.IssuerURL, "/")
+	if err := checkIssuerURL(c.Context(), issuerURL); err != nil {
+		return err
+	}
+
+	// 4️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	domains := make([]string, 0, len(req.AllowedDomains))
+	for _, domain := range req.AllowedDomains {
+		domains = append(domains, strings.ToLower(domain))
+	}
+
+	// 5️⃣ Approve the issuer the institute has configured
+	settings, err := server.store.ApproveInstituteOIDCProvider(
+		c.Context(),
+		pgdb.ApproveInstituteOIDCProviderParams{
+			InstituteID:    int32(instituteID),
+			IssuerUrl:      issuerURL,
+			AllowedDomains: domains,
+			ApprovedBy:     pgtype.Int4{Int32: int32(payload.ID), Valid: payload.ID != 0},
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"the institute has not configured this issuer",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(server.newOIDCProviderResponse(c, settings))
+}
+
+func (server *Server) deleteOIDCProvider(c *fiber.Ctx) error {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	if err := server.store.DeleteInstituteOIDCProvider(c.Context(), payload.InstituteID); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(msgResponse{Msg: "single sign-on disabled"})
+}
+
+func (server *Server) newOIDCProviderResponse(c *fiber.Ctx, settings pgdb.InstituteOidcProvider) oidcProviderResponse {
+	return oidcProviderResponse{
+		InstituteID:     settings.InstituteID,
+		IssuerURL:       settings.IssuerUrl,
+		ClientID:        settings.ClientID,
+		HasClientSecret: settings.ClientSecret.Valid,
+		Scopes:          settings.Scopes,
+		AutoProvision:   settings.AutoProvision,
+		DefaultRole:     settings.DefaultRole,
+		Enabled:         settings.Enabled,
+		Approved:        oidcIssuerApproved(settings),
+		AllowedDomains:  settings.AllowedDomains,
+		RedirectURL:     server.oidcRedirectURL(c),
+		UpdatedAt:       settings.UpdatedAt,
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errPrivateIssuer stops requests to identity providers on internal
+// addresses; institute admins choose the issuer URL
+var errPrivateIssuer = errors.New("identity provider address is not public")
+
+// sharedAddressSpace is carrier-grade NAT (RFC 6598), not covered by IsPrivate
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+var oidcHTTPClient = &http.Client{
+	Timeout:   10 * time.Second,
+	Transport: publicOnlyTransport(),
+}
+
+// publicOnlyTransport checks the address every connection actually goes to,
+// so a DNS answer that changes after checkIssuerURL can't reach inside
+func publicOnlyTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: dialPublicOnly,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+func dialPublicOnly(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !isPublicAddr(addr) {
+		return errPrivateIssuer
+	}
+	return nil
+}
+
+// isPublicAddr rejects loopback, private, link-local, multicast and other
+// addresses that only make sense inside our network
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || sharedAddressSpace.Contains(addr) {
+		return false
+	}
+	// 0.0.0.0/8 is "this network"
+	return !addr.Is4() || addr.As4()[0] != 0
+}
+
+// checkIssuerURL accepts https issuers on public hosts
+func checkIssuerURL(ctx context.Context, issuerURL string) error {
+	u, err := url.Parse(issuerURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"issuer_url must be an https URL",
+		)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", strings.TrimSuffix(u.Hostname(), "."))
+	if err != nil || len(addrs) == 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"issuer_url host does not resolve",
+		)
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return fiber.NewError(
+				fiber.StatusBadRequest,
+				"issuer_url must point to a public host",
+			)
+		}
+	}
+	return nil
+}
+
+// emailDomainAllowed tells whether an IdP may sign in existing accounts with
+// this email without them linking first
+func emailDomainAllowed(allowedDomains []string, email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+
+	for _, allowed := range allowedDomains {
+		if strings.ToLower(allowed) == domain {
+			return true
+		}
+	}
+	return false
+}
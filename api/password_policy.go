@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/password"
+	"dashboard/token"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// `password` expands to every rule of the institute password policy
+const passwordTagRules = "password_length,password_classes,password_breached,password_history"
+
+type PasswordPolicyRequest struct {
+	MinLength     int  `json:"min_length" validate:"required,min=6,max=128"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	HistorySize   int  `json:"history_size" validate:"min=0,max=5"` // at most pgdb.PasswordHistoryLimit
+	CheckBreached bool `json:"check_breached"`
+}
+
+// passwordRules is what the password validators check against. It travels in
+// the validation context because it depends on the institute and the user.
+type passwordRules struct {
+	policy password.Policy
+	// hashes the new password must not match
+	history []string
+}
+
+type passwordRulesKey struct{}
+
+func withPasswordRules(ctx context.Context, rules passwordRules) context.Context {
+	return context.WithValue(ctx, passwordRulesKey{}, rules)
+}
+
+func passwordRulesFrom(ctx context.Context) (passwordRules, bool) {
+	rules, ok := ctx.Value(passwordRulesKey{}).(passwordRules)
+	return rules, ok
+}
+
+func registerPasswordValidators(valid *validator.Validate) error {
+	checks := map[string]func(rules passwordRules, value string) bool{
+		"password_length": func(rules passwordRules, value string) bool {
+			return rules.policy.LongEnough(value)
+		},
+		"password_classes": func(rules passwordRules, value string) bool {
+			return rules.policy.HasRequiredClasses(value)
+		},
+		"password_breached": func(rules passwordRules, value string) bool {
+			return !rules.policy.CheckBreached || !password.IsBreached(value)
+		},
+		"password_history": func(rules passwordRules, value string) bool {
+			return !password.IsReused(value, rules.history)
+		},
+	}
+
+	for tag, check := range checks {
+		err := valid.RegisterValidationCtx(tag, func(ctx context.Context, fl validator.FieldLevel) bool {
+			// rules are only known once the handler has loaded the institute;
+			// until then (or if a handler never does) the minimum policy applies
+			rules, ok := passwordRulesFrom(ctx)
+			if !ok {
+				rules = passwordRules{policy: password.MinimumPolicy()}
+			}
+			return check(rules, fl.Field().String())
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	valid.RegisterAlias("password", passwordTagRules)
+	return nil
+}
+
+// passwordRuleMessage explains a failed password rule in terms of the policy
+func passwordRuleMessage(policy password.Policy, tag string) (string, bool) {
+	switch tag {
+	case "password_length":
+		return fmt.Sprintf("password must be at least %d characters long", policy.MinLength), true
+	case "password_classes":
+		var classes []string
+		if policy.RequireUpper {
+			classes = append(classes, "an uppercase letter")
+		}
+		if policy.RequireLower {
+			classes = append(classes, "a lowercase letter")
+		}
+		if policy.RequireDigit {
+			classes = append(classes, "a digit")
+		}
+		if policy.RequireSymbol {
+			classes = append(classes, "a symbol")
+		}
+		return "password must contain " + strings.Join(classes, ", "), true
+	case "password_breached":
+		return "password appears in a list of breached passwords, choose another one", true
+	case "password_history":
+		return fmt.Sprintf("password must not match any of your last %d passwords", policy.HistorySize), true
+	}
+	return "", false
+}
+
+// passwordRulesFor loads the institute policy and, for an existing user, the
+// hashes covered by the no-reuse rule (currentHash included)
+func (server *Server) passwordRulesFor(ctx context.Context, instituteID int32, userID int32, currentHash string) (passwordRules, error) {
+	policy, err := server.passwordPolicy(ctx, instituteID)
+	if err != nil {
+		return passwordRules{}, err
+	}
+
+	rules := passwordRules{policy: policy}
+	if policy.HistorySize == 0 || userID == 0 {
+		return rules, nil
+	}
+
+	history, err := server.store.GetRecentPasswordHashes(ctx, pgdb.GetRecentPasswordHashesParams{
+		UserID: userID,
+		Limit:  int32(policy.HistorySize),
+	})
+	if err != nil {
+		return passwordRules{}, err
+	}
+	// accounts from before password history was recorded
+	if len(history) == 0 && currentHash != "" {
+		history = append(history, currentHash)
+	}
+	rules.history = history
+
+	return rules, nil
+}
+
+func (server *Server) passwordPolicy(ctx context.Context, instituteID int32) (password.Policy, error) {
+	row, err := server.store.GetPasswordPolicy(ctx, instituteID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return password.DefaultPolicy(), nil
+		}
+		return password.Policy{}, err
+	}
+
+	return policyFromRow(row), nil
+}
+
+// recordPassword adds a newly set password to the user's history
+func (server *Server) recordPassword(ctx context.Context, userID int32, passwordHash string) {
+	if err := server.store.RecordPasswordHistoryTx(ctx, userID, passwordHash); err != nil {
+		log.Printf("failed to record password history for user %d: %v", userID, err)
+	}
+}
+
+func (server *Server) getPasswordPolicy(c *fiber.Ctx) error {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	policy, err := server.passwordPolicy(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(policy)
+}
+
+func (server *Server) updatePasswordPolicy(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req PasswordPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 4️⃣ Save policy
+	row, err := server.store.UpsertPasswordPolicy(
+		c.Context(),
+		pgdb.UpsertPasswordPolicyParams{
+			InstituteID:   payload.InstituteID,
+			MinLength:     int32(req.MinLength),
+			RequireUpper:  req.RequireUpper,
+			RequireLower:  req.RequireLower,
+			RequireDigit:  req.RequireDigit,
+			RequireSymbol: req.RequireSymbol,
+			HistorySize:   int32(req.HistorySize),
+			CheckBreached: req.CheckBreached,
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(policyFromRow(row))
+}
+
+func policyFromRow(row pgdb.InstitutePasswordPolicy) password.Policy {
+	return password.Policy{
+		MinLength:     int(row.MinLength),
+		RequireUpper:  row.RequireUpper,
+		RequireLower:  row.RequireLower,
+		RequireDigit:  row.RequireDigit,
+		RequireSymbol: row.RequireSymbol,
+		HistorySize:   min(int(row.HistorySize), pgdb.PasswordHistoryLimit),
+		CheckBreached: row.CheckBreached,
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"dashboard/password"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestPasswordValidators(t *testing.T) {
+	valid := validator.New()
+	if err := registerPasswordValidators(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	strict := passwordRules{policy: password.Policy{
+		MinLength:    10,
+		RequireUpper: true,
+		RequireDigit: true,
+	}}
+	reusedHash, err := password.HashPassword("Reused-password-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withHistory := passwordRules{
+		policy:  password.Policy{MinLength: 6, HistorySize: 1},
+		history: []string{reusedHash},
+	}
+
+	tests := []struct {
+		name     string
+		rules    *passwordRules // nil: the handler never loaded a policy
+		password string
+		wantOK   bool
+	}{
+		{"no rules, too short", nil, "abc", false},
+		{"no rules, minimum length", nil, "abcdef", true},
+		{"strict policy, too short", &strict, "Abc1", false},
+		{"strict policy, missing classes", &strict, "abcdefghijk", false},
+		{"strict policy met", &strict, "Abcdefghij1", true},
+		{"reused password", &withHistory, "Reused-password-1", false},
+		{"new password", &withHistory, "Fresh-password-2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.rules != nil {
+				ctx = withPasswordRules(ctx, *tt.rules)
+			}
+
+			err := valid.VarCtx(ctx, tt.password, "password")
+			if ok := err == nil; ok != tt.wantOK {
+				t.Fatalf("valid = %v, want %v (%v)", ok, tt.wantOK, err)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsHidePasswords(t *testing.T) {
+	valid := validator.New()
+	if err := valid.RegisterValidation("role", validateRole); err != nil {
+		t.Fatal(err)
+	}
+	if err := registerPasswordValidators(valid); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{valid: valid}
+
+	strict := passwordRules{policy: password.Policy{MinLength: 10, RequireDigit: true}}
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		value string
+	}{
+		{"pre-validation without a policy", context.Background(), "abc"},
+		{"institute policy, too short", withPasswordRules(context.Background(), strict), "abc"},
+		{"institute policy, missing digit", withPasswordRules(context.Background(), strict), "abcdefghijkl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := server.validateCtx(tt.ctx, CreateUserRequest{
+				Name:     "Some User",
+				Email:    "user@example.com",
+				Password: tt.value,
+				Role:     RoleViewer,
+			})
+			if len(errs) != 1 {
+				t.Fatalf("got %d errors, want 1: %+v", len(errs), errs)
+			}
+			if errs[0].Value != "" || strings.Contains(errs[0].Msg, tt.value) {
+				t.Fatalf("error echoes the password: %+v", errs[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"dashboard/db/pgdb"
+	"dashboard/mailer"
+	"dashboard/password"
+	"dashboard/token"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const passwordResetTokenSize = 32
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,password"`
+}
+
+func (server *Server) forgotPassword(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// Same answer whether or not the email exists (no account enumeration)
+	response := msgResponse{Msg: "if the email is registered, a reset link has been sent"}
+
+	// 3️⃣ Find active user
+	user, err := server.store.LoginUser(
+		c.Context(),
+		pgdb.LoginUserParams{
+			Email: req.Email,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return c.JSON(response)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Only the latest reset token stays usable
+	if err := server.store.InvalidateUserPasswordResetTokens(c.Context(), user.ID); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Generate single-use token
+	resetToken, hash, err := token.GenerateTokenAndHash(passwordResetTokenSize)
+	if err != nil {
+		return InternalServerError("failed to generate reset token")
+	}
+
+	expiresAt := time.Now().Add(server.config.PasswordResetDuration)
+	_, err = server.store.CreatePasswordResetToken(
+		c.Context(),
+		pgdb.CreatePasswordResetTokenParams{
+			UserID:    user.ID,
+			TokenHash: hash,
+			ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 6️⃣ Send email
+	err = server.mailer.Send(c.Context(), mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\r\n\r\nUse the link below to reset your password. It expires in %s.\r\n\r\n%s\r\n\r\nIf you did not request this, you can ignore this email.\r\n",
+			user.Name,
+			server.config.PasswordResetDuration,
+			server.passwordResetLink(resetToken),
+		),
+	})
+	if err != nil {
+		log.Printf("failed to send password reset email to user %d: %v", user.ID, err)
+	}
+
+	return c.JSON(response)
+}
+
+func (server *Server) resetPassword(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	invalidToken := fiber.NewError(
+		fiber.StatusBadRequest,
+		"invalid or expired reset token",
+	)
+
+	// 3️⃣ Look up token
+	resetToken, err := server.store.GetPasswordResetTokenByHash(
+		c.Context(),
+		token.GetTokenHash(req.Token),
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidToken
+		}
+		return InternalServerError(err.Error())
+	}
+
+	if resetToken.UsedAt.Valid || time.Now().After(resetToken.ExpiresAt.Time) {
+		return invalidToken
+	}
+
+	// 4️⃣ User must still be active
+	user, err := server.store.LoginUser(
+		c.Context(),
+		pgdb.LoginUserParams{
+			ID: resetToken.UserID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidToken
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Institute password policy
+	rules, err := server.passwordRulesFor(c.Context(), user.InstituteID.Int32, user.ID, user.Password)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if validationErrors := server.validateCtx(withPasswordRules(c.Context(), rules), req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 6️⃣ Hash new password
+	hashedPassword, err := password.HashPassword(req.NewPassword)
+	if err != nil {
+		return InternalServerError("failed to hash password")
+	}
+
+	// 7️⃣ Consume token and update password
+	_, err = server.store.ResetPasswordTx(
+		c.Context(),
+		pgdb.ResetPasswordTxParams{
+			TokenID:     resetToken.ID,
+			UserID:      user.ID,
+			InstituteID: user.InstituteID,
+			Password:    hashedPassword,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return invalidToken
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 8️⃣ Sign out everywhere
+	if err := server.revokeUserAccess(c.Context(), user.ID); err != nil {
+		return InternalServerError(err.Error())
+	}
+	server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventPasswordChanged, user.Email, "password reset")
+
+	return c.JSON(msgResponse{Msg: "password has been reset successfully"})
+}
+
+func (server *Server) passwordResetLink(resetToken string) string {
+	return tokenLink(server.config.PasswordResetURL, resetToken)
+}
+
+// tokenLink sets ?token= on a configured frontend URL, keeping any query it
+// already has. Without a URL the bare token is mailed.
+func tokenLink(base string, value string) string {
+	if base == "" {
+		return value
+	}
+
+	link, err := url.Parse(base)
+	if err != nil {
+		// LoadConfig rejects invalid URLs, so this is not expected
+		return value
+	}
+
+	query := link.Query()
+	query.Set("token", value)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
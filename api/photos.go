@@ -261,15 +261,7 @@ func (server *Server) deletePhoto(c *fiber.Ctx) error {
 		)
 	}
 
-	// 3️⃣ Admin check
-	if payload.Role != "admin" {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"admin access required",
-		)
-	}
-
-	// 4️⃣ Fetch photo (INSTITUTE SCOPED)
+	// 3️⃣ Fetch photo (INSTITUTE SCOPED)
 	photo, err := server.store.GetPhotoByID(
 		c.Context(),
 		pgdb.GetPhotoByIDParams{
@@ -284,7 +276,7 @@ func (server *Server) deletePhoto(c *fiber.Ctx) error {
 		return InternalServerError(err.Error())
 	}
 
-	// 5️⃣ Delete image from Cloudinary (SAFE)
+	// 4️⃣ Delete image from Cloudinary (SAFE)
 	if photo.CloudinaryPublicID.Valid {
 		_ = utils.DeleteImage(
 			c.Context(),
@@ -292,7 +284,7 @@ func (server *Server) deletePhoto(c *fiber.Ctx) error {
 		)
 	}
 
-	// 6️⃣ Delete DB record
+	// 5️⃣ Delete DB record
 	err = server.store.DeletePhoto(
 		c.Context(),
 		pgdb.DeletePhotoParams{
@@ -304,7 +296,7 @@ func (server *Server) deletePhoto(c *fiber.Ctx) error {
 		return InternalServerError(err.Error())
 	}
 
-	// 7️⃣ Response
+	// 6️⃣ Response
 	return c.JSON(fiber.Map{
 		"message": "photo deleted successfully",
 	})
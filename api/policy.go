@@ -0,0 +1,134 @@
+package api
+
+import (
+	"dashboard/token"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Permission string
+
+const (
+	PermUserRead      Permission = "user:read"
+	PermUserWrite     Permission = "user:write"
+	PermNoticeRead    Permission = "notice:read"
+	PermNoticeWrite   Permission = "notice:write"
+	PermNoticeDelete  Permission = "notice:delete"
+	PermPhotoRead     Permission = "photo:read"
+	PermPhotoWrite    Permission = "photo:write"
+	PermPhotoDelete   Permission = "photo:delete"
+	PermCarouselRead  Permission = "carousel:read"
+	PermCarouselWrite Permission = "carousel:write"
+	PermSettingsWrite Permission = "settings:write"
+
+	PermInstituteRead  Permission = "institute:read"
+	PermInstituteWrite Permission = "institute:write"
+)
+
+const (
+	RoleSuperAdmin = "superadmin"
+	RoleAdmin      = "admin"
+	RoleEditor     = "editor"
+	RoleViewer     = "viewer"
+
+	// machine clients; never stored on a user, permissions come from key scopes
+	RoleAPIKey = "api_key"
+)
+
+// rolePermissions is the single source of truth for what each role may do.
+// Roles missing from this map have no permissions and are rejected on user create/update.
+// The database only accepts these roles (migration 000024), so keep them in sync.
+var rolePermissions = map[string][]Permission{
+	// platform level: manages institutes, not bound to an institute_id
+	RoleSuperAdmin: {
+		PermInstituteRead, PermInstituteWrite,
+	},
+	RoleAdmin: {
+		PermUserRead, PermUserWrite,
+		PermNoticeRead, PermNoticeWrite, PermNoticeDelete,
+		PermPhotoRead, PermPhotoWrite, PermPhotoDelete,
+		PermCarouselRead, PermCarouselWrite,
+		PermSettingsWrite,
+	},
+	RoleEditor: {
+		PermUserRead,
+		PermNoticeRead, PermNoticeWrite,
+		PermPhotoRead, PermPhotoWrite,
+		PermCarouselRead, PermCarouselWrite,
+	},
+	RoleViewer: {
+		PermNoticeRead,
+		PermPhotoRead,
+		PermCarouselRead,
+	},
+}
+
+// apiKeyScopes are the permissions an API key may carry. Anything tied to a
+// user (user management, uploads attributed to a user, settings) is excluded.
+var apiKeyScopes = []Permission{
+	PermUserRead,
+	PermNoticeRead, PermNoticeWrite, PermNoticeDelete,
+	PermPhotoRead,
+	PermCarouselRead, PermCarouselWrite,
+}
+
+// scopeReadOnly expands to every read permission an API key may carry
+const scopeReadOnly = "read-only"
+
+func hasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadHasPermission checks role permissions for users and scopes for API keys
+func payloadHasPermission(payload *token.TokenPayload, perm Permission) bool {
+	if payload.Role != RoleAPIKey {
+		return hasPermission(payload.Role, perm)
+	}
+	for _, scope := range payload.Scopes {
+		if scope == string(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidRole reports whether role can be assigned to an institute user.
+// superadmin is platform level and never assignable through user endpoints.
+func isValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok && role != RoleSuperAdmin
+}
+
+// validateRole backs the `role` validator tag
+func validateRole(fl validator.FieldLevel) bool {
+	return isValidRole(fl.Field().String())
+}
+
+// require is a route middleware (after authMiddleware) that allows the
+// request only if the caller's role grants the given permission
+func (server *Server) require(perm Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+		if !ok {
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"invalid auth context",
+			)
+		}
+
+		if !payloadHasPermission(payload, perm) {
+			return fiber.NewError(
+				fiber.StatusForbidden,
+				"permission denied: requires "+string(perm),
+			)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"dashboard/utils"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxAvatarSize = 5 << 20 // 5 MB
+
+	// body limit of an avatar upload, see uploadBodyLimit
+	maxAvatarBody = maxAvatarSize + 1<<20
+)
+
+// avatarTypes are the image formats http.DetectContentType recognises that
+// we accept as avatars; the part's Content-Type header is up to the client
+var avatarTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type UpdateProfileRequest struct {
+	Name  string `json:"name" validate:"required,min=3"`
+	Phone string `json:"phone" validate:"omitempty,e164"`
+}
+
+type profileResponse struct {
+	ID          int32              `json:"id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	Phone       string             `json:"phone"`
+	Role        string             `json:"role"`
+	AvatarURL   string             `json:"avatar_url"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+func newProfileResponse(row pgdb.GetUserProfileRow) profileResponse {
+	return profileResponse{
+		ID:          row.ID,
+		InstituteID: row.InstituteID,
+		Name:        row.Name,
+		Email:       row.Email,
+		Phone:       row.Phone,
+		Role:        row.Role.String,
+		AvatarURL:   row.AvatarUrl.String,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+}
+
+// mePayload returns the caller's token payload; API keys have no profile
+func mePayload(c *fiber.Ctx) (*token.TokenPayload, error) {
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return nil, fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+	if payload.ID == 0 {
+		return nil, fiber.NewError(
+			fiber.StatusForbidden,
+			"api keys have no profile",
+		)
+	}
+	return payload, nil
+}
+
+func (server *Server) getMe(c *fiber.Ctx) error {
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	profile, err := server.store.GetUserProfile(c.Context(), int32(payload.ID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(newProfileResponse(profile))
+}
+
+func (server *Server) updateMe(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Update own profile (email and role stay with the admins)
+	profile, err := server.store.UpdateUserProfile(
+		c.Context(),
+		pgdb.UpdateUserProfileParams{
+			ID:    int32(payload.ID),
+			Name:  req.Name,
+			Phone: req.Phone,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(newProfileResponse(pgdb.GetUserProfileRow(profile)))
+}
+
+func (server *Server) uploadMyAvatar(c *fiber.Ctx) error {
+
+	// 1️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 2️⃣ Get image
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"image file is required",
+		)
+	}
+	if fileHeader.Size > maxAvatarSize {
+		return fiber.NewError(
+			fiber.StatusRequestEntityTooLarge,
+			"avatar must be at most 5 MB",
+		)
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return InternalServerError("failed to open image")
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return InternalServerError("failed to read image")
+	}
+	if !avatarTypes[http.DetectContentType(head[:n])] {
+		return fiber.NewError(
+			fiber.StatusUnsupportedMediaType,
+			"avatar must be a JPEG, PNG, GIF or WebP image",
+		)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return InternalServerError("failed to read image")
+	}
+
+	// 3️⃣ Current avatar (removed once the new one is saved)
+	old, err := server.store.GetUserProfile(c.Context(), int32(payload.ID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Upload new image
+	imageURL, publicID, err := utils.UploadImageStream(
+		c.Context(),
+		file,
+		server.config.ProfilesFolder,
+	)
+	if err != nil {
+		return InternalServerError("cloudinary upload failed")
+	}
+
+	// 5️⃣ Save it
+	profile, err := server.store.SetUserAvatar(
+		c.Context(),
+		pgdb.SetUserAvatarParams{
+			ID:             int32(payload.ID),
+			AvatarUrl:      pgtype.Text{String: imageURL, Valid: true},
+			AvatarPublicID: pgtype.Text{String: publicID, Valid: true},
+		},
+	)
+	if err != nil {
+		// don't leave the upload behind
+		server.deleteAvatar(c.Context(), publicID)
+		return InternalServerError(err.Error())
+	}
+
+	// 6️⃣ Delete old image
+	if old.AvatarPublicID.Valid {
+		server.deleteAvatar(c.Context(), old.AvatarPublicID.String)
+	}
+
+	return c.JSON(newProfileResponse(pgdb.GetUserProfileRow(profile)))
+}
+
+func (server *Server) deleteMyAvatar(c *fiber.Ctx) error {
+
+	// 1️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 2️⃣ Current avatar
+	old, err := server.store.GetUserProfile(c.Context(), int32(payload.ID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+	if !old.AvatarPublicID.Valid {
+		return NotFoundError("no avatar to remove")
+	}
+
+	// 3️⃣ Clear it
+	profile, err := server.store.SetUserAvatar(
+		c.Context(),
+		pgdb.SetUserAvatarParams{
+			ID: int32(payload.ID),
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Delete image
+	server.deleteAvatar(c.Context(), old.AvatarPublicID.String)
+
+	return c.JSON(newProfileResponse(pgdb.GetUserProfileRow(profile)))
+}
+
+// deleteAvatar removes an avatar from Cloudinary; a leftover asset is not
+// worth failing the request for
+func (server *Server) deleteAvatar(ctx context.Context, publicID string) {
+	if err := utils.DeleteImage(ctx, publicID); err != nil {
+		log.Printf("failed to delete avatar %s: %v", publicID, err)
+	}
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"dashboard/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getPublicKeys publishes the token verification key(s) so other services
+// (student portal, kiosks) can verify dashboard tokens on their own
+func (server *Server) getPublicKeys(c *fiber.Ctx) error {
+	source, ok := server.token.(token.PublicKeySource)
+	if !ok {
+		return NotFoundError("tokens are not signed with a public key")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(fiber.Map{
+		"keys": source.PublicKeys(),
+	})
+}
@@ -0,0 +1,381 @@
+package api
+
+import (
+	"dashboard/db/pgdb"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	publicFeedLimit = 50
+
+	// short, so scheduled notices show up soon after they go live; the etag
+	// middleware turns revalidations into 304s
+	publicFeedCacheControl = "public, max-age=60"
+)
+
+type publicNoticeResponse struct {
+	ID          int32                      `json:"id"`
+	Title       string                     `json:"title"`
+	Description string                     `json:"description"`
+	PublishAt   *time.Time                 `json:"publish_at"`
+	ExpireAt    *time.Time                 `json:"expire_at"`
+	URL         string                     `json:"url"`
+	Attachments []noticeAttachmentResponse `json:"attachments"`
+}
+
+type publicFeedResponse struct {
+	Institute string                 `json:"institute"`
+	Code      string                 `json:"code"`
+	Timezone  string                 `json:"timezone"`
+	Notices   []publicNoticeResponse `json:"notices"`
+}
+
+// rssFeed is an RSS 2.0 document
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description,omitempty"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+	PubDate     string        `xml:"pubDate"`
+}
+
+// rssEnclosure carries the first attachment; RSS allows only one per item
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// atomFeed is an Atom (RFC 4287) document
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Links     []atomLink `xml:"link"`
+	Summary   *atomText  `xml:"summary,omitempty"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// publicFeed is what the JSON, RSS and Atom variants are rendered from
+type publicFeed struct {
+	institute   pgdb.Institute
+	loc         *time.Location
+	baseURL     string // .../public/institutes/:code/notices
+	notices     []pgdb.Notice
+	attachments map[int32][]pgdb.NoticeAttachment
+}
+
+// noticeTime is when a notice went live; notices without publish_at were
+// live from creation
+func noticeTime(notice pgdb.Notice) time.Time {
+	if notice.PublishAt.Valid {
+		return notice.PublishAt.Time
+	}
+	return notice.CreatedAt.Time
+}
+
+func (feed publicFeed) noticeURL(notice pgdb.Notice) string {
+	return fmt.Sprintf("%s/%d", feed.baseURL, notice.ID)
+}
+
+// updated is the newest notice time, so the body (and its etag) only changes
+// with the notices
+func (feed publicFeed) updated() time.Time {
+	updated := feed.institute.CreatedAt.Time
+	for _, notice := range feed.notices {
+		if t := noticeTime(notice); t.After(updated) {
+			updated = t
+		}
+	}
+	return updated.In(feed.loc)
+}
+
+func (feed publicFeed) publicNotice(notice pgdb.Notice) publicNoticeResponse {
+	return publicNoticeResponse{
+		ID:          notice.ID,
+		Title:       notice.Title,
+		Description: notice.Description.String,
+		PublishAt:   localTime(notice.PublishAt, feed.loc),
+		ExpireAt:    localTime(notice.ExpireAt, feed.loc),
+		URL:         feed.noticeURL(notice),
+		Attachments: newAttachmentResponses(feed.attachments[notice.ID]),
+	}
+}
+
+// loadPublicInstitute resolves the institute of a public route from :code
+func (server *Server) loadPublicInstitute(c *fiber.Ctx) (publicFeed, error) {
+	institute, err := server.store.GetInstituteByCode(c.Context(), c.Params("code"))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return publicFeed{}, NotFoundError("institute not found")
+		}
+		return publicFeed{}, InternalServerError(err.Error())
+	}
+
+	loc, err := time.LoadLocation(institute.Timezone)
+	if err != nil {
+		return publicFeed{}, InternalServerError(err.Error())
+	}
+
+	return publicFeed{
+		institute: institute,
+		loc:       loc,
+		baseURL:   fmt.Sprintf("%s/public/institutes/%s/notices", server.publicBaseURL(c), url.PathEscape(institute.Code)),
+	}, nil
+}
+
+// publicBaseURL prefixes links in public feeds. Feeds are cached, so the
+// request's Host header is only used in development, where NewServer lets
+// PUBLIC_BASE_URL be empty.
+func (server *Server) publicBaseURL(c *fiber.Ctx) string {
+	if server.config.PublicBaseURL != "" {
+		return server.config.PublicBaseURL
+	}
+	return c.BaseURL()
+}
+
+// loadPublicFeed is the institute with its latest live notices
+func (server *Server) loadPublicFeed(c *fiber.Ctx) (publicFeed, error) {
+	feed, err := server.loadPublicInstitute(c)
+	if err != nil {
+		return publicFeed{}, err
+	}
+
+	feed.notices, err = server.store.GetPublicNotices(
+		c.Context(),
+		pgdb.GetPublicNoticesParams{
+			InstituteID: feed.institute.ID,
+			Limit:       publicFeedLimit,
+		},
+	)
+	if err != nil {
+		return publicFeed{}, InternalServerError(err.Error())
+	}
+
+	feed.attachments, err = server.noticeAttachments(c.Context(), feed.notices)
+	if err != nil {
+		return publicFeed{}, InternalServerError(err.Error())
+	}
+
+	return feed, nil
+}
+
+func (server *Server) getPublicNotices(c *fiber.Ctx) error {
+	feed, err := server.loadPublicFeed(c)
+	if err != nil {
+		return err
+	}
+
+	response := publicFeedResponse{
+		Institute: feed.institute.Name,
+		Code:      feed.institute.Code,
+		Timezone:  feed.loc.String(),
+		Notices:   make([]publicNoticeResponse, 0, len(feed.notices)),
+	}
+	for _, notice := range feed.notices {
+		response.Notices = append(response.Notices, feed.publicNotice(notice))
+	}
+
+	c.Set(fiber.HeaderCacheControl, publicFeedCacheControl)
+	return c.JSON(response)
+}
+
+func (server *Server) getPublicNotice(c *fiber.Ctx) error {
+
+	// 1️⃣ Read notice ID
+	noticeID, err := c.ParamsInt("id")
+	if err != nil || noticeID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid notice id",
+		)
+	}
+
+	// 2️⃣ Institute by code
+	feed, err := server.loadPublicInstitute(c)
+	if err != nil {
+		return err
+	}
+
+	// 3️⃣ Live notice only
+	notice, err := server.store.GetPublicNotice(
+		c.Context(),
+		pgdb.GetPublicNoticeParams{
+			ID:          int32(noticeID),
+			InstituteID: feed.institute.ID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("notice not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	feed.attachments, err = server.noticeAttachments(c.Context(), []pgdb.Notice{notice})
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	c.Set(fiber.HeaderCacheControl, publicFeedCacheControl)
+	return c.JSON(feed.publicNotice(notice))
+}
+
+func (server *Server) getPublicNoticesRSS(c *fiber.Ctx) error {
+	feed, err := server.loadPublicFeed(c)
+	if err != nil {
+		return err
+	}
+
+	channel := rssChannel{
+		Title:       feed.institute.Name + " notices",
+		Link:        feed.baseURL,
+		Description: "Notices published by " + feed.institute.Name,
+		AtomLink: atomLink{
+			Href: feed.baseURL + "/rss",
+			Rel:  "self",
+			Type: "application/rss+xml",
+		},
+		Items: make([]rssItem, 0, len(feed.notices)),
+	}
+	if len(feed.notices) > 0 {
+		channel.LastBuildDate = feed.updated().Format(time.RFC1123Z)
+	}
+
+	for _, notice := range feed.notices {
+		link := feed.noticeURL(notice)
+		item := rssItem{
+			Title:       notice.Title,
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			Description: notice.Description.String,
+			PubDate:     noticeTime(notice).In(feed.loc).Format(time.RFC1123Z),
+		}
+		if attachments := feed.attachments[notice.ID]; len(attachments) > 0 {
+			item.Enclosure = &rssEnclosure{
+				URL:    attachments[0].Url,
+				Length: attachments[0].SizeBytes,
+				Type:   attachments[0].ContentType,
+			}
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	return sendXML(c, "application/rss+xml; charset=utf-8", rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: channel,
+	})
+}
+
+func (server *Server) getPublicNoticesAtom(c *fiber.Ctx) error {
+	feed, err := server.loadPublicFeed(c)
+	if err != nil {
+		return err
+	}
+
+	document := atomFeed{
+		ID:      feed.baseURL,
+		Title:   feed.institute.Name + " notices",
+		Updated: feed.updated().Format(time.RFC3339),
+		Author:  atomAuthor{Name: feed.institute.Name},
+		Links: []atomLink{
+			{Href: feed.baseURL + "/atom", Rel: "self", Type: "application/atom+xml"},
+			{Href: feed.baseURL, Rel: "alternate", Type: "application/json"},
+		},
+		Entries: make([]atomEntry, 0, len(feed.notices)),
+	}
+
+	for _, notice := range feed.notices {
+		published := noticeTime(notice).In(feed.loc).Format(time.RFC3339)
+		entry := atomEntry{
+			ID:        feed.noticeURL(notice),
+			Title:     notice.Title,
+			Updated:   published,
+			Published: published,
+			Links: []atomLink{
+				{Href: feed.noticeURL(notice), Rel: "alternate", Type: "application/json"},
+			},
+		}
+		for _, attachment := range feed.attachments[notice.ID] {
+			entry.Links = append(entry.Links, atomLink{
+				Href:   attachment.Url,
+				Rel:    "enclosure",
+				Type:   attachment.ContentType,
+				Title:  attachment.FileName,
+				Length: attachment.SizeBytes,
+			})
+		}
+		if notice.Description.String != "" {
+			entry.Summary = &atomText{Type: "text", Value: notice.Description.String}
+		}
+		document.Entries = append(document.Entries, entry)
+	}
+
+	return sendXML(c, "application/atom+xml; charset=utf-8", document)
+}
+
+func sendXML(c *fiber.Ctx, contentType string, document any) error {
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, publicFeedCacheControl)
+	return c.Send(append([]byte(xml.Header), body...))
+}
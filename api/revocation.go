@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type revocationEntry struct {
+	revoked   bool
+	userID    int64
+	expiresAt time.Time
+}
+
+// revocationStore is the Postgres-backed token denylist with an in-memory cache.
+// Revoked results are cached until the token expires, valid results only for
+// cacheTTL so revocations made by other replicas are picked up quickly.
+type revocationStore struct {
+	store    pgdb.Store
+	cacheTTL time.Duration
+	// how long access tokens live; a user cutoff is only needed until every
+	// token issued before it has expired
+	tokenTTL time.Duration
+
+	mu     sync.RWMutex
+	tokens map[string]revocationEntry
+	users  map[int64]time.Time
+}
+
+func newRevocationStore(store pgdb.Store, cacheTTL time.Duration, tokenTTL time.Duration) *revocationStore {
+	return &revocationStore{
+		store:    store,
+		cacheTTL: cacheTTL,
+		tokenTTL: tokenTTL,
+		tokens:   make(map[string]revocationEntry),
+		users:    make(map[int64]time.Time),
+	}
+}
+
+func (r *revocationStore) IsRevoked(ctx context.Context, payload *token.TokenPayload) (bool, error) {
+	now := time.Now()
+
+	r.mu.RLock()
+	entry, cached := r.tokens[payload.TokenID]
+	cutoff, userRevoked := r.users[payload.ID]
+	r.mu.RUnlock()
+
+	if userRevoked && payload.IssuedAt.Before(cutoff) {
+		return true, nil
+	}
+	if cached && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := r.store.IsTokenRevoked(ctx, pgdb.IsTokenRevokedParams{
+		Jti:      payload.TokenID,
+		UserID:   int32(payload.ID),
+		IssuedAt: pgtype.Timestamptz{Time: payload.IssuedAt, Valid: true},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	entry = revocationEntry{
+		revoked:   revoked,
+		userID:    payload.ID,
+		expiresAt: now.Add(r.cacheTTL),
+	}
+	if revoked {
+		entry.expiresAt = payload.ExpiredAt
+	}
+
+	r.mu.Lock()
+	r.tokens[payload.TokenID] = entry
+	r.mu.Unlock()
+
+	return revoked, nil
+}
+
+// RevokeToken denylists a single token until it expires
+func (r *revocationStore) RevokeToken(ctx context.Context, payload *token.TokenPayload) error {
+	err := r.store.RevokeToken(ctx, pgdb.RevokeTokenParams{
+		Jti:       payload.TokenID,
+		UserID:    int32(payload.ID),
+		ExpiresAt: pgtype.Timestamptz{Time: payload.ExpiredAt, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.tokens[payload.TokenID] = revocationEntry{
+		revoked:   true,
+		userID:    payload.ID,
+		expiresAt: payload.ExpiredAt,
+	}
+	r.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUser rejects every token issued to the user up to now. The cutoff is
+// taken from this server's clock, the same clock that sets token IssuedAt.
+func (r *revocationStore) RevokeUser(ctx context.Context, userID int64) error {
+	row, err := r.store.RevokeUserTokens(ctx, pgdb.RevokeUserTokensParams{
+		UserID:        int32(userID),
+		RevokedBefore: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.users[userID] = row.RevokedBefore.Time
+	for jti, entry := range r.tokens {
+		if entry.userID == userID && !entry.revoked {
+			delete(r.tokens, jti)
+		}
+	}
+	r.mu.Unlock()
+
+	return nil
+}
+
+// prune drops expired cache entries, user cutoffs older than any live token
+// and denylist rows of expired tokens
+func (r *revocationStore) prune(ctx context.Context) {
+	now := time.Now()
+
+	r.mu.Lock()
+	for jti, entry := range r.tokens {
+		if now.After(entry.expiresAt) {
+			delete(r.tokens, jti)
+		}
+	}
+	for userID, cutoff := range r.users {
+		if now.After(cutoff.Add(r.tokenTTL)) {
+			delete(r.users, userID)
+		}
+	}
+	r.mu.Unlock()
+
+	if err := r.store.DeleteExpiredRevokedTokens(ctx); err != nil {
+		log.Printf("failed to delete expired revoked tokens: %v", err)
+	}
+}
+
+// revokeUserAccess locks a user out of every access token and refresh session
+// issued so far, e.g. after the account is disabled or its password changes.
+func (server *Server) revokeUserAccess(ctx context.Context, userID int32) error {
+	if err := server.revoked.RevokeUser(ctx, int64(userID)); err != nil {
+		return err
+	}
+	return server.store.RevokeUserSessions(ctx, userID)
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// runScheduler applies time-based changes that were scheduled ahead. It runs
+// more often than housekeeping so they happen close to their time.
+func (server *Server) runScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			server.applyScheduledStatusChanges(ctx)
+			server.advanceNoticeStatuses(ctx)
+		}
+	}
+}
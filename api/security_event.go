@@ -0,0 +1,110 @@
+package api
+
+import (
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	SecurityEventLoginSuccess      = "login_success"
+	SecurityEventLoginFailure      = "login_failure"
+	SecurityEventLoginLocked       = "login_locked"
+	SecurityEventPasswordChanged   = "password_changed"
+	SecurityEventAccountDisabled   = "account_disabled"
+	SecurityEventAccountEnabled    = "account_enabled"
+	SecurityEventTokenRefreshed    = "token_refreshed"
+	SecurityEventTokenReuse        = "refresh_token_reuse"
+	SecurityEventAccountOffboarded = "account_offboarded"
+	SecurityEventEmailChanged      = "email_changed"
+)
+
+// recordSecurityEvent stores an authentication event with the caller's IP and
+// user agent. userID and instituteID are 0 when unknown (e.g. a wrong email).
+// Failures are only logged, auditing must never block a login.
+func (server *Server) recordSecurityEvent(c *fiber.Ctx, userID int32, instituteID int32, eventType string, email string, detail string) {
+	err := server.store.CreateSecurityEvent(
+		c.Context(),
+		securityEventParams(c, userID, instituteID, eventType, email, detail),
+	)
+	if err != nil {
+		log.Printf("failed to record %s event for user %d: %v", eventType, userID, err)
+	}
+}
+
+// securityEventParams describes an event of the current request, for
+// transactions that record it together with the change
+func securityEventParams(c *fiber.Ctx, userID int32, instituteID int32, eventType string, email string, detail string) pgdb.CreateSecurityEventParams {
+	return pgdb.CreateSecurityEventParams{
+		UserID:      pgtype.Int4{Int32: userID, Valid: userID != 0},
+		InstituteID: pgtype.Int4{Int32: instituteID, Valid: instituteID != 0},
+		EventType:   eventType,
+		Email:       email,
+		Ip:          c.IP(),
+		UserAgent:   c.Get(fiber.HeaderUserAgent),
+		Detail:      detail,
+	}
+}
+
+func (server *Server) getSecurityEvents(c *fiber.Ctx) error {
+
+	// 1️⃣ Read user ID from URL
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Only user managers OR self user can see the events
+	if !payloadHasPermission(payload, PermUserWrite) && int64(userID) != payload.ID {
+		return fiber.NewError(
+			fiber.StatusForbidden,
+			"not allowed to view this user's security events",
+		)
+	}
+
+	// 4️⃣ Self, or a user of the caller's institute
+	user, err := server.targetUser(c.Context(), payload, int32(userID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Paging (?limit=, ?before=<event id>)
+	limit := c.QueryInt("limit", defaultPageLimit)
+	if limit <= 0 || limit > maxPageLimit {
+		limit = defaultPageLimit
+	}
+	before := c.QueryInt("before", 0)
+
+	// 6️⃣ Fetch events, newest first
+	events, err := server.store.GetUserSecurityEvents(
+		c.Context(),
+		pgdb.GetUserSecurityEventsParams{
+			UserID:   pgtype.Int4{Int32: user.ID, Valid: true},
+			BeforeID: pgtype.Int8{Int64: int64(before), Valid: before > 0},
+			RowLimit: int32(limit),
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(events)
+}
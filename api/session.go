@@ -0,0 +1,283 @@
+package api
+
+import (
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const refreshTokenSize = 32
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type refreshTokenResponse struct {
+	Token                 string    `json:"token"`
+	TokenExpiresAt        time.Time `json:"token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// newSessionParams generates a refresh token and the row that stores its hash.
+// An empty familyID starts a new session family (fresh login); rotations pass
+// the family's start so it never outlives SessionMaxLifetime. instituteID is
+// only set for sessions switched away from the home institute.
+func (server *Server) newSessionParams(c *fiber.Ctx, userID int32, familyID string, familyCreatedAt time.Time, instituteID pgtype.Int4) (string, pgdb.CreateSessionParams, error) {
+	now := time.Now()
+	if familyID == "" {
+		var err error
+		familyID, err = token.GenerateRandomStringURLSafe(16)
+		if err != nil {
+			return "", pgdb.CreateSessionParams{}, err
+		}
+		familyCreatedAt = now
+	}
+
+	expiresAt := now.Add(server.config.RefreshTokenDuration)
+	if familyEnd := familyCreatedAt.Add(server.config.SessionMaxLifetime); familyEnd.Before(expiresAt) {
+		expiresAt = familyEnd
+	}
+
+	refreshToken, hash, err := token.GenerateTokenAndHash(refreshTokenSize)
+	if err != nil {
+		return "", pgdb.CreateSessionParams{}, err
+	}
+
+	return refreshToken, pgdb.CreateSessionParams{
+		UserID:           userID,
+		FamilyID:         familyID,
+		RefreshTokenHash: hash,
+		UserAgent:        string(c.Request().Header.UserAgent()),
+		ClientIp:         c.IP(),
+		ExpiresAt: pgtype.Timestamptz{
+			Time:  expiresAt,
+			Valid: true,
+		},
+		InstituteID:     instituteID,
+		FamilyCreatedAt: pgtype.Timestamptz{Time: familyCreatedAt, Valid: true},
+	}, nil
+}
+
+// createSession starts a new refresh token family for a successful login or
+// an institute switch
+func (server *Server) createSession(c *fiber.Ctx, userID int32, instituteID pgtype.Int4) (string, pgdb.Session, error) {
+	refreshToken, arg, err := server.newSessionParams(c, userID, "", time.Time{}, instituteID)
+	if err != nil {
+		return "", pgdb.Session{}, err
+	}
+
+	session, err := server.store.CreateSession(c.Context(), arg)
+	if err != nil {
+		return "", pgdb.Session{}, err
+	}
+
+	return refreshToken, session, nil
+}
+
+func (server *Server) refreshAccessToken(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req refreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Look up session by token hash
+	session, err := server.store.GetSessionByTokenHash(
+		c.Context(),
+		token.GetTokenHash(req.RefreshToken),
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"invalid refresh token",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Revoked session
+	if session.IsRevoked {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"session has been revoked",
+		)
+	}
+
+	// 5️⃣ Reuse detection: an already rotated token means it was stolen
+	if session.RotatedAt.Valid {
+		return server.revokeStolenFamily(c, session)
+	}
+
+	// 6️⃣ Expired session (or family past its absolute lifetime)
+	if time.Now().After(session.ExpiresAt.Time) {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"refresh token expired",
+		)
+	}
+	if time.Now().After(session.FamilyCreatedAt.Time.Add(server.config.SessionMaxLifetime)) {
+		_ = server.store.RevokeSessionFamily(c.Context(), session.FamilyID)
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"session expired, please login again",
+		)
+	}
+
+	// 7️⃣ Reload user (must still be active)
+	user, err := server.store.LoginUser(
+		c.Context(),
+		pgdb.LoginUserParams{
+			ID: session.UserID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			_ = server.store.RevokeSessionFamily(c.Context(), session.FamilyID)
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"your account is disabled, please contact admin",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 🏫 Institute the session was switched to (membership must still exist)
+	instituteID, role, err := server.sessionInstitute(c.Context(), user, session)
+	if err != nil {
+		if errors.Is(err, errTwoFactorRequired) {
+			_ = server.store.RevokeSessionFamily(c.Context(), session.FamilyID)
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"this institute requires two-factor authentication, please login again",
+			)
+		}
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			_ = server.store.RevokeSessionFamily(c.Context(), session.FamilyID)
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"your institute membership has been removed, please login again",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 8️⃣ Rotate refresh token
+	refreshToken, arg, err := server.newSessionParams(c, user.ID, session.FamilyID, session.FamilyCreatedAt.Time, session.InstituteID)
+	if err != nil {
+		return InternalServerError("failed to generate refresh token")
+	}
+
+	newSession, err := server.store.RotateSessionTx(
+		c.Context(),
+		pgdb.RotateSessionTxParams{
+			OldSessionID: session.ID,
+			NewSession:   arg,
+		},
+	)
+	if err != nil {
+		// lost the race against another request using the same token
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return server.revokeStolenFamily(c, session)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 9️⃣ Issue new access token
+	accessToken, payload, err := server.token.CreateToken(
+		int64(user.ID),
+		user.Email,
+		role,
+		user.Name,
+		instituteID,
+		server.config.TokenDuration,
+	)
+	if err != nil {
+		return InternalServerError("failed to generate token")
+	}
+	server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventTokenRefreshed, user.Email, "")
+
+	// 🔟 Response
+	return c.JSON(refreshTokenResponse{
+		Token:                 accessToken,
+		TokenExpiresAt:        payload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newSession.ExpiresAt.Time,
+	})
+}
+
+func (server *Server) logout(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req refreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Look up session
+	session, err := server.store.GetSessionByTokenHash(
+		c.Context(),
+		token.GetTokenHash(req.RefreshToken),
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"invalid refresh token",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Revoke the whole session family
+	if err := server.store.RevokeSessionFamily(c.Context(), session.FamilyID); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 5️⃣ Revoke the current access token as well, if one was sent
+	authHeader := c.Get(AuthorizationHeaderKey)
+	if strings.HasPrefix(authHeader, BearerPrefix) {
+		payload, err := server.token.VerifyToken(strings.TrimPrefix(authHeader, BearerPrefix))
+		if err == nil && int32(payload.ID) == session.UserID {
+			if err := server.revoked.RevokeToken(c.Context(), payload); err != nil {
+				return InternalServerError(err.Error())
+			}
+		}
+	}
+
+	return c.JSON(msgResponse{Msg: "logged out successfully"})
+}
+
+func (server *Server) revokeStolenFamily(c *fiber.Ctx, session pgdb.Session) error {
+	if err := server.store.RevokeSessionFamily(c.Context(), session.FamilyID); err != nil {
+		return InternalServerError(err.Error())
+	}
+	server.recordSecurityEvent(c, session.UserID, 0, SecurityEventTokenReuse, "", "session family revoked")
+	return fiber.NewError(
+		fiber.StatusUnauthorized,
+		"refresh token reuse detected, please login again",
+	)
+}
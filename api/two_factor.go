@@ -0,0 +1,666 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/password"
+	"dashboard/token"
+	"dashboard/totp"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	loginChallengeSize        = 32
+	maxLoginChallengeAttempts = 5
+	recoveryCodeCount         = 10
+	totpSkew                  = 1
+)
+
+type loginChallengeResponse struct {
+	MFARequired        bool      `json:"mfa_required"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ChallengeExpiresAt time.Time `json:"challenge_expires_at"`
+}
+
+type totpEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type LoginChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+type VerifyLoginChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DisableTOTPRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type Require2FARequest struct {
+	Required bool `json:"required"`
+}
+
+func (server *Server) isTOTPEnabled(ctx context.Context, userID int32) (bool, error) {
+	userTOTP, err := server.store.GetUserTOTP(ctx, userID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return false, nil
+		}
+		return false, err
+	}
+	return userTOTP.ConfirmedAt.Valid, nil
+}
+
+// startLoginChallenge answers a correct password with a short-lived challenge
+// token instead of an access token. A valid instituteID is a switch to that
+// institute; the challenge then issues tokens for it.
+func (server *Server) startLoginChallenge(c *fiber.Ctx, user pgdb.User, enrollmentRequired bool, instituteID pgtype.Int4) error {
+	challengeToken, hash, err := token.GenerateTokenAndHash(loginChallengeSize)
+	if err != nil {
+		return InternalServerError("failed to generate challenge token")
+	}
+
+	challenge, err := server.store.CreateLoginChallenge(
+		c.Context(),
+		pgdb.CreateLoginChallengeParams{
+			UserID:    user.ID,
+			TokenHash: hash,
+			ExpiresAt: pgtype.Timestamptz{
+				Time:  time.Now().Add(server.config.LoginChallengeDuration),
+				Valid: true,
+			},
+			InstituteID: instituteID,
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(loginChallengeResponse{
+		MFARequired:        true,
+		EnrollmentRequired: enrollmentRequired,
+		ChallengeToken:     challengeToken,
+		ChallengeExpiresAt: challenge.ExpiresAt.Time,
+	})
+}
+
+// loadLoginChallenge returns a usable challenge and the (still active) user it belongs to
+func (server *Server) loadLoginChallenge(c *fiber.Ctx, challengeToken string) (pgdb.LoginChallenge, pgdb.User, error) {
+	invalid := fiber.NewError(
+		fiber.StatusUnauthorized,
+		"invalid or expired challenge token",
+	)
+
+	challenge, err := server.store.GetLoginChallengeByHash(c.Context(), token.GetTokenHash(challengeToken))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return pgdb.LoginChallenge{}, pgdb.User{}, invalid
+		}
+		return pgdb.LoginChallenge{}, pgdb.User{}, InternalServerError(err.Error())
+	}
+
+	if challenge.UsedAt.Valid ||
+		challenge.Attempts >= maxLoginChallengeAttempts ||
+		time.Now().After(challenge.ExpiresAt.Time) {
+		return pgdb.LoginChallenge{}, pgdb.User{}, invalid
+	}
+
+	user, err := server.store.LoginUser(
+		c.Context(),
+		pgdb.LoginUserParams{
+			ID: challenge.UserID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return pgdb.LoginChallenge{}, pgdb.User{}, invalid
+		}
+		return pgdb.LoginChallenge{}, pgdb.User{}, InternalServerError(err.Error())
+	}
+
+	return challenge, user, nil
+}
+
+func (server *Server) enrollLoginChallenge(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req LoginChallengeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Load challenge
+	_, user, err := server.loadLoginChallenge(c, req.ChallengeToken)
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Create pending secret
+	return server.enrollTOTP(c, user.ID, user.Email)
+}
+
+func (server *Server) verifyLoginChallenge(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req VerifyLoginChallengeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Load challenge
+	challenge, user, err := server.loadLoginChallenge(c, req.ChallengeToken)
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Brute-force protection, shared with the password step
+	retryAfter, err := server.loginThrottle.RetryAfter(c.Context(), user.Email, c.IP())
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if retryAfter > 0 {
+		return tooManyLoginAttempts(c, retryAfter)
+	}
+
+	// 5️⃣ Claim an attempt before checking the code
+	_, err = server.store.IncrementLoginChallengeAttempts(
+		c.Context(),
+		pgdb.IncrementLoginChallengeAttemptsParams{
+			ID:          challenge.ID,
+			MaxAttempts: maxLoginChallengeAttempts,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"invalid or expired challenge token",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 6️⃣ Load TOTP enrolment
+	userTOTP, err := server.store.GetUserTOTP(c.Context(), user.ID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusBadRequest,
+				"two-factor enrollment required, call /login/2fa/enroll first",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 7️⃣ Check the second factor
+	var recoveryCodes []string
+	verified := false
+
+	switch {
+	case !userTOTP.ConfirmedAt.Valid:
+		// first code of an enrollment forced by the institute policy
+		step, ok := totp.Validate(userTOTP.Secret, req.Code, time.Now(), totpSkew)
+		if ok {
+			recoveryCodes, err = server.confirmTOTP(c.Context(), user.ID, step)
+			if err != nil {
+				return err
+			}
+			verified = true
+		}
+
+	case req.Code != "":
+		verified, err = server.useTOTPCode(c.Context(), userTOTP, req.Code)
+		if err != nil {
+			return InternalServerError(err.Error())
+		}
+
+	default:
+		_, err = server.store.UseRecoveryCode(
+			c.Context(),
+			pgdb.UseRecoveryCodeParams{
+				UserID:   user.ID,
+				CodeHash: hashRecoveryCode(req.RecoveryCode),
+			},
+		)
+		if err != nil && pgdb.ErrorCode(err) != pgdb.ErrorNoRow {
+			return InternalServerError(err.Error())
+		}
+		verified = err == nil
+	}
+
+	if !verified {
+		locked, err := server.loginThrottle.RecordFailure(c.Context(), user.Email, c.IP())
+		if err != nil {
+			return InternalServerError(err.Error())
+		}
+		if locked {
+			server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventLoginLocked, user.Email, "too many failed attempts")
+			return tooManyLoginAttempts(c, server.config.LoginLockoutDuration)
+		}
+		server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventLoginFailure, user.Email, "invalid two-factor code")
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid two-factor code",
+		)
+	}
+
+	// 8️⃣ Challenge is single-use
+	if _, err := server.store.UseLoginChallenge(c.Context(), challenge.ID); err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusUnauthorized,
+				"invalid or expired challenge token",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 9️⃣ Both factors passed, clear the failure counter
+	if err := server.loginThrottle.RecordSuccess(c.Context(), user.Email); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 🔟 Issue the real tokens
+	if challenge.InstituteID.Valid {
+		return server.issueSwitch(c, user, challenge.InstituteID.Int32, recoveryCodes)
+	}
+	return server.issueLogin(c, user, recoveryCodes)
+}
+
+func (server *Server) getTOTPStatus(c *fiber.Ctx) error {
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	enabled, err := server.isTOTPEnabled(c.Context(), int32(payload.ID))
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	remaining, err := server.store.CountUnusedRecoveryCodes(c.Context(), int32(payload.ID))
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	required, err := server.isTOTPRequired(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"enabled":                  enabled,
+		"required":                 required,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+func (server *Server) enrollMyTOTP(c *fiber.Ctx) error {
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	return server.enrollTOTP(c, int32(payload.ID), payload.Email)
+}
+
+func (server *Server) confirmMyTOTP(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req TOTPCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Load pending enrolment
+	userTOTP, err := server.store.GetUserTOTP(c.Context(), int32(payload.ID))
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("no pending two-factor enrollment")
+		}
+		return InternalServerError(err.Error())
+	}
+	if userTOTP.ConfirmedAt.Valid {
+		return fiber.NewError(
+			fiber.StatusConflict,
+			"two-factor authentication is already enabled",
+		)
+	}
+
+	// 5️⃣ Verify first code
+	step, ok := totp.Validate(userTOTP.Secret, req.Code, time.Now(), totpSkew)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid two-factor code",
+		)
+	}
+
+	// 6️⃣ Enable and hand out recovery codes (shown once)
+	recoveryCodes, err := server.confirmTOTP(c.Context(), userTOTP.UserID, step)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message":        "two-factor authentication enabled",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+func (server *Server) regenerateRecoveryCodes(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req TOTPCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Require a current code
+	userTOTP, err := server.store.GetUserTOTP(c.Context(), int32(payload.ID))
+	if err != nil || !userTOTP.ConfirmedAt.Valid {
+		if err == nil || pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("two-factor authentication is not enabled")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	verified, err := server.useTOTPCode(c.Context(), userTOTP, req.Code)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if !verified {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid two-factor code",
+		)
+	}
+
+	// 5️⃣ Replace recovery codes
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return InternalServerError("failed to generate recovery codes")
+	}
+	if err := server.store.ReplaceRecoveryCodesTx(c.Context(), userTOTP.UserID, hashes); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"recovery_codes": codes,
+	})
+}
+
+func (server *Server) disableMyTOTP(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req DisableTOTPRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 3️⃣ Get token payload
+	payload, err := mePayload(c)
+	if err != nil {
+		return err
+	}
+
+	// 4️⃣ Not allowed when the institute enforces 2FA
+	required, err := server.isTOTPRequired(c.Context(), payload.InstituteID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if required {
+		return fiber.NewError(
+			fiber.StatusForbidden,
+			"two-factor authentication is required by your institute",
+		)
+	}
+
+	// 5️⃣ Re-check password
+	user, err := server.store.LoginUser(
+		c.Context(),
+		pgdb.LoginUserParams{
+			ID: int32(payload.ID),
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+	if _, err := password.CheckPassword(req.Password, user.Password); err != nil {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"password is incorrect",
+		)
+	}
+
+	// 6️⃣ Remove secret and recovery codes
+	if err := server.store.DisableTOTPTx(c.Context(), user.ID); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(msgResponse{Msg: "two-factor authentication disabled"})
+}
+
+func (server *Server) setInstituteRequire2FA(c *fiber.Ctx) error {
+
+	// 1️⃣ Parse request body
+	var req Require2FARequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid request body",
+		)
+	}
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Update institute policy
+	institute, err := server.store.SetInstituteRequire2FA(
+		c.Context(),
+		pgdb.SetInstituteRequire2FAParams{
+			ID:         payload.InstituteID,
+			Require2fa: req.Required,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("institute not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"institute_id": institute.ID,
+		"require_2fa":  institute.Require2fa,
+	})
+}
+
+func (server *Server) enrollTOTP(c *fiber.Ctx, userID int32, email string) error {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return InternalServerError("failed to generate secret")
+	}
+
+	_, err = server.store.UpsertUserTOTP(
+		c.Context(),
+		pgdb.UpsertUserTOTPParams{
+			UserID: userID,
+			Secret: secret,
+		},
+	)
+	if err != nil {
+		// the upsert skips confirmed rows
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"two-factor authentication is already enabled",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(totpEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(server.config.TOTPIssuer, email, secret),
+	})
+}
+
+func (server *Server) confirmTOTP(ctx context.Context, userID int32, step int64) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, InternalServerError("failed to generate recovery codes")
+	}
+
+	_, err = server.store.EnableTOTPTx(ctx, pgdb.EnableTOTPTxParams{
+		UserID:     userID,
+		Step:       step,
+		CodeHashes: hashes,
+	})
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return nil, fiber.NewError(
+				fiber.StatusConflict,
+				"two-factor authentication is already enabled",
+			)
+		}
+		return nil, InternalServerError(err.Error())
+	}
+
+	return codes, nil
+}
+
+// useTOTPCode validates a code and records its step so it cannot be replayed
+func (server *Server) useTOTPCode(ctx context.Context, userTOTP pgdb.UserTotp, code string) (bool, error) {
+	step, ok := totp.Validate(userTOTP.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+
+	_, err := server.store.UseTOTPStep(ctx, pgdb.UseTOTPStepParams{
+		UserID:       userTOTP.UserID,
+		LastUsedStep: pgtype.Int8{Int64: step, Valid: true},
+	})
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (server *Server) isTOTPRequired(ctx context.Context, instituteID int32) (bool, error) {
+	if instituteID == 0 {
+		return false, nil
+	}
+
+	institute, err := server.store.GetInstituteByID(ctx, instituteID)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return false, nil
+		}
+		return false, err
+	}
+	return institute.Require2fa, nil
+}
+
+// generateRecoveryCodes returns codes formatted as XXXX-XXXX and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		b, err := token.GenerateRandomBytes(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw := base32.StdEncoding.EncodeToString(b)
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	return token.GetTokenHash(code)
+}
@@ -2,7 +2,11 @@ package api
 
 import (
 	"dashboard/db/pgdb"
+	"dashboard/password"
 	"dashboard/token"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,33 +19,38 @@ type userLoginRequest struct {
 }
 
 type userLoginResponse struct {
-	Token          string    `json:"token"`
-	TokenExpiresAt time.Time `json:"token_expires_at"`
-	ID             int64     `json:"id"`
-	Email          string    `json:"email"`
-	Name           string    `json:"name"`
-	Role           string    `json:"role"`
-	InstituteID    int32     `json:"institute_id"`
+	Token                 string    `json:"token"`
+	TokenExpiresAt        time.Time `json:"token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	ID                    int64     `json:"id"`
+	Email                 string    `json:"email"`
+	Name                  string    `json:"name"`
+	Role                  string    `json:"role"`
+	InstituteID           int32     `json:"institute_id"`
+	RecoveryCodes         []string  `json:"recovery_codes,omitempty"`
+	// every institute the user can switch to, home institute first
+	Institutes []pgdb.GetUserInstitutesRow `json:"institutes"`
 }
 
 // ✅ Create user request (ADMIN)
 type CreateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=3"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+	Role     string `json:"role" validate:"required,role"`
 	IsActive bool   `json:"is_active"`
 }
 
 type UpdateUserRequest struct {
 	Name     string `json:"name" validate:"required,min=3"`
-	Role     string `json:"role" validate:"required"`
+	Role     string `json:"role" validate:"required,role"`
 	IsActive bool   `json:"is_active"`
 }
 
 type UpdateUserPasswordRequest struct {
 	OldPassword string `json:"old_password" validate:"required"`
-	NewPassword string `json:"new_password" validate:"required,min=6"`
+	NewPassword string `json:"new_password" validate:"required,password"`
 }
 
 func (server *Server) createUser(c *fiber.Ctx) error {
@@ -55,12 +64,7 @@ func (server *Server) createUser(c *fiber.Ctx) error {
 		)
 	}
 
-	// 2️⃣ Validate request
-	if validationErrors := server.validate(req); validationErrors != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
-	}
-
-	// 3️⃣ Get token payload
+	// 2️⃣ Get token payload
 	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
 	if !ok {
 		return fiber.NewError(
@@ -69,12 +73,19 @@ func (server *Server) createUser(c *fiber.Ctx) error {
 		)
 	}
 
-	// 4️⃣ Admin-only access
-	if payload.Role != "admin" {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"admin access required",
-		)
+	// 3️⃣ Validate request (against the institute password policy)
+	rules, err := server.passwordRulesFor(c.Context(), payload.InstituteID, 0, "")
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if validationErrors := server.validateCtx(withPasswordRules(c.Context(), rules), req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 4️⃣ Hash password
+	hashedPassword, err := password.HashPassword(req.Password)
+	if err != nil {
+		return InternalServerError("failed to hash password")
 	}
 
 	// 5️⃣ Create user (in same institute)
@@ -84,7 +95,7 @@ func (server *Server) createUser(c *fiber.Ctx) error {
 			InstituteID: payload.InstituteID,
 			Name:        req.Name,
 			Email:       req.Email,
-			Password:    req.Password, // ⚠️ hash later
+			Password:    hashedPassword,
 
 			Role: pgtype.Text{
 				String: req.Role,
@@ -107,6 +118,7 @@ func (server *Server) createUser(c *fiber.Ctx) error {
 		}
 		return InternalServerError(err.Error())
 	}
+	server.recordPassword(c.Context(), user.ID, hashedPassword)
 
 	// 6️⃣ Safe role
 	role := ""
@@ -140,14 +152,32 @@ func (server *Server) updateUser(c *fiber.Ctx) error {
 	}
 
 	// 3️⃣ Validate request
-	if err := server.valid.Struct(req); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
 	}
 
 	// 4️⃣ Get institute_id from JWT
-	authPayload := c.Locals("authPayload").(*token.TokenPayload)
+	authPayload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid auth context")
+	}
+
+	// 5️⃣ Current status, for the status history
+	before, err := server.store.GetUserByID(c.Context(), pgdb.GetUserByIDParams{
+		ID:          int32(userID),
+		InstituteID: authPayload.InstituteID,
+	})
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return fiber.NewError(
+				fiber.StatusNotFound,
+				"user not found",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
 
-	// 5️⃣ Prepare DB params (sqlc + pgtype)
+	// 6️⃣ Prepare DB params (sqlc + pgtype)
 	arg := pgdb.UpdateUserParams{
 		ID:          int32(userID),
 		Name:        req.Name,
@@ -156,7 +186,7 @@ func (server *Server) updateUser(c *fiber.Ctx) error {
 		InstituteID: authPayload.InstituteID, // 🔐 institute safety
 	}
 
-	// 6️⃣ Execute query
+	// 7️⃣ Execute query
 	user, err := server.store.UpdateUser(c.Context(), arg)
 	if err != nil {
 
@@ -178,7 +208,31 @@ func (server *Server) updateUser(c *fiber.Ctx) error {
 		return InternalServerError(err.Error())
 	}
 
-	// 7️⃣ Response
+	// 8️⃣ Lock out existing tokens of a disabled user; an enabled one no
+	// longer needs their invite
+	if !req.IsActive {
+		if err := server.revokeUserAccess(c.Context(), user.ID); err != nil {
+			return InternalServerError(err.Error())
+		}
+	} else if err := server.store.DeleteOpenUserInvites(c.Context(), user.ID); err != nil {
+		return InternalServerError(err.Error())
+	}
+	if req.IsActive != (before.IsActive.Valid && before.IsActive.Bool) {
+		// like any immediate change, this one overrides the scheduled ones
+		_, err := server.store.CancelPendingUserStatusChanges(
+			c.Context(),
+			pgdb.CancelPendingUserStatusChangesParams{
+				UserID:      user.ID,
+				InstituteID: user.InstituteID.Int32,
+			},
+		)
+		if err != nil {
+			return InternalServerError(err.Error())
+		}
+		server.recordStatusChange(c, user.ID, user.InstituteID.Int32, user.Email, req.IsActive, authPayload)
+	}
+
+	// 9️⃣ Response
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "user updated successfully",
 		"user": fiber.Map{
@@ -227,22 +281,16 @@ func (server *Server) UpdateUserPassword(c *fiber.Ctx) error {
 		)
 	}
 
-	// 5️⃣ Only admin OR self user can update password
-	if payload.Role != "admin" && int64(userID) != payload.ID {
+	// 5️⃣ Only user managers OR self user can update password
+	if !hasPermission(payload.Role, PermUserWrite) && int64(userID) != payload.ID {
 		return fiber.NewError(
 			fiber.StatusForbidden,
 			"not allowed to update this user's password",
 		)
 	}
 
-	// 6️⃣ Fetch existing user
-	user, err := server.store.GetUserByID(
-		c.Context(),
-		pgdb.GetUserByIDParams{
-			ID:          int32(userID),
-			InstituteID: payload.InstituteID,
-		},
-	)
+	// 6️⃣ Fetch existing user (self, or a user of the caller's institute)
+	user, err := server.targetUser(c.Context(), payload, int32(userID))
 	if err != nil {
 		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
 			return NotFoundError("user not found")
@@ -251,105 +299,64 @@ func (server *Server) UpdateUserPassword(c *fiber.Ctx) error {
 	}
 
 	// 7️⃣ Verify old password
-	// ⚠️ Plain compare for now (hash later)
-	if user.Password != req.OldPassword {
+	if _, err := password.CheckPassword(req.OldPassword, user.Password); err != nil {
 		return fiber.NewError(
 			fiber.StatusUnauthorized,
 			"old password is incorrect",
 		)
 	}
 
-	// 8️⃣ Update password
-	updatedUser, err := server.store.UpdateUserPassword(
-		c.Context(),
-		pgdb.UpdateUserPasswordParams{
-			Password:    req.NewPassword,
-			ID:          int32(userID),
-			InstituteID: payload.InstituteID,
-		},
-	)
+	// 🔒 Institute password policy (length, classes, breached list, reuse)
+	rules, err := server.passwordRulesFor(c.Context(), user.InstituteID.Int32, user.ID, user.Password)
 	if err != nil {
 		return InternalServerError(err.Error())
 	}
-
-	// 9️⃣ Success response
-	return c.JSON(fiber.Map{
-		"message":    "password updated successfully",
-		"user_id":    updatedUser.ID,
-		"updated_at": updatedUser.UpdatedAt,
-	})
-}
-
-func (server *Server) DisableUser(c *fiber.Ctx) error {
-
-	// 1️⃣ Read user ID from URL
-	userID, err := c.ParamsInt("id")
-	if err != nil || userID <= 0 {
-		return fiber.NewError(
-			fiber.StatusBadRequest,
-			"invalid user id",
-		)
-	}
-
-	// 2️⃣ Get JWT payload
-	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
-	if !ok {
-		return fiber.NewError(
-			fiber.StatusUnauthorized,
-			"invalid auth context",
-		)
-	}
-
-	// 3️⃣ Admin-only access
-	if payload.Role != "admin" {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"admin access required",
-		)
+	if errs := server.validateCtx(withPasswordRules(c.Context(), rules), req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
 	}
 
-	// 4️⃣ Prevent admin disabling himself
-	if int64(userID) == payload.ID {
-		return fiber.NewError(
-			fiber.StatusBadRequest,
-			"you cannot disable your own account",
-		)
+	// 8️⃣ Hash new password
+	hashedPassword, err := password.HashPassword(req.NewPassword)
+	if err != nil {
+		return InternalServerError("failed to hash password")
 	}
 
-	// 5️⃣ Disable user (Institute scoped)
-	user, err := server.store.DisableUser(
+	// 9️⃣ Update password
+	updatedUser, err := server.store.UpdateUserPassword(
 		c.Context(),
-		pgdb.DisableUserParams{
-			ID:          int32(userID),
-			InstituteID: payload.InstituteID,
+		pgdb.UpdateUserPasswordParams{
+			Password:    hashedPassword,
+			ID:          user.ID,
+			InstituteID: user.InstituteID,
 		},
 	)
 	if err != nil {
-		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
-			return NotFoundError("user not found")
-		}
 		return InternalServerError(err.Error())
 	}
+	server.recordPassword(c.Context(), updatedUser.ID, hashedPassword)
+	server.recordSecurityEvent(c, updatedUser.ID, updatedUser.InstituteID.Int32, SecurityEventPasswordChanged, updatedUser.Email, "")
 
-	// 6️⃣ Safe role
-	role := ""
-	if user.Role.Valid {
-		role = user.Role.String
+	// 🔟 Revoke tokens issued with the old password
+	if err := server.revokeUserAccess(c.Context(), updatedUser.ID); err != nil {
+		return InternalServerError(err.Error())
 	}
 
-	// 7️⃣ Response
+	// ✅ Success response
 	return c.JSON(fiber.Map{
-		"message":    "user disabled successfully",
-		"id":         user.ID,
-		"institute":  user.InstituteID,
-		"name":       user.Name,
-		"email":      user.Email,
-		"role":       role,
-		"is_active":  user.IsActive,
-		"updated_at": user.UpdatedAt,
+		"message":    "password updated successfully",
+		"user_id":    updatedUser.ID,
+		"updated_at": updatedUser.UpdatedAt,
 	})
 }
 
+func (server *Server) DisableUser(c *fiber.Ctx) error {
+	return server.changeUserStatus(c, false)
+}
+
+func (server *Server) EnableUser(c *fiber.Ctx) error {
+	return server.changeUserStatus(c, true)
+}
+
 func (server *Server) userLogin(c *fiber.Ctx) error {
 	var req userLoginRequest
 
@@ -361,6 +368,15 @@ func (server *Server) userLogin(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
 	}
 
+	// ⏳ Brute-force protection (per email and per IP)
+	retryAfter, err := server.loginThrottle.RetryAfter(c.Context(), req.Email, c.IP())
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if retryAfter > 0 {
+		return tooManyLoginAttempts(c, retryAfter)
+	}
+
 	user, err := server.store.LoginUser(
 		c.Context(),
 		pgdb.LoginUserParams{
@@ -369,48 +385,137 @@ func (server *Server) userLogin(c *fiber.Ctx) error {
 	)
 	if err != nil {
 		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			if _, err := server.loginThrottle.RecordFailure(c.Context(), req.Email, c.IP()); err != nil {
+				return InternalServerError(err.Error())
+			}
+			server.recordSecurityEvent(c, 0, 0, SecurityEventLoginFailure, req.Email, "unknown email")
 			return NotFoundError("invalid email or password")
 		}
 		return InternalServerError(err.Error())
 	}
 
-	// ❌ Check if user is disabled
-	if user.IsActive.Valid && !user.IsActive.Bool {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"your account is disabled, please contact admin",
-		)
-	}
-
-	// ⚠️ Password check (hash later)
-	if user.Password != req.Password {
+	// 🔑 Password check
+	needsRehash, err := password.CheckPassword(req.Password, user.Password)
+	if err != nil {
+		locked, err := server.loginThrottle.RecordFailure(c.Context(), req.Email, c.IP())
+		if err != nil {
+			return InternalServerError(err.Error())
+		}
+		if locked {
+			server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventLoginLocked, user.Email, "too many failed attempts")
+			return tooManyLoginAttempts(c, server.config.LoginLockoutDuration)
+		}
+		server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventLoginFailure, user.Email, "wrong password")
 		return fiber.NewError(
 			fiber.StatusUnauthorized,
 			"invalid email or password",
 		)
 	}
 
+	// ❌ Check if the user's institute is disabled (super admins have none); only
+	// after the password, so the answer doesn't reveal the account
+	require2FA := false
+	if user.InstituteID.Valid {
+		institute, err := server.store.GetInstituteByID(c.Context(), user.InstituteID.Int32)
+		if err != nil {
+			if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+				server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventLoginFailure, user.Email, "institute disabled")
+				return fiber.NewError(
+					fiber.StatusForbidden,
+					"your institute is disabled, please contact support",
+				)
+			}
+			return InternalServerError(err.Error())
+		}
+		require2FA = institute.Require2fa
+	}
+
+	// ♻️ Upgrade legacy plaintext (or outdated) hashes on successful login
+	if needsRehash {
+		if hashedPassword, err := password.HashPassword(req.Password); err == nil {
+			_, err = server.store.UpdateUserPassword(
+				c.Context(),
+				pgdb.UpdateUserPasswordParams{
+					Password:    hashedPassword,
+					ID:          user.ID,
+					InstituteID: user.InstituteID,
+				},
+			)
+			if err != nil {
+				log.Printf("failed to rehash password for user %d: %v", user.ID, err)
+			}
+		}
+	}
+
+	// 🔐 Second factor (enrolled users, or everyone if the institute requires it)
+	totpEnabled, err := server.isTOTPEnabled(c.Context(), user.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	if totpEnabled || require2FA {
+		// the failure counter is cleared once the second factor passes
+		return server.startLoginChallenge(c, user, !totpEnabled, pgtype.Int4{})
+	}
+
+	if err := server.loginThrottle.RecordSuccess(c.Context(), req.Email); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return server.issueLogin(c, user, nil)
+}
+
+// issueLogin creates the access token and refresh session for an authenticated user
+func (server *Server) issueLogin(c *fiber.Ctx, user pgdb.User, recoveryCodes []string) error {
+	if err := server.issueTokens(c, user, user.InstituteID.Int32, user.Role.String, recoveryCodes); err != nil {
+		return err
+	}
+
+	server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, SecurityEventLoginSuccess, user.Email, "")
+	return nil
+}
+
+// issueTokens responds with an access token and a new refresh session scoped
+// to instituteID, which is the home institute or one of the user's memberships
+func (server *Server) issueTokens(c *fiber.Ctx, user pgdb.User, instituteID int32, role string, recoveryCodes []string) error {
+
 	// 🔐 Create JWT with institute_id
 	token, payload, err := server.token.CreateToken(
 		int64(user.ID),
 		user.Email,
-		user.Role.String,
+		role,
 		user.Name,
-		user.InstituteID,
+		instituteID,
 		server.config.TokenDuration,
 	)
 	if err != nil {
 		return InternalServerError("failed to generate token")
 	}
 
+	// 🔄 Create refresh token session (remembers a switched institute)
+	sessionInstitute := pgtype.Int4{Int32: instituteID, Valid: instituteID != user.InstituteID.Int32}
+	refreshToken, session, err := server.createSession(c, user.ID, sessionInstitute)
+	if err != nil {
+		return InternalServerError("failed to create session")
+	}
+
+	// 🏫 Institutes to switch between
+	institutes, err := server.store.GetUserInstitutes(c.Context(), user.ID)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
 	return c.JSON(userLoginResponse{
-		Token:          token,
-		TokenExpiresAt: payload.ExpiredAt,
-		ID:             payload.ID,
-		Email:          payload.Email,
-		Role:           payload.Role,
-		Name:           payload.Name,
-		InstituteID:    payload.InstituteID,
+		Token:                 token,
+		TokenExpiresAt:        payload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt.Time,
+		ID:                    payload.ID,
+		Email:                 payload.Email,
+		Role:                  payload.Role,
+		Name:                  payload.Name,
+		InstituteID:           payload.InstituteID,
+		RecoveryCodes:         recoveryCodes,
+		Institutes:            institutes,
 	})
 }
 
@@ -521,9 +626,39 @@ func (server *Server) getUserByEmail(c *fiber.Ctx) error {
 	})
 }
 
+type listUsersQuery struct {
+	Limit    int    `query:"limit" validate:"omitempty,min=1,max=200"`
+	Cursor   string `query:"cursor"`
+	Role     string `query:"role" validate:"omitempty,role"`
+	IsActive *bool  `query:"is_active"`
+	Search   string `query:"q" validate:"omitempty,max=100"`
+	Sort     string `query:"sort" validate:"omitempty,oneof=created_at -created_at name -name email -email"`
+}
+
 func (server *Server) getUsersByInstitute(c *fiber.Ctx) error {
 
-	// 1️⃣ Get token payload
+	// 1️⃣ Parse query
+	var req listUsersQuery
+	if err := c.QueryParser(&req); err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid query parameters",
+		)
+	}
+
+	// 2️⃣ Validate query
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultPageLimit
+	}
+	if req.Sort == "" {
+		req.Sort = "-created_at"
+	}
+
+	// 3️⃣ Get token payload
 	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
 	if !ok {
 		return fiber.NewError(
@@ -532,24 +667,79 @@ func (server *Server) getUsersByInstitute(c *fiber.Ctx) error {
 		)
 	}
 
-	// 2️⃣ (Optional but recommended) Admin-only access
-	if payload.Role != "admin" {
-		return fiber.NewError(
-			fiber.StatusForbidden,
-			"admin access required",
-		)
+	// 4️⃣ Filters (shared by the page and the total)
+	arg := pgdb.ListUsersByInstituteParams{
+		InstituteID: payload.InstituteID,
+		Role:        pgtype.Text{String: req.Role, Valid: req.Role != ""},
+		Search:      pgtype.Text{String: likePattern(req.Search), Valid: req.Search != ""},
+		Sort:        req.Sort,
+		RowLimit:    int32(req.Limit) + 1, // one extra row tells if there is a next page
+	}
+	if req.IsActive != nil {
+		arg.IsActive = pgtype.Bool{Bool: *req.IsActive, Valid: true}
+	}
+
+	// 5️⃣ Resume after the cursor
+	if req.Cursor != "" {
+		cursor, err := decodePageCursor(req.Cursor, req.Sort)
+		if err != nil {
+			return err
+		}
+		arg.CursorID = pgtype.Int4{Int32: cursor.ID, Valid: true}
+
+		switch strings.TrimPrefix(req.Sort, "-") {
+		case "created_at":
+			createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return fiber.NewError(
+					fiber.StatusBadRequest,
+					"invalid cursor",
+				)
+			}
+			arg.CursorTime = pgtype.Timestamptz{Time: createdAt, Valid: true}
+		default:
+			arg.CursorText = pgtype.Text{String: cursor.Value, Valid: true}
+		}
+	}
+
+	// 6️⃣ Fetch page and total
+	users, err := server.store.ListUsersByInstitute(c.Context(), arg)
+	if err != nil {
+		return InternalServerError(err.Error())
 	}
 
-	// 3️⃣ Fetch users
-	users, err := server.store.GetUsersByInstitute(
+	total, err := server.store.CountUsersByInstitute(
 		c.Context(),
-		payload.InstituteID,
+		pgdb.CountUsersByInstituteParams{
+			InstituteID: arg.InstituteID,
+			Role:        arg.Role,
+			IsActive:    arg.IsActive,
+			Search:      arg.Search,
+		},
 	)
 	if err != nil {
 		return InternalServerError(err.Error())
 	}
 
-	// 4️⃣ Build safe response
+	c.Set(HeaderTotalCount, strconv.FormatInt(total, 10))
+
+	if len(users) > req.Limit {
+		users = users[:req.Limit]
+		last := users[len(users)-1]
+
+		cursor := pageCursor{Sort: req.Sort, ID: last.ID}
+		switch strings.TrimPrefix(req.Sort, "-") {
+		case "created_at":
+			cursor.Value = last.CreatedAt.Time.Format(time.RFC3339Nano)
+		case "name":
+			cursor.Value = last.Name
+		case "email":
+			cursor.Value = last.Email
+		}
+		c.Set(HeaderNextCursor, cursor.encode())
+	}
+
+	// 7️⃣ Build safe response
 	response := make([]fiber.Map, 0, len(users))
 
 	for _, user := range users {
@@ -569,6 +759,6 @@ func (server *Server) getUsersByInstitute(c *fiber.Ctx) error {
 		})
 	}
 
-	// 5️⃣ Return response
+	// 8️⃣ Return response
 	return c.JSON(response)
 }
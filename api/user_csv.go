@@ -0,0 +1,397 @@
+package api
+
+import (
+	"bytes"
+	"dashboard/db/pgdb"
+	"dashboard/password"
+	"dashboard/token"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxImportRows     = 1000
+	maxImportFileSize = 1 << 20 // 1 MB
+)
+
+var exportColumns = []string{"id", "name", "email", "role", "is_active", "created_at"}
+
+type importRow struct {
+	line   int
+	create CreateUserRequest
+}
+
+type importRowError struct {
+	Row    int               `json:"row"` // line in the file, the header is line 1
+	Email  string            `json:"email"`
+	Errors []*validatorError `json:"errors"`
+}
+
+type importReport struct {
+	DryRun bool             `json:"dry_run"`
+	Invite bool             `json:"invite"`
+	Total  int              `json:"total"`
+	Valid  int              `json:"valid"`
+	Errors []importRowError `json:"errors"`
+}
+
+// importUsers creates users from a CSV file (multipart field "file") with the
+// columns name, email, role and optionally password and is_active.
+// ?dry_run=true only reports per-row errors, ?invite=true sends invitations
+// instead of taking passwords. Nothing is inserted unless every row is valid.
+func (server *Server) importUsers(c *fiber.Ctx) error {
+
+	// 1️⃣ Options
+	dryRun := c.QueryBool("dry_run")
+	invite := c.QueryBool("invite")
+
+	// 2️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Read CSV file
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"csv file is required",
+		)
+	}
+	if fileHeader.Size > maxImportFileSize {
+		return fiber.NewError(
+			fiber.StatusRequestEntityTooLarge,
+			fmt.Sprintf("csv file must be at most %d bytes", maxImportFileSize),
+		)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return InternalServerError("failed to open csv file")
+	}
+	defer file.Close()
+
+	rows, err := parseImportCSV(file, invite)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// 4️⃣ Validate every row with the create user rules
+	rules, err := server.passwordRulesFor(c.Context(), payload.InstituteID, 0, "")
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	ctx := withPasswordRules(c.Context(), rules)
+
+	report := importReport{
+		DryRun: dryRun,
+		Invite: invite,
+		Total:  len(rows),
+		Errors: []importRowError{},
+	}
+
+	rowErrors := make(map[int][]*validatorError)
+	seen := make(map[string]int)
+	emails := make([]string, 0, len(rows))
+
+	for i, row := range rows {
+		var errs []*validatorError
+		if invite {
+			errs = server.validate(InviteUserRequest{
+				Name:  row.create.Name,
+				Email: row.create.Email,
+				Role:  row.create.Role,
+			})
+		} else {
+			errs = server.validateCtx(ctx, row.create)
+		}
+
+		// emails are unique regardless of case
+		key := strings.ToLower(row.create.Email)
+		if first, ok := seen[key]; ok && key != "" {
+			errs = append(errs, emailConflict(row.create.Email, fmt.Sprintf("email repeats row %d", rows[first].line)))
+		} else {
+			seen[key] = i
+			emails = append(emails, row.create.Email)
+		}
+
+		if len(errs) > 0 {
+			rowErrors[i] = errs
+		}
+	}
+
+	// 5️⃣ Emails must not exist yet (in any institute)
+	existing, err := server.store.GetExistingUserEmails(c.Context(), emails)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+	for _, email := range existing {
+		// existing holds lowercased addresses
+		i := seen[email]
+		rowErrors[i] = append(rowErrors[i], emailConflict(rows[i].create.Email, "email already exists"))
+	}
+
+	for i, row := range rows {
+		if errs, ok := rowErrors[i]; ok {
+			report.Errors = append(report.Errors, importRowError{
+				Row:    row.line,
+				Email:  row.create.Email,
+				Errors: errs,
+			})
+		}
+	}
+	report.Valid = report.Total - len(report.Errors)
+
+	// 6️⃣ Preview or reject
+	if dryRun {
+		return c.JSON(report)
+	}
+	if len(report.Errors) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(report)
+	}
+
+	// 7️⃣ Hash passwords / generate invite tokens
+	params := pgdb.ImportUsersTxParams{
+		Users:     make([]pgdb.ImportUserRow, 0, len(rows)),
+		InvitedBy: pgtype.Int4{Int32: int32(payload.ID), Valid: payload.ID != 0},
+		InviteExpiresAt: pgtype.Timestamptz{
+			Time:  time.Now().Add(server.config.InviteDuration),
+			Valid: true,
+		},
+	}
+	inviteTokens := make([]string, 0, len(rows))
+
+	for _, row := range rows {
+		secret := row.create.Password
+		if invite {
+			// placeholder nobody knows, replaced on accept
+			secret, err = token.GenerateRandomStringURLSafe(32)
+			if err != nil {
+				return InternalServerError("failed to generate password")
+			}
+		}
+
+		hashedPassword, err := password.HashPassword(secret)
+		if err != nil {
+			return InternalServerError("failed to hash password")
+		}
+
+		importUser := pgdb.ImportUserRow{
+			User: pgdb.CreateUserParams{
+				InstituteID: payload.InstituteID,
+				Name:        row.create.Name,
+				Email:       row.create.Email,
+				Password:    hashedPassword,
+				Role:        pgtype.Text{String: row.create.Role, Valid: true},
+				IsActive:    pgtype.Bool{Bool: row.create.IsActive && !invite, Valid: true},
+			},
+		}
+
+		if invite {
+			inviteToken, hash, err := token.GenerateTokenAndHash(inviteTokenSize)
+			if err != nil {
+				return InternalServerError("failed to generate invite token")
+			}
+			importUser.InviteTokenHash = hash
+			inviteTokens = append(inviteTokens, inviteToken)
+		}
+
+		params.Users = append(params.Users, importUser)
+	}
+
+	// 8️⃣ Insert all rows in one transaction
+	users, err := server.store.ImportUsersTx(c.Context(), params)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorDuplicateKey {
+			return fiber.NewError(
+				fiber.StatusConflict,
+				"email already exists",
+			)
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 9️⃣ Send invites once the users exist
+	if invite {
+		for i, user := range users {
+			server.sendInvite(c.Context(), user.Name, user.Email, inviteTokens[i])
+		}
+	}
+
+	// 🔟 Response (NO passwords)
+	response := make([]fiber.Map, 0, len(users))
+	for _, user := range users {
+		response = append(response, fiber.Map{
+			"id":        user.ID,
+			"name":      user.Name,
+			"email":     user.Email,
+			"role":      user.Role.String,
+			"is_active": user.IsActive,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": fmt.Sprintf("%d users imported", len(users)),
+		"invite":  invite,
+		"users":   response,
+	})
+}
+
+// parseImportCSV maps the rows of the file onto CreateUserRequest by header name
+func parseImportCSV(r io.Reader, invite bool) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv file is empty")
+		}
+		return nil, fmt.Errorf("invalid csv: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+
+	required := []string{"name", "email", "role"}
+	if !invite {
+		required = append(required, "password")
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %q column", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("csv file must have at most %d rows", maxImportRows)
+		}
+
+		isActive := true
+		if value := field(record, "is_active"); value != "" {
+			isActive, err = strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: is_active must be true or false", line)
+			}
+		}
+
+		rows = append(rows, importRow{
+			line: line,
+			create: CreateUserRequest{
+				Name:     field(record, "name"),
+				Email:    field(record, "email"),
+				Password: field(record, "password"),
+				Role:     field(record, "role"),
+				IsActive: isActive,
+			},
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("csv file has no rows")
+	}
+
+	return rows, nil
+}
+
+func emailConflict(email string, msg string) *validatorError {
+	return &validatorError{
+		FailedField: "email",
+		Tag:         "unique",
+		Value:       email,
+		Msg:         msg,
+	}
+}
+
+func (server *Server) exportUsers(c *fiber.Ctx) error {
+
+	// 1️⃣ Get token payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 2️⃣ Fetch users
+	users, err := server.store.GetUsersByInstitute(
+		c.Context(),
+		payload.InstituteID,
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 3️⃣ Write CSV (NO passwords)
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(exportColumns); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	for _, user := range users {
+		record := []string{
+			strconv.Itoa(int(user.ID)),
+			csvSafe(user.Name),
+			csvSafe(user.Email),
+			csvSafe(user.Role.String),
+			strconv.FormatBool(user.IsActive.Valid && user.IsActive.Bool),
+			user.CreatedAt.Time.UTC().Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return InternalServerError(err.Error())
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Download
+	c.Attachment(fmt.Sprintf("users-%s.csv", time.Now().UTC().Format("2006-01-02")))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
+
+// csvSafe stops spreadsheet apps from evaluating a cell as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
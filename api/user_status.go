@@ -0,0 +1,319 @@
+package api
+
+import (
+	"context"
+	"dashboard/db/pgdb"
+	"dashboard/token"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// scheduled status changes applied per scheduler run
+const statusChangeBatchSize = 100
+
+type UserStatusRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+	// apply the change at this time instead of now (e.g. end of a contract)
+	At *time.Time `json:"at"`
+}
+
+// changeUserStatus enables or disables a user now, or schedules it when the
+// body has a future `at`. The body is optional.
+func (server *Server) changeUserStatus(c *fiber.Ctx, isActive bool) error {
+
+	// 1️⃣ Read user ID from URL
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+
+	// 2️⃣ Parse optional request body
+	var req UserStatusRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(
+				fiber.StatusBadRequest,
+				"invalid request body",
+			)
+		}
+	}
+
+	// 3️⃣ Validate request
+	if validationErrors := server.validate(req); validationErrors != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(validationErrors)
+	}
+
+	// 4️⃣ Get JWT payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 5️⃣ Prevent admin disabling himself
+	if !isActive && int64(userID) == payload.ID {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"you cannot disable your own account",
+		)
+	}
+
+	changedBy := pgtype.Int4{Int32: int32(payload.ID), Valid: payload.ID != 0}
+
+	// 6️⃣ Schedule for later
+	if req.At != nil && req.At.After(time.Now()) {
+		user, err := server.store.GetUserByID(
+			c.Context(),
+			pgdb.GetUserByIDParams{
+				ID:          int32(userID),
+				InstituteID: payload.InstituteID,
+			},
+		)
+		if err != nil {
+			if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+				return NotFoundError("user not found")
+			}
+			return InternalServerError(err.Error())
+		}
+
+		change, err := server.store.CreateUserStatusChange(
+			c.Context(),
+			pgdb.CreateUserStatusChangeParams{
+				UserID:      user.ID,
+				InstituteID: payload.InstituteID,
+				IsActive:    isActive,
+				Reason:      req.Reason,
+				ChangedBy:   changedBy,
+				EffectiveAt: pgtype.Timestamptz{Time: *req.At, Valid: true},
+			},
+		)
+		if err != nil {
+			return InternalServerError(err.Error())
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "status change scheduled",
+			"change":  change,
+		})
+	}
+
+	// 7️⃣ Change status now (Institute scoped)
+	result, err := server.store.ChangeUserStatusTx(
+		c.Context(),
+		pgdb.ChangeUserStatusTxParams{
+			UserID:      int32(userID),
+			InstituteID: payload.InstituteID,
+			IsActive:    isActive,
+			Reason:      req.Reason,
+			ChangedBy:   changedBy,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+	user := result.User
+
+	// 8️⃣ Revoke all tokens and sessions of a disabled user
+	message := "user enabled successfully"
+	eventType := SecurityEventAccountEnabled
+	if !isActive {
+		if err := server.revokeUserAccess(c.Context(), user.ID); err != nil {
+			return InternalServerError(err.Error())
+		}
+		message = "user disabled successfully"
+		eventType = SecurityEventAccountDisabled
+	}
+	server.recordSecurityEvent(c, user.ID, user.InstituteID.Int32, eventType, user.Email, req.Reason)
+
+	// 9️⃣ Safe role
+	role := ""
+	if user.Role.Valid {
+		role = user.Role.String
+	}
+
+	// 🔟 Response
+	return c.JSON(fiber.Map{
+		"message":                    message,
+		"id":                         user.ID,
+		"institute":                  user.InstituteID,
+		"name":                       user.Name,
+		"email":                      user.Email,
+		"role":                       role,
+		"is_active":                  user.IsActive,
+		"reason":                     result.Change.Reason,
+		"updated_at":                 user.UpdatedAt,
+		"canceled_scheduled_changes": result.CanceledChanges,
+	})
+}
+
+// recordStatusChange adds a status change made through the generic user
+// update to the history
+func (server *Server) recordStatusChange(c *fiber.Ctx, userID int32, instituteID int32, email string, isActive bool, payload *token.TokenPayload) {
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	_, err := server.store.CreateUserStatusChange(
+		c.Context(),
+		pgdb.CreateUserStatusChangeParams{
+			UserID:      userID,
+			InstituteID: instituteID,
+			IsActive:    isActive,
+			Reason:      "changed with the user update",
+			ChangedBy:   pgtype.Int4{Int32: int32(payload.ID), Valid: payload.ID != 0},
+			EffectiveAt: now,
+			AppliedAt:   now,
+		},
+	)
+	if err != nil {
+		log.Printf("failed to record status change of user %d: %v", userID, err)
+	}
+
+	eventType := SecurityEventAccountEnabled
+	if !isActive {
+		eventType = SecurityEventAccountDisabled
+	}
+	server.recordSecurityEvent(c, userID, instituteID, eventType, email, "")
+}
+
+func (server *Server) getUserStatusHistory(c *fiber.Ctx) error {
+
+	// 1️⃣ Read user ID from URL
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+
+	// 2️⃣ Get JWT payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ User must exist in this institute
+	if _, err := server.store.GetUserByID(
+		c.Context(),
+		pgdb.GetUserByIDParams{
+			ID:          int32(userID),
+			InstituteID: payload.InstituteID,
+		},
+	); err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("user not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	// 4️⃣ Applied, scheduled and canceled changes, newest first
+	changes, err := server.store.GetUserStatusChanges(
+		c.Context(),
+		pgdb.GetUserStatusChangesParams{
+			UserID:      int32(userID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(changes)
+}
+
+func (server *Server) cancelUserStatusChange(c *fiber.Ctx) error {
+
+	// 1️⃣ Read IDs from URL
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid user id",
+		)
+	}
+	changeID, err := c.ParamsInt("changeId")
+	if err != nil || changeID <= 0 {
+		return fiber.NewError(
+			fiber.StatusBadRequest,
+			"invalid status change id",
+		)
+	}
+
+	// 2️⃣ Get JWT payload
+	payload, ok := c.Locals(TokenPayloadKey).(*token.TokenPayload)
+	if !ok {
+		return fiber.NewError(
+			fiber.StatusUnauthorized,
+			"invalid auth context",
+		)
+	}
+
+	// 3️⃣ Only pending changes can be canceled
+	change, err := server.store.CancelUserStatusChange(
+		c.Context(),
+		pgdb.CancelUserStatusChangeParams{
+			ID:          int32(changeID),
+			UserID:      int32(userID),
+			InstituteID: payload.InstituteID,
+		},
+	)
+	if err != nil {
+		if pgdb.ErrorCode(err) == pgdb.ErrorNoRow {
+			return NotFoundError("scheduled status change not found")
+		}
+		return InternalServerError(err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "status change canceled",
+		"change":  change,
+	})
+}
+
+// applyScheduledStatusChanges runs due enable/disable changes and locks
+// disabled users out
+func (server *Server) applyScheduledStatusChanges(ctx context.Context) {
+	for {
+		changes, err := server.store.ApplyDueUserStatusChangesTx(ctx, statusChangeBatchSize)
+		if err != nil {
+			log.Printf("failed to apply scheduled status changes: %v", err)
+			return
+		}
+
+		for _, change := range changes {
+			eventType := SecurityEventAccountEnabled
+			if !change.IsActive {
+				eventType = SecurityEventAccountDisabled
+				if err := server.revokeUserAccess(ctx, change.UserID); err != nil {
+					log.Printf("failed to revoke access of user %d: %v", change.UserID, err)
+				}
+			}
+
+			err := server.store.CreateSecurityEvent(ctx, pgdb.CreateSecurityEventParams{
+				UserID:      pgtype.Int4{Int32: change.UserID, Valid: true},
+				InstituteID: pgtype.Int4{Int32: change.InstituteID, Valid: true},
+				EventType:   eventType,
+				Detail:      "scheduled: " + change.Reason,
+			})
+			if err != nil {
+				log.Printf("failed to record %s event for user %d: %v", eventType, change.UserID, err)
+			}
+		}
+
+		if len(changes) < statusChangeBatchSize {
+			return
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"dashboard/password"
 	"fmt"
 	"reflect"
 
@@ -21,8 +23,14 @@ type validatorError struct {
 
 // supply struct with validator tags
 func (api *Server) validate(s interface{}) []*validatorError {
+	return api.validateCtx(context.Background(), s)
+}
+
+// validateCtx is validate for rules that need request data, e.g. the
+// institute password policy set with withPasswordRules
+func (api *Server) validateCtx(ctx context.Context, s interface{}) []*validatorError {
 	var errors []*validatorError
-	err := api.valid.Struct(s)
+	err := api.valid.StructCtx(ctx, s)
 	ref := reflect.ValueOf(s)
 	if err != nil {
 		for _, err := range err.(validator.ValidationErrors) {
@@ -41,9 +49,20 @@ func (api *Server) validate(s interface{}) []*validatorError {
 					element.FailedField = v.Tag.Get(FORM)
 				}
 			}
-			element.Tag = err.Tag()
+			// aliases (e.g. `password`) report the rule that actually failed
+			element.Tag = err.ActualTag()
 			element.Value = reflect.Indirect(ref).FieldByName(err.Field()).String()
-			element.Msg = fmt.Sprintf("validation failed: invalid value '%s' in field '%s' failed on the '%s'", element.Value, element.FailedField, err.Tag())
+			element.Msg = fmt.Sprintf("validation failed: invalid value '%s' in field '%s' failed on the '%s'", element.Value, element.FailedField, element.Tag)
+			// never echo a rejected password back, with or without the
+			// institute policy in ctx
+			rules, ok := passwordRulesFrom(ctx)
+			if !ok {
+				rules = passwordRules{policy: password.MinimumPolicy()}
+			}
+			if msg, ok := passwordRuleMessage(rules.policy, element.Tag); ok {
+				element.Value = ""
+				element.Msg = msg
+			}
 			errors = append(errors, &element)
 		}
 	}
@@ -0,0 +1,6 @@
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_superadmin_institute_check;
+
+DELETE FROM users WHERE institute_id IS NULL;
+
+ALTER TABLE users ALTER COLUMN institute_id SET NOT NULL;
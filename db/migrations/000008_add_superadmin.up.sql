@@ -0,0 +1,6 @@
+-- platform super admins are not bound to an institute
+ALTER TABLE users ALTER COLUMN institute_id DROP NOT NULL;
+
+ALTER TABLE users
+ADD CONSTRAINT users_superadmin_institute_check
+CHECK ((role = 'superadmin') = (institute_id IS NULL));
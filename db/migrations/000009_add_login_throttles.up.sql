@@ -0,0 +1,7 @@
+-- failed login tracking, keyed by "email:<address>" or "ip:<address>"
+CREATE TABLE login_throttles (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    blocked_until TIMESTAMPTZ
+);
@@ -0,0 +1,16 @@
+-- institute-scoped keys for machine clients (kiosks, displays)
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    institute_id INT NOT NULL REFERENCES institutes (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    created_by INT REFERENCES users (id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX api_keys_institute_id_idx ON api_keys (institute_id);
@@ -0,0 +1,23 @@
+-- per-institute OpenID Connect identity provider
+CREATE TABLE institute_oidc_providers (
+    institute_id INT PRIMARY KEY REFERENCES institutes (id) ON DELETE CASCADE,
+    issuer_url TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    client_secret TEXT,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    auto_provision BOOLEAN NOT NULL DEFAULT false,
+    default_role TEXT NOT NULL DEFAULT 'viewer',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+-- pending authorization-code logins, keyed by the hashed state parameter
+CREATE TABLE oidc_login_states (
+    state_hash TEXT PRIMARY KEY,
+    institute_id INT NOT NULL REFERENCES institutes (id) ON DELETE CASCADE,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
@@ -0,0 +1,14 @@
+-- invited users stay inactive until they accept and choose a password
+CREATE TABLE user_invites (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    institute_id INT NOT NULL REFERENCES institutes (id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    invited_by INT REFERENCES users (id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX user_invites_institute_id_idx ON user_invites (institute_id);
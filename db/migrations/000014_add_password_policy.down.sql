@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_password_history;
+DROP TABLE IF EXISTS institute_password_policies;
@@ -0,0 +1,21 @@
+CREATE TABLE institute_password_policies (
+    institute_id INT PRIMARY KEY REFERENCES institutes (id) ON DELETE CASCADE,
+    min_length INT NOT NULL DEFAULT 6,
+    require_upper BOOLEAN NOT NULL DEFAULT false,
+    require_lower BOOLEAN NOT NULL DEFAULT false,
+    require_digit BOOLEAN NOT NULL DEFAULT false,
+    require_symbol BOOLEAN NOT NULL DEFAULT false,
+    history_size INT NOT NULL DEFAULT 0,
+    check_breached BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+-- hashes of previously set passwords, for the "no reuse" rule
+CREATE TABLE user_password_history (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX user_password_history_user_id_idx ON user_password_history (user_id, created_at DESC);
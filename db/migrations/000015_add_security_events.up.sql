@@ -0,0 +1,14 @@
+-- audit trail of authentication events; user_id is NULL for unknown emails
+CREATE TABLE security_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT REFERENCES users (id) ON DELETE CASCADE,
+    institute_id INT REFERENCES institutes (id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX security_events_user_id_idx ON security_events (user_id, id DESC);
@@ -0,0 +1,4 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS avatar_public_id,
+DROP COLUMN IF EXISTS avatar_url,
+DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+ADD COLUMN phone TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_url TEXT,
+ADD COLUMN avatar_public_id TEXT;
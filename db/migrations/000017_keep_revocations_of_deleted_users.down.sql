@@ -0,0 +1,6 @@
+DELETE FROM user_token_revocations r
+WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = r.user_id);
+
+ALTER TABLE user_token_revocations
+ADD CONSTRAINT user_token_revocations_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- the revocation cutoff of an offboarded user must outlive the user row,
+-- otherwise their unexpired access tokens would pass again after a hard delete
+ALTER TABLE user_token_revocations
+DROP CONSTRAINT IF EXISTS user_token_revocations_user_id_fkey;
@@ -0,0 +1,18 @@
+-- history of enabling / disabling users; rows with applied_at NULL are
+-- scheduled for effective_at and picked up by the housekeeping job
+CREATE TABLE user_status_changes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    institute_id INT NOT NULL REFERENCES institutes (id) ON DELETE CASCADE,
+    is_active BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_by INT REFERENCES users (id) ON DELETE SET NULL,
+    effective_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    applied_at TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX user_status_changes_user_id_idx ON user_status_changes (user_id, effective_at DESC);
+CREATE INDEX user_status_changes_pending_idx ON user_status_changes (effective_at)
+WHERE applied_at IS NULL AND canceled_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE sessions DROP COLUMN IF EXISTS institute_id;
+
+DROP TABLE IF EXISTS institute_memberships;
@@ -0,0 +1,16 @@
+-- extra institutes a user works for; the home institute and role stay on users
+CREATE TABLE institute_memberships (
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    institute_id INT NOT NULL REFERENCES institutes (id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    added_by INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    PRIMARY KEY (user_id, institute_id)
+);
+
+CREATE INDEX institute_memberships_institute_id_idx ON institute_memberships (institute_id);
+
+-- refresh sessions remember the institute they were switched to (NULL = home)
+ALTER TABLE sessions
+ADD COLUMN institute_id INT REFERENCES institutes (id) ON DELETE CASCADE;
@@ -0,0 +1,14 @@
+-- pending email changes; users.email is only swapped once the new address
+-- confirms with the token sent to it
+CREATE TABLE email_change_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    new_email TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    requested_by INT REFERENCES users (id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX email_change_requests_user_id_idx ON email_change_requests (user_id);
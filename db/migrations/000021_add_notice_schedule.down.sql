@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS notices_pending_expire_idx;
+DROP INDEX IF EXISTS notices_pending_publish_idx;
+DROP INDEX IF EXISTS notices_institute_status_idx;
+
+ALTER TABLE notices
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS expire_at,
+    DROP COLUMN IF EXISTS publish_at;
+
+ALTER TABLE institutes DROP COLUMN IF EXISTS timezone;
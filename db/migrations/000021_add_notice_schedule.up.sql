@@ -0,0 +1,26 @@
+-- notices go live and expire at exact times; times are entered in the
+-- institute's timezone
+ALTER TABLE institutes ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- status is kept in step with publish_at / expire_at by the scheduler:
+-- draft (not published), scheduled, live, expired
+ALTER TABLE notices
+    ADD COLUMN publish_at TIMESTAMPTZ,
+    ADD COLUMN expire_at TIMESTAMPTZ,
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'live'
+        CHECK (status IN ('draft', 'scheduled', 'live', 'expired'));
+
+UPDATE notices
+SET publish_at = publish_date::timestamp AT TIME ZONE 'UTC'
+WHERE publish_date IS NOT NULL;
+
+UPDATE notices
+SET status = CASE
+    WHEN is_published = false THEN 'draft'
+    WHEN publish_at > now() THEN 'scheduled'
+    ELSE 'live'
+END;
+
+CREATE INDEX notices_institute_status_idx ON notices (institute_id, status);
+CREATE INDEX notices_pending_publish_idx ON notices (publish_at) WHERE status = 'scheduled';
+CREATE INDEX notices_pending_expire_idx ON notices (expire_at) WHERE status = 'live' AND expire_at IS NOT NULL;
@@ -0,0 +1,17 @@
+-- files (PDF, DOCX, images) attached to a notice; the file itself lives in
+-- Cloudinary under public_id / resource_type
+CREATE TABLE notice_attachments (
+    id SERIAL PRIMARY KEY,
+    notice_id INT NOT NULL REFERENCES notices (id) ON DELETE CASCADE,
+    institute_id INT NOT NULL REFERENCES institutes (id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    url TEXT NOT NULL,
+    public_id TEXT NOT NULL,
+    resource_type TEXT NOT NULL,
+    uploaded_by INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX notice_attachments_notice_id_idx ON notice_attachments (notice_id, id);
@@ -0,0 +1,12 @@
+-- every rotation inherits the time its family started, so a refresh family
+-- cannot outlive the absolute session lifetime
+ALTER TABLE sessions ADD COLUMN family_created_at TIMESTAMPTZ NOT NULL DEFAULT (now());
+
+UPDATE sessions s
+SET family_created_at = f.started
+FROM (
+    SELECT family_id, MIN(created_at) AS started
+    FROM sessions
+    GROUP BY family_id
+) f
+WHERE s.family_id = f.family_id;
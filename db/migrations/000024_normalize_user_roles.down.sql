@@ -0,0 +1,8 @@
+ALTER TABLE institute_oidc_providers
+DROP CONSTRAINT IF EXISTS institute_oidc_providers_default_role_check;
+
+ALTER TABLE institute_memberships
+DROP CONSTRAINT IF EXISTS institute_memberships_role_check;
+
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS users_role_check;
@@ -0,0 +1,33 @@
+-- roles used to be free text and only 'admin' meant anything; every other
+-- value was read-only, which is what 'viewer' grants now
+UPDATE users
+SET role = CASE
+    WHEN lower(btrim(role)) IN ('admin', 'editor', 'viewer') THEN lower(btrim(role))
+    ELSE 'viewer'
+END
+WHERE institute_id IS NOT NULL;
+
+UPDATE institute_memberships
+SET role = CASE
+    WHEN lower(btrim(role)) IN ('admin', 'editor', 'viewer') THEN lower(btrim(role))
+    ELSE 'viewer'
+END;
+
+UPDATE institute_oidc_providers
+SET default_role = CASE
+    WHEN lower(btrim(default_role)) IN ('admin', 'editor', 'viewer') THEN lower(btrim(default_role))
+    ELSE 'viewer'
+END;
+
+-- superadmin is still tied to institute_id IS NULL by users_superadmin_institute_check
+ALTER TABLE users
+ADD CONSTRAINT users_role_check
+CHECK (role IS NOT NULL AND role IN ('superadmin', 'admin', 'editor', 'viewer'));
+
+ALTER TABLE institute_memberships
+ADD CONSTRAINT institute_memberships_role_check
+CHECK (role IN ('admin', 'editor', 'viewer'));
+
+ALTER TABLE institute_oidc_providers
+ADD CONSTRAINT institute_oidc_providers_default_role_check
+CHECK (default_role IN ('admin', 'editor', 'viewer'));
@@ -0,0 +1,2 @@
+-- case-insensitive email lookups (single sign-on, offboarding)
+CREATE INDEX IF NOT EXISTS users_lower_email_idx ON users (lower(email));
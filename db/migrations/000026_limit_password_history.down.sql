@@ -0,0 +1,2 @@
+ALTER TABLE institute_password_policies
+DROP CONSTRAINT IF EXISTS institute_password_policies_history_size_check;
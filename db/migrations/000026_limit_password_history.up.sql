@@ -0,0 +1,17 @@
+-- each remembered password costs an argon2id hash on every password change
+UPDATE institute_password_policies
+SET history_size = 5
+WHERE history_size > 5;
+
+ALTER TABLE institute_password_policies
+ADD CONSTRAINT institute_password_policies_history_size_check
+CHECK (history_size BETWEEN 0 AND 5);
+
+DELETE FROM user_password_history h
+WHERE h.id NOT IN (
+    SELECT r.id
+    FROM user_password_history r
+    WHERE r.user_id = h.user_id
+    ORDER BY r.created_at DESC, r.id DESC
+    LIMIT 5
+);
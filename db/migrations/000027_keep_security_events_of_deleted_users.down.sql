@@ -0,0 +1,6 @@
+ALTER TABLE security_events
+DROP CONSTRAINT IF EXISTS security_events_user_id_fkey;
+
+ALTER TABLE security_events
+ADD CONSTRAINT security_events_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
@@ -0,0 +1,8 @@
+-- the audit trail outlives the account: user_id becomes NULL, the email the
+-- event was recorded with stays
+ALTER TABLE security_events
+DROP CONSTRAINT IF EXISTS security_events_user_id_fkey;
+
+ALTER TABLE security_events
+ADD CONSTRAINT security_events_user_id_fkey
+FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL;
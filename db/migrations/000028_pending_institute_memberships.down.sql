@@ -0,0 +1,6 @@
+-- pending invitations would turn into access without the column
+DELETE FROM institute_memberships
+WHERE accepted_at IS NULL;
+
+ALTER TABLE institute_memberships
+DROP COLUMN IF EXISTS accepted_at;
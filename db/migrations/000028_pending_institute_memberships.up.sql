@@ -0,0 +1,7 @@
+-- a membership only grants access once the invited user accepts it;
+-- memberships that already exist count as accepted
+ALTER TABLE institute_memberships
+ADD COLUMN accepted_at TIMESTAMPTZ;
+
+UPDATE institute_memberships
+SET accepted_at = created_at;
@@ -0,0 +1,9 @@
+ALTER TABLE oidc_login_states DROP COLUMN IF EXISTS link_user_id;
+
+DROP TABLE IF EXISTS user_oidc_identities;
+
+ALTER TABLE institute_oidc_providers
+DROP COLUMN IF EXISTS approved_at,
+DROP COLUMN IF EXISTS approved_by,
+DROP COLUMN IF EXISTS allowed_domains,
+DROP COLUMN IF EXISTS approved_issuer_url;
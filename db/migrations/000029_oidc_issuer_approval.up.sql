@@ -0,0 +1,25 @@
+-- institute admins configure their identity provider, but sign-ins only work
+-- once a super admin has approved that issuer. Providers configured before
+-- this migration wait for approval.
+ALTER TABLE institute_oidc_providers
+ADD COLUMN approved_issuer_url TEXT,
+ADD COLUMN allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN approved_by INT REFERENCES users (id) ON DELETE SET NULL,
+ADD COLUMN approved_at TIMESTAMPTZ;
+
+-- identities a user has linked (or was provisioned with); existing accounts
+-- outside the approved email domains sign in through these only
+CREATE TABLE user_oidc_identities (
+    institute_id INT NOT NULL REFERENCES institutes (id) ON DELETE CASCADE,
+    issuer_url TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    PRIMARY KEY (institute_id, issuer_url, subject)
+);
+
+CREATE INDEX user_oidc_identities_user_id_idx ON user_oidc_identities (user_id);
+
+-- a login started by a signed-in user links the identity instead
+ALTER TABLE oidc_login_states
+ADD COLUMN link_user_id INT REFERENCES users (id) ON DELETE CASCADE;
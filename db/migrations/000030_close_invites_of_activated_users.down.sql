@@ -0,0 +1 @@
+-- the deleted invites can't be restored
@@ -0,0 +1,19 @@
+-- invites of users that were enabled without accepting them; revoking such
+-- an invite would delete the account, accepting it would reset its password
+DELETE FROM user_invites i
+WHERE i.accepted_at IS NULL
+  AND (
+    EXISTS (
+        SELECT 1
+        FROM users u
+        WHERE u.id = i.user_id
+          AND u.is_active = true
+    )
+    OR EXISTS (
+        SELECT 1
+        FROM user_status_changes c
+        WHERE c.user_id = i.user_id
+          AND c.is_active = true
+          AND c.applied_at IS NOT NULL
+    )
+  );
@@ -0,0 +1,6 @@
+-- open switch challenges would log into the home institute without the column
+DELETE FROM login_challenges
+WHERE institute_id IS NOT NULL;
+
+ALTER TABLE login_challenges
+DROP COLUMN IF EXISTS institute_id;
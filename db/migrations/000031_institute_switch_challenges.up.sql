@@ -0,0 +1,4 @@
+-- a challenge started by switching to an institute that requires 2FA issues
+-- tokens for that institute instead of the home one
+ALTER TABLE login_challenges
+ADD COLUMN institute_id INT REFERENCES institutes (id) ON DELETE CASCADE;
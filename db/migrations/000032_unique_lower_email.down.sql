@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS users_lower_email_idx ON users (lower(email));
+
+DROP INDEX IF EXISTS users_lower_email_key;
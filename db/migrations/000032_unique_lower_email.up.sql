@@ -0,0 +1,6 @@
+-- emails are unique regardless of case; this replaces the plain lower(email)
+-- index. Fails if two accounts differ only in the case of their email, which
+-- has to be resolved by hand first.
+CREATE UNIQUE INDEX IF NOT EXISTS users_lower_email_key ON users (lower(email));
+
+DROP INDEX IF EXISTS users_lower_email_idx;
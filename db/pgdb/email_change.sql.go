@@ -0,0 +1,154 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: email_change.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createEmailChangeRequest = `-- name: CreateEmailChangeRequest :one
+INSERT INTO email_change_requests (
+    user_id,
+    new_email,
+    token_hash,
+    requested_by,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, user_id, new_email, token_hash, requested_by, expires_at, used_at, created_at
+`
+
+type CreateEmailChangeRequestParams struct {
+	UserID      int32              `json:"user_id"`
+	NewEmail    string             `json:"new_email"`
+	TokenHash   string             `json:"token_hash"`
+	RequestedBy pgtype.Int4        `json:"requested_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateEmailChangeRequest(ctx context.Context, arg CreateEmailChangeRequestParams) (EmailChangeRequest, error) {
+	row := q.db.QueryRow(ctx, createEmailChangeRequest,
+		arg.UserID,
+		arg.NewEmail,
+		arg.TokenHash,
+		arg.RequestedBy,
+		arg.ExpiresAt,
+	)
+	var i EmailChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.RequestedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getEmailChangeRequestByHash = `-- name: GetEmailChangeRequestByHash :one
+SELECT id, user_id, new_email, token_hash, requested_by, expires_at, used_at, created_at
+FROM email_change_requests
+WHERE token_hash = $1
+LIMIT 1
+`
+
+func (q *Queries) GetEmailChangeRequestByHash(ctx context.Context, tokenHash string) (EmailChangeRequest, error) {
+	row := q.db.QueryRow(ctx, getEmailChangeRequestByHash, tokenHash)
+	var i EmailChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.RequestedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const invalidateUserEmailChangeRequests = `-- name: InvalidateUserEmailChangeRequests :exec
+UPDATE email_change_requests
+SET used_at = now()
+WHERE user_id = $1
+  AND used_at IS NULL
+`
+
+func (q *Queries) InvalidateUserEmailChangeRequests(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, invalidateUserEmailChangeRequests, userID)
+	return err
+}
+
+const updateUserEmail = `-- name: UpdateUserEmail :one
+UPDATE users
+SET
+    email = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, institute_id, name, email, role, is_active, updated_at
+`
+
+type UpdateUserEmailParams struct {
+	ID    int32  `json:"id"`
+	Email string `json:"email"`
+}
+
+type UpdateUserEmailRow struct {
+	ID          int32              `json:"id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	Role        pgtype.Text        `json:"role"`
+	IsActive    pgtype.Bool        `json:"is_active"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (UpdateUserEmailRow, error) {
+	row := q.db.QueryRow(ctx, updateUserEmail, arg.ID, arg.Email)
+	var i UpdateUserEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Role,
+		&i.IsActive,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const useEmailChangeRequest = `-- name: UseEmailChangeRequest :one
+UPDATE email_change_requests
+SET used_at = now()
+WHERE id = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+RETURNING id, user_id, new_email, token_hash, requested_by, expires_at, used_at, created_at
+`
+
+func (q *Queries) UseEmailChangeRequest(ctx context.Context, id int32) (EmailChangeRequest, error) {
+	row := q.db.QueryRow(ctx, useEmailChangeRequest, id)
+	var i EmailChangeRequest
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.NewEmail,
+		&i.TokenHash,
+		&i.RequestedBy,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -22,7 +22,7 @@ INSERT INTO institutes (
 ) VALUES (
     $1, $2, $3, $4, $5, $6
 )
-RETURNING id, name, code, email, phone, address, is_active, created_at, updated_at
+RETURNING id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
 `
 
 type CreateInstituteParams struct {
@@ -54,18 +54,23 @@ func (q *Queries) CreateInstitute(ctx context.Context, arg CreateInstituteParams
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Require2fa,
+		&i.Timezone,
 	)
 	return i, err
 }
 
-const deleteInstitute = `-- name: DeleteInstitute :exec
+const deleteInstitute = `-- name: DeleteInstitute :execrows
 DELETE FROM institutes
 WHERE id = $1
 `
 
-func (q *Queries) DeleteInstitute(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteInstitute, id)
-	return err
+func (q *Queries) DeleteInstitute(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteInstitute, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const disableInstitute = `-- name: DisableInstitute :exec
@@ -80,7 +85,7 @@ func (q *Queries) DisableInstitute(ctx context.Context, id int32) error {
 }
 
 const getAllInstitutes = `-- name: GetAllInstitutes :many
-SELECT id, name, code, email, phone, address, is_active, created_at, updated_at
+SELECT id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
 FROM institutes
 WHERE is_active = true
 ORDER BY created_at DESC
@@ -105,6 +110,46 @@ func (q *Queries) GetAllInstitutes(ctx context.Context) ([]Institute, error) {
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Require2fa,
+			&i.Timezone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllInstitutesAnyStatus = `-- name: GetAllInstitutesAnyStatus :many
+SELECT id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
+FROM institutes
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetAllInstitutesAnyStatus(ctx context.Context) ([]Institute, error) {
+	rows, err := q.db.Query(ctx, getAllInstitutesAnyStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Institute{}
+	for rows.Next() {
+		var i Institute
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Code,
+			&i.Email,
+			&i.Phone,
+			&i.Address,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Require2fa,
+			&i.Timezone,
 		); err != nil {
 			return nil, err
 		}
@@ -117,7 +162,7 @@ func (q *Queries) GetAllInstitutes(ctx context.Context) ([]Institute, error) {
 }
 
 const getInstituteByCode = `-- name: GetInstituteByCode :one
-SELECT id, name, code, email, phone, address, is_active, created_at, updated_at
+SELECT id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
 FROM institutes
 WHERE code = $1
 AND is_active = true
@@ -137,12 +182,14 @@ func (q *Queries) GetInstituteByCode(ctx context.Context, code string) (Institut
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Require2fa,
+		&i.Timezone,
 	)
 	return i, err
 }
 
 const getInstituteByID = `-- name: GetInstituteByID :one
-SELECT id, name, code, email, phone, address, is_active, created_at, updated_at
+SELECT id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
 FROM institutes
 WHERE id = $1
 AND is_active = true
@@ -162,42 +209,183 @@ func (q *Queries) GetInstituteByID(ctx context.Context, id int32) (Institute, er
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Require2fa,
+		&i.Timezone,
 	)
 	return i, err
 }
 
-const updateInstitute = `-- name: UpdateInstitute :one
+const getInstituteByIDAnyStatus = `-- name: GetInstituteByIDAnyStatus :one
+SELECT id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
+FROM institutes
+WHERE id = $1
+LIMIT 1
+`
+
+// platform admins also see disabled institutes
+func (q *Queries) GetInstituteByIDAnyStatus(ctx context.Context, id int32) (Institute, error) {
+	row := q.db.QueryRow(ctx, getInstituteByIDAnyStatus, id)
+	var i Institute
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Code,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Require2fa,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const getInstituteTimezone = `-- name: GetInstituteTimezone :one
+SELECT timezone
+FROM institutes
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetInstituteTimezone(ctx context.Context, id int32) (string, error) {
+	row := q.db.QueryRow(ctx, getInstituteTimezone, id)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
+const getInstituteUserIDs = `-- name: GetInstituteUserIDs :many
+SELECT id AS user_id
+FROM users
+WHERE institute_id = $1::int
+UNION
+SELECT user_id
+FROM institute_memberships
+WHERE institute_id = $1::int
+`
+
+// home users and members, everyone who can hold a token for the institute
+func (q *Queries) GetInstituteUserIDs(ctx context.Context, instituteID int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getInstituteUserIDs, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var user_id int32
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setInstituteRequire2FA = `-- name: SetInstituteRequire2FA :one
 UPDATE institutes
 SET
-    name = $2,
-    code = $3,
-    email = $4,
-    phone = $5,
-    address = $6,
-    is_active = $7
+    require_2fa = $2,
+    updated_at = now()
 WHERE id = $1
-RETURNING id, name, code, email, phone, address, is_active, created_at, updated_at
+RETURNING id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
+`
+
+type SetInstituteRequire2FAParams struct {
+	ID         int32 `json:"id"`
+	Require2fa bool  `json:"require_2fa"`
+}
+
+func (q *Queries) SetInstituteRequire2FA(ctx context.Context, arg SetInstituteRequire2FAParams) (Institute, error) {
+	row := q.db.QueryRow(ctx, setInstituteRequire2FA, arg.ID, arg.Require2fa)
+	var i Institute
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Code,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Require2fa,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const setInstituteTimezone = `-- name: SetInstituteTimezone :one
+UPDATE institutes
+SET
+    timezone = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
+`
+
+type SetInstituteTimezoneParams struct {
+	ID       int32  `json:"id"`
+	Timezone string `json:"timezone"`
+}
+
+func (q *Queries) SetInstituteTimezone(ctx context.Context, arg SetInstituteTimezoneParams) (Institute, error) {
+	row := q.db.QueryRow(ctx, setInstituteTimezone, arg.ID, arg.Timezone)
+	var i Institute
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Code,
+		&i.Email,
+		&i.Phone,
+		&i.Address,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Require2fa,
+		&i.Timezone,
+	)
+	return i, err
+}
+
+const updateInstitute = `-- name: UpdateInstitute :one
+UPDATE institutes
+SET
+    name = $1,
+    code = $2,
+    email = $3,
+    phone = $4,
+    address = $5,
+    is_active = COALESCE($6, is_active)
+WHERE id = $7
+RETURNING id, name, code, email, phone, address, is_active, created_at, updated_at, require_2fa, timezone
 `
 
 type UpdateInstituteParams struct {
-	ID       int32       `json:"id"`
 	Name     string      `json:"name"`
 	Code     string      `json:"code"`
 	Email    pgtype.Text `json:"email"`
 	Phone    pgtype.Text `json:"phone"`
 	Address  pgtype.Text `json:"address"`
 	IsActive pgtype.Bool `json:"is_active"`
+	ID       int32       `json:"id"`
 }
 
+// is_active stays as it is when NULL
 func (q *Queries) UpdateInstitute(ctx context.Context, arg UpdateInstituteParams) (Institute, error) {
 	row := q.db.QueryRow(ctx, updateInstitute,
-		arg.ID,
 		arg.Name,
 		arg.Code,
 		arg.Email,
 		arg.Phone,
 		arg.Address,
 		arg.IsActive,
+		arg.ID,
 	)
 	var i Institute
 	err := row.Scan(
@@ -210,6 +398,8 @@ func (q *Queries) UpdateInstitute(ctx context.Context, arg UpdateInstituteParams
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Require2fa,
+		&i.Timezone,
 	)
 	return i, err
 }
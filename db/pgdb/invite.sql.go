@@ -0,0 +1,350 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: invite.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const acceptUserInvite = `-- name: AcceptUserInvite :one
+UPDATE user_invites
+SET
+    accepted_at = now(),
+    updated_at = now()
+WHERE id = $1
+  AND accepted_at IS NULL
+  AND expires_at > now()
+RETURNING id, user_id, institute_id, token_hash, invited_by, expires_at, accepted_at, created_at, updated_at
+`
+
+func (q *Queries) AcceptUserInvite(ctx context.Context, id int32) (UserInvite, error) {
+	row := q.db.QueryRow(ctx, acceptUserInvite, id)
+	var i UserInvite
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.InstituteID,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const activateInvitedUser = `-- name: ActivateInvitedUser :one
+UPDATE users
+SET
+    password = $1,
+    is_active = true,
+    updated_at = now()
+WHERE users.id = $2
+  AND users.is_active = false
+  AND EXISTS (
+    SELECT 1
+    FROM user_invites i
+    WHERE i.id = $3
+      AND i.user_id = users.id
+      AND i.accepted_at IS NULL
+      AND i.expires_at > now()
+  )
+RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at
+`
+
+type ActivateInvitedUserParams struct {
+	Password string `json:"password"`
+	ID       int32  `json:"id"`
+	InviteID int32  `json:"invite_id"`
+}
+
+type ActivateInvitedUserRow struct {
+	ID          int32              `json:"id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	Role        pgtype.Text        `json:"role"`
+	IsActive    pgtype.Bool        `json:"is_active"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+// only a pending (inactive) user with this still-open invite, so an active
+// account's password is never reset through an invite link
+func (q *Queries) ActivateInvitedUser(ctx context.Context, arg ActivateInvitedUserParams) (ActivateInvitedUserRow, error) {
+	row := q.db.QueryRow(ctx, activateInvitedUser, arg.Password, arg.ID, arg.InviteID)
+	var i ActivateInvitedUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Role,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createUserInvite = `-- name: CreateUserInvite :one
+INSERT INTO user_invites (
+    user_id,
+    institute_id,
+    token_hash,
+    invited_by,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING id, user_id, institute_id, token_hash, invited_by, expires_at, accepted_at, created_at, updated_at
+`
+
+type CreateUserInviteParams struct {
+	UserID      int32              `json:"user_id"`
+	InstituteID int32              `json:"institute_id"`
+	TokenHash   string             `json:"token_hash"`
+	InvitedBy   pgtype.Int4        `json:"invited_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateUserInvite(ctx context.Context, arg CreateUserInviteParams) (UserInvite, error) {
+	row := q.db.QueryRow(ctx, createUserInvite,
+		arg.UserID,
+		arg.InstituteID,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	var i UserInvite
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.InstituteID,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteOpenUserInvites = `-- name: DeleteOpenUserInvites :exec
+DELETE FROM user_invites
+WHERE user_id = $1
+  AND accepted_at IS NULL
+`
+
+// a user activated without the invite must not be able to use it later
+func (q *Queries) DeleteOpenUserInvites(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteOpenUserInvites, userID)
+	return err
+}
+
+const getPendingUserInvite = `-- name: GetPendingUserInvite :one
+SELECT
+    i.id,
+    i.user_id,
+    u.name,
+    u.email,
+    u.role,
+    i.invited_by,
+    i.expires_at,
+    i.created_at
+FROM user_invites i
+JOIN users u ON u.id = i.user_id
+WHERE i.id = $1
+  AND i.institute_id = $2
+  AND i.accepted_at IS NULL
+LIMIT 1
+`
+
+type GetPendingUserInviteParams struct {
+	ID          int32 `json:"id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+type GetPendingUserInviteRow struct {
+	ID        int32              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email"`
+	Role      pgtype.Text        `json:"role"`
+	InvitedBy pgtype.Int4        `json:"invited_by"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) GetPendingUserInvite(ctx context.Context, arg GetPendingUserInviteParams) (GetPendingUserInviteRow, error) {
+	row := q.db.QueryRow(ctx, getPendingUserInvite, arg.ID, arg.InstituteID)
+	var i GetPendingUserInviteRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPendingUserInvitesByInstitute = `-- name: GetPendingUserInvitesByInstitute :many
+SELECT
+    i.id,
+    i.user_id,
+    u.name,
+    u.email,
+    u.role,
+    i.invited_by,
+    i.expires_at,
+    i.created_at
+FROM user_invites i
+JOIN users u ON u.id = i.user_id
+WHERE i.institute_id = $1
+  AND i.accepted_at IS NULL
+ORDER BY i.created_at DESC
+`
+
+type GetPendingUserInvitesByInstituteRow struct {
+	ID        int32              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email"`
+	Role      pgtype.Text        `json:"role"`
+	InvitedBy pgtype.Int4        `json:"invited_by"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) GetPendingUserInvitesByInstitute(ctx context.Context, instituteID int32) ([]GetPendingUserInvitesByInstituteRow, error) {
+	rows, err := q.db.Query(ctx, getPendingUserInvitesByInstitute, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetPendingUserInvitesByInstituteRow{}
+	for rows.Next() {
+		var i GetPendingUserInvitesByInstituteRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserInviteByHash = `-- name: GetUserInviteByHash :one
+SELECT id, user_id, institute_id, token_hash, invited_by, expires_at, accepted_at, created_at, updated_at
+FROM user_invites
+WHERE token_hash = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserInviteByHash(ctx context.Context, tokenHash string) (UserInvite, error) {
+	row := q.db.QueryRow(ctx, getUserInviteByHash, tokenHash)
+	var i UserInvite
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.InstituteID,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const renewUserInvite = `-- name: RenewUserInvite :one
+UPDATE user_invites
+SET
+    token_hash = $3,
+    expires_at = $4,
+    updated_at = now()
+WHERE id = $1
+  AND institute_id = $2
+  AND accepted_at IS NULL
+RETURNING id, user_id, institute_id, token_hash, invited_by, expires_at, accepted_at, created_at, updated_at
+`
+
+type RenewUserInviteParams struct {
+	ID          int32              `json:"id"`
+	InstituteID int32              `json:"institute_id"`
+	TokenHash   string             `json:"token_hash"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+}
+
+// resend: the previous link stops working
+func (q *Queries) RenewUserInvite(ctx context.Context, arg RenewUserInviteParams) (UserInvite, error) {
+	row := q.db.QueryRow(ctx, renewUserInvite,
+		arg.ID,
+		arg.InstituteID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i UserInvite
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.InstituteID,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const revokeUserInvite = `-- name: RevokeUserInvite :one
+DELETE FROM users
+WHERE id = (
+    SELECT i.user_id
+    FROM user_invites i
+    WHERE i.id = $1
+      AND i.institute_id = $2
+      AND i.accepted_at IS NULL
+)
+  AND is_active = false
+RETURNING id
+`
+
+type RevokeUserInviteParams struct {
+	ID          int32 `json:"id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+// the pending user never signed in, so it is removed together with the
+// invite; an account that was activated some other way stays
+func (q *Queries) RevokeUserInvite(ctx context.Context, arg RevokeUserInviteParams) (int32, error) {
+	row := q.db.QueryRow(ctx, revokeUserInvite, arg.ID, arg.InstituteID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
@@ -0,0 +1,115 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: login_throttle.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteStaleLoginThrottles = `-- name: DeleteStaleLoginThrottles :exec
+DELETE FROM login_throttles
+WHERE last_failure_at < $1
+  AND (blocked_until IS NULL OR blocked_until < now())
+`
+
+func (q *Queries) DeleteStaleLoginThrottles(ctx context.Context, windowStart pgtype.Timestamptz) error {
+	_, err := q.db.Exec(ctx, deleteStaleLoginThrottles, windowStart)
+	return err
+}
+
+const getLoginThrottles = `-- name: GetLoginThrottles :many
+SELECT key, failures, last_failure_at, blocked_until
+FROM login_throttles
+WHERE key = ANY($1::text[])
+`
+
+func (q *Queries) GetLoginThrottles(ctx context.Context, keys []string) ([]LoginThrottle, error) {
+	rows, err := q.db.Query(ctx, getLoginThrottles, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LoginThrottle{}
+	for rows.Next() {
+		var i LoginThrottle
+		if err := rows.Scan(
+			&i.Key,
+			&i.Failures,
+			&i.LastFailureAt,
+			&i.BlockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (
+    key,
+    failures,
+    last_failure_at
+) VALUES (
+    $1, 1, now()
+)
+ON CONFLICT (key) DO UPDATE
+SET
+    failures = CASE
+        WHEN login_throttles.last_failure_at < $2 THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = now()
+RETURNING key, failures, last_failure_at, blocked_until
+`
+
+type RecordLoginFailureParams struct {
+	Key         string             `json:"key"`
+	WindowStart pgtype.Timestamptz `json:"window_start"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error) {
+	row := q.db.QueryRow(ctx, recordLoginFailure, arg.Key, arg.WindowStart)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Key,
+		&i.Failures,
+		&i.LastFailureAt,
+		&i.BlockedUntil,
+	)
+	return i, err
+}
+
+const resetLoginThrottle = `-- name: ResetLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1
+`
+
+func (q *Queries) ResetLoginThrottle(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, resetLoginThrottle, key)
+	return err
+}
+
+const setLoginBlockedUntil = `-- name: SetLoginBlockedUntil :exec
+UPDATE login_throttles
+SET blocked_until = $2
+WHERE key = $1
+`
+
+type SetLoginBlockedUntilParams struct {
+	Key          string             `json:"key"`
+	BlockedUntil pgtype.Timestamptz `json:"blocked_until"`
+}
+
+func (q *Queries) SetLoginBlockedUntil(ctx context.Context, arg SetLoginBlockedUntilParams) error {
+	_, err := q.db.Exec(ctx, setLoginBlockedUntil, arg.Key, arg.BlockedUntil)
+	return err
+}
@@ -0,0 +1,341 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: membership.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const acceptInstituteMembership = `-- name: AcceptInstituteMembership :execrows
+UPDATE institute_memberships m
+SET
+    accepted_at = now(),
+    updated_at = now()
+FROM institutes i
+WHERE i.id = m.institute_id
+  AND m.user_id = $1
+  AND m.institute_id = $2
+  AND m.accepted_at IS NULL
+  AND i.is_active = true
+`
+
+type AcceptInstituteMembershipParams struct {
+	UserID      int32 `json:"user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) AcceptInstituteMembership(ctx context.Context, arg AcceptInstituteMembershipParams) (int64, error) {
+	result, err := q.db.Exec(ctx, acceptInstituteMembership, arg.UserID, arg.InstituteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const declineInstituteMembership = `-- name: DeclineInstituteMembership :execrows
+DELETE FROM institute_memberships
+WHERE user_id = $1
+  AND institute_id = $2
+  AND accepted_at IS NULL
+`
+
+type DeclineInstituteMembershipParams struct {
+	UserID      int32 `json:"user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) DeclineInstituteMembership(ctx context.Context, arg DeclineInstituteMembershipParams) (int64, error) {
+	result, err := q.db.Exec(ctx, declineInstituteMembership, arg.UserID, arg.InstituteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteInstituteMembership = `-- name: DeleteInstituteMembership :execrows
+DELETE FROM institute_memberships
+WHERE user_id = $1
+  AND institute_id = $2
+`
+
+type DeleteInstituteMembershipParams struct {
+	UserID      int32 `json:"user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) DeleteInstituteMembership(ctx context.Context, arg DeleteInstituteMembershipParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteInstituteMembership, arg.UserID, arg.InstituteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getInstituteMembers = `-- name: GetInstituteMembers :many
+SELECT
+    m.user_id,
+    u.name,
+    u.email,
+    u.is_active,
+    m.role,
+    h.code AS home_institute_code,
+    m.created_at,
+    m.accepted_at
+FROM institute_memberships m
+JOIN users u ON u.id = m.user_id
+LEFT JOIN institutes h ON h.id = u.institute_id
+WHERE m.institute_id = $1
+  AND m.accepted_at IS NOT NULL
+ORDER BY u.name
+`
+
+type GetInstituteMembersRow struct {
+	UserID            int32              `json:"user_id"`
+	Name              string             `json:"name"`
+	Email             string             `json:"email"`
+	IsActive          pgtype.Bool        `json:"is_active"`
+	Role              string             `json:"role"`
+	HomeInstituteCode pgtype.Text        `json:"home_institute_code"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	AcceptedAt        pgtype.Timestamptz `json:"accepted_at"`
+}
+
+// pending invitations stay hidden, they would tell which emails exist
+func (q *Queries) GetInstituteMembers(ctx context.Context, instituteID int32) ([]GetInstituteMembersRow, error) {
+	rows, err := q.db.Query(ctx, getInstituteMembers, instituteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetInstituteMembersRow{}
+	for rows.Next() {
+		var i GetInstituteMembersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Name,
+			&i.Email,
+			&i.IsActive,
+			&i.Role,
+			&i.HomeInstituteCode,
+			&i.CreatedAt,
+			&i.AcceptedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInstituteMembershipRole = `-- name: GetInstituteMembershipRole :one
+SELECT m.role
+FROM institute_memberships m
+JOIN institutes i ON i.id = m.institute_id
+WHERE m.user_id = $1
+  AND m.institute_id = $2
+  AND m.accepted_at IS NOT NULL
+  AND i.is_active = true
+LIMIT 1
+`
+
+type GetInstituteMembershipRoleParams struct {
+	UserID      int32 `json:"user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+// memberships of disabled institutes don't count
+func (q *Queries) GetInstituteMembershipRole(ctx context.Context, arg GetInstituteMembershipRoleParams) (string, error) {
+	row := q.db.QueryRow(ctx, getInstituteMembershipRole, arg.UserID, arg.InstituteID)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getPendingMemberships = `-- name: GetPendingMemberships :many
+SELECT
+    m.institute_id,
+    i.code,
+    i.name,
+    m.role,
+    m.created_at
+FROM institute_memberships m
+JOIN institutes i ON i.id = m.institute_id
+WHERE m.user_id = $1
+  AND m.accepted_at IS NULL
+  AND i.is_active = true
+ORDER BY m.created_at DESC
+`
+
+type GetPendingMembershipsRow struct {
+	InstituteID int32              `json:"institute_id"`
+	Code        string             `json:"code"`
+	Name        string             `json:"name"`
+	Role        string             `json:"role"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) GetPendingMemberships(ctx context.Context, userID int32) ([]GetPendingMembershipsRow, error) {
+	rows, err := q.db.Query(ctx, getPendingMemberships, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetPendingMembershipsRow{}
+	for rows.Next() {
+		var i GetPendingMembershipsRow
+		if err := rows.Scan(
+			&i.InstituteID,
+			&i.Code,
+			&i.Name,
+			&i.Role,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByEmailAnyInstitute = `-- name: GetUserByEmailAnyInstitute :one
+SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
+FROM users
+WHERE lower(email) = lower($1)
+LIMIT 1
+`
+
+func (q *Queries) GetUserByEmailAnyInstitute(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmailAnyInstitute, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
+	)
+	return i, err
+}
+
+const getUserInstitutes = `-- name: GetUserInstitutes :many
+SELECT
+    i.id AS institute_id,
+    i.code,
+    i.name,
+    COALESCE(u.role, '')::text AS role,
+    true AS home
+FROM users u
+JOIN institutes i ON i.id = u.institute_id
+WHERE u.id = $1
+  AND i.is_active = true
+UNION ALL
+SELECT
+    i.id AS institute_id,
+    i.code,
+    i.name,
+    m.role,
+    false AS home
+FROM institute_memberships m
+JOIN institutes i ON i.id = m.institute_id
+WHERE m.user_id = $1
+  AND m.accepted_at IS NOT NULL
+  AND i.is_active = true
+ORDER BY home DESC, name
+`
+
+type GetUserInstitutesRow struct {
+	InstituteID int32  `json:"institute_id"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Role        string `json:"role"`
+	Home        bool   `json:"home"`
+}
+
+// the home institute first, then the memberships
+func (q *Queries) GetUserInstitutes(ctx context.Context, userID int32) ([]GetUserInstitutesRow, error) {
+	rows, err := q.db.Query(ctx, getUserInstitutes, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetUserInstitutesRow{}
+	for rows.Next() {
+		var i GetUserInstitutesRow
+		if err := rows.Scan(
+			&i.InstituteID,
+			&i.Code,
+			&i.Name,
+			&i.Role,
+			&i.Home,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertInstituteMembership = `-- name: UpsertInstituteMembership :one
+INSERT INTO institute_memberships (
+    user_id,
+    institute_id,
+    role,
+    added_by
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id, institute_id) DO UPDATE
+SET
+    role = EXCLUDED.role,
+    updated_at = now()
+RETURNING user_id, institute_id, role, added_by, created_at, updated_at, accepted_at
+`
+
+type UpsertInstituteMembershipParams struct {
+	UserID      int32       `json:"user_id"`
+	InstituteID int32       `json:"institute_id"`
+	Role        string      `json:"role"`
+	AddedBy     pgtype.Int4 `json:"added_by"`
+}
+
+// new memberships start pending; a role change keeps accepted_at
+func (q *Queries) UpsertInstituteMembership(ctx context.Context, arg UpsertInstituteMembershipParams) (InstituteMembership, error) {
+	row := q.db.QueryRow(ctx, upsertInstituteMembership,
+		arg.UserID,
+		arg.InstituteID,
+		arg.Role,
+		arg.AddedBy,
+	)
+	var i InstituteMembership
+	err := row.Scan(
+		&i.UserID,
+		&i.InstituteID,
+		&i.Role,
+		&i.AddedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AcceptedAt,
+	)
+	return i, err
+}
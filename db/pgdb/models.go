@@ -8,6 +8,20 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ApiKey struct {
+	ID          int32              `json:"id"`
+	InstituteID int32              `json:"institute_id"`
+	Name        string             `json:"name"`
+	Prefix      string             `json:"prefix"`
+	KeyHash     string             `json:"key_hash"`
+	Scopes      []string           `json:"scopes"`
+	CreatedBy   pgtype.Int4        `json:"created_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt  pgtype.Timestamptz `json:"last_used_at"`
+	RevokedAt   pgtype.Timestamptz `json:"revoked_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type Carousel struct {
 	ID          int32              `json:"id"`
 	InstituteID int32              `json:"institute_id"`
@@ -25,16 +39,86 @@ type CarouselPhoto struct {
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
 }
 
+type EmailChangeRequest struct {
+	ID          int32              `json:"id"`
+	UserID      int32              `json:"user_id"`
+	NewEmail    string             `json:"new_email"`
+	TokenHash   string             `json:"token_hash"`
+	RequestedBy pgtype.Int4        `json:"requested_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	UsedAt      pgtype.Timestamptz `json:"used_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
 type Institute struct {
-	ID        int32              `json:"id"`
-	Name      string             `json:"name"`
-	Code      string             `json:"code"`
-	Email     pgtype.Text        `json:"email"`
-	Phone     pgtype.Text        `json:"phone"`
-	Address   pgtype.Text        `json:"address"`
-	IsActive  pgtype.Bool        `json:"is_active"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID         int32              `json:"id"`
+	Name       string             `json:"name"`
+	Code       string             `json:"code"`
+	Email      pgtype.Text        `json:"email"`
+	Phone      pgtype.Text        `json:"phone"`
+	Address    pgtype.Text        `json:"address"`
+	IsActive   pgtype.Bool        `json:"is_active"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+	Require2fa bool               `json:"require_2fa"`
+	Timezone   string             `json:"timezone"`
+}
+
+type InstituteMembership struct {
+	UserID      int32              `json:"user_id"`
+	InstituteID int32              `json:"institute_id"`
+	Role        string             `json:"role"`
+	AddedBy     pgtype.Int4        `json:"added_by"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	AcceptedAt  pgtype.Timestamptz `json:"accepted_at"`
+}
+
+type InstituteOidcProvider struct {
+	InstituteID       int32              `json:"institute_id"`
+	IssuerUrl         string             `json:"issuer_url"`
+	ClientID          string             `json:"client_id"`
+	ClientSecret      pgtype.Text        `json:"client_secret"`
+	Scopes            []string           `json:"scopes"`
+	AutoProvision     bool               `json:"auto_provision"`
+	DefaultRole       string             `json:"default_role"`
+	Enabled           bool               `json:"enabled"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	ApprovedIssuerUrl pgtype.Text        `json:"approved_issuer_url"`
+	AllowedDomains    []string           `json:"allowed_domains"`
+	ApprovedBy        pgtype.Int4        `json:"approved_by"`
+	ApprovedAt        pgtype.Timestamptz `json:"approved_at"`
+}
+
+type InstitutePasswordPolicy struct {
+	InstituteID   int32              `json:"institute_id"`
+	MinLength     int32              `json:"min_length"`
+	RequireUpper  bool               `json:"require_upper"`
+	RequireLower  bool               `json:"require_lower"`
+	RequireDigit  bool               `json:"require_digit"`
+	RequireSymbol bool               `json:"require_symbol"`
+	HistorySize   int32              `json:"history_size"`
+	CheckBreached bool               `json:"check_breached"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type LoginChallenge struct {
+	ID          int32              `json:"id"`
+	UserID      int32              `json:"user_id"`
+	TokenHash   string             `json:"token_hash"`
+	Attempts    int32              `json:"attempts"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	UsedAt      pgtype.Timestamptz `json:"used_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
+}
+
+type LoginThrottle struct {
+	Key           string             `json:"key"`
+	Failures      int32              `json:"failures"`
+	LastFailureAt pgtype.Timestamptz `json:"last_failure_at"`
+	BlockedUntil  pgtype.Timestamptz `json:"blocked_until"`
 }
 
 type Notice struct {
@@ -45,6 +129,42 @@ type Notice struct {
 	IsPublished pgtype.Bool        `json:"is_published"`
 	PublishDate pgtype.Date        `json:"publish_date"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	PublishAt   pgtype.Timestamptz `json:"publish_at"`
+	ExpireAt    pgtype.Timestamptz `json:"expire_at"`
+	Status      string             `json:"status"`
+}
+
+type NoticeAttachment struct {
+	ID           int32              `json:"id"`
+	NoticeID     int32              `json:"notice_id"`
+	InstituteID  int32              `json:"institute_id"`
+	FileName     string             `json:"file_name"`
+	ContentType  string             `json:"content_type"`
+	SizeBytes    int64              `json:"size_bytes"`
+	Url          string             `json:"url"`
+	PublicID     string             `json:"public_id"`
+	ResourceType string             `json:"resource_type"`
+	UploadedBy   pgtype.Int4        `json:"uploaded_by"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type OidcLoginState struct {
+	StateHash    string             `json:"state_hash"`
+	InstituteID  int32              `json:"institute_id"`
+	CodeVerifier string             `json:"code_verifier"`
+	Nonce        string             `json:"nonce"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	LinkUserID   pgtype.Int4        `json:"link_user_id"`
+}
+
+type PasswordResetToken struct {
+	ID        int32              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	TokenHash string             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 type Photo struct {
@@ -58,14 +178,112 @@ type Photo struct {
 	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
 }
 
+type RevokedToken struct {
+	Jti       string             `json:"jti"`
+	UserID    int32              `json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type SecurityEvent struct {
+	ID          int64              `json:"id"`
+	UserID      pgtype.Int4        `json:"user_id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
+	EventType   string             `json:"event_type"`
+	Email       string             `json:"email"`
+	Ip          string             `json:"ip"`
+	UserAgent   string             `json:"user_agent"`
+	Detail      string             `json:"detail"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type Session struct {
+	ID               int32              `json:"id"`
+	UserID           int32              `json:"user_id"`
+	FamilyID         string             `json:"family_id"`
+	RefreshTokenHash string             `json:"refresh_token_hash"`
+	UserAgent        string             `json:"user_agent"`
+	ClientIp         string             `json:"client_ip"`
+	IsRevoked        bool               `json:"is_revoked"`
+	RotatedAt        pgtype.Timestamptz `json:"rotated_at"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	InstituteID      pgtype.Int4        `json:"institute_id"`
+	FamilyCreatedAt  pgtype.Timestamptz `json:"family_created_at"`
+}
+
 type User struct {
+	ID             int32              `json:"id"`
+	InstituteID    pgtype.Int4        `json:"institute_id"`
+	Name           string             `json:"name"`
+	Email          string             `json:"email"`
+	Password       string             `json:"password"`
+	Role           pgtype.Text        `json:"role"`
+	IsActive       pgtype.Bool        `json:"is_active"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	Phone          string             `json:"phone"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	AvatarPublicID pgtype.Text        `json:"avatar_public_id"`
+}
+
+type UserInvite struct {
 	ID          int32              `json:"id"`
+	UserID      int32              `json:"user_id"`
 	InstituteID int32              `json:"institute_id"`
-	Name        string             `json:"name"`
-	Email       string             `json:"email"`
-	Password    string             `json:"password"`
-	Role        pgtype.Text        `json:"role"`
-	IsActive    pgtype.Bool        `json:"is_active"`
+	TokenHash   string             `json:"token_hash"`
+	InvitedBy   pgtype.Int4        `json:"invited_by"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	AcceptedAt  pgtype.Timestamptz `json:"accepted_at"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
+
+type UserOidcIdentity struct {
+	InstituteID int32              `json:"institute_id"`
+	IssuerUrl   string             `json:"issuer_url"`
+	Subject     string             `json:"subject"`
+	UserID      int32              `json:"user_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserPasswordHistory struct {
+	ID           int32              `json:"id"`
+	UserID       int32              `json:"user_id"`
+	PasswordHash string             `json:"password_hash"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserRecoveryCode struct {
+	ID        int32              `json:"id"`
+	UserID    int32              `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserStatusChange struct {
+	ID          int32              `json:"id"`
+	UserID      int32              `json:"user_id"`
+	InstituteID int32              `json:"institute_id"`
+	IsActive    bool               `json:"is_active"`
+	Reason      string             `json:"reason"`
+	ChangedBy   pgtype.Int4        `json:"changed_by"`
+	EffectiveAt pgtype.Timestamptz `json:"effective_at"`
+	AppliedAt   pgtype.Timestamptz `json:"applied_at"`
+	CanceledAt  pgtype.Timestamptz `json:"canceled_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserTokenRevocation struct {
+	UserID        int32              `json:"user_id"`
+	RevokedBefore pgtype.Timestamptz `json:"revoked_before"`
+}
+
+type UserTotp struct {
+	UserID       int32              `json:"user_id"`
+	Secret       string             `json:"secret"`
+	ConfirmedAt  pgtype.Timestamptz `json:"confirmed_at"`
+	LastUsedStep pgtype.Int8        `json:"last_used_step"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
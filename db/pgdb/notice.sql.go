@@ -11,25 +11,48 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const advanceNoticeStatuses = `-- name: AdvanceNoticeStatuses :execrows
+UPDATE notices
+SET status = CASE WHEN expire_at <= now() THEN 'expired' ELSE 'live' END
+WHERE (status = 'scheduled' AND publish_at <= now())
+OR (status = 'live' AND expire_at <= now())
+`
+
+// scheduled notices go live at publish_at, live ones expire at expire_at
+// (a scheduled notice whose window already passed goes straight to expired)
+func (q *Queries) AdvanceNoticeStatuses(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, advanceNoticeStatuses)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const createNotice = `-- name: CreateNotice :one
 INSERT INTO notices (
     institute_id,
     title,
     description,
     is_published,
-    publish_date
+    publish_date,
+    publish_at,
+    expire_at,
+    status
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
-RETURNING id, institute_id, title, description, is_published, publish_date, created_at
+RETURNING id, institute_id, title, description, is_published, publish_date, created_at, publish_at, expire_at, status
 `
 
 type CreateNoticeParams struct {
-	InstituteID int32       `json:"institute_id"`
-	Title       string      `json:"title"`
-	Description pgtype.Text `json:"description"`
-	IsPublished pgtype.Bool `json:"is_published"`
-	PublishDate pgtype.Date `json:"publish_date"`
+	InstituteID int32              `json:"institute_id"`
+	Title       string             `json:"title"`
+	Description pgtype.Text        `json:"description"`
+	IsPublished pgtype.Bool        `json:"is_published"`
+	PublishDate pgtype.Date        `json:"publish_date"`
+	PublishAt   pgtype.Timestamptz `json:"publish_at"`
+	ExpireAt    pgtype.Timestamptz `json:"expire_at"`
+	Status      string             `json:"status"`
 }
 
 func (q *Queries) CreateNotice(ctx context.Context, arg CreateNoticeParams) (Notice, error) {
@@ -39,6 +62,9 @@ func (q *Queries) CreateNotice(ctx context.Context, arg CreateNoticeParams) (Not
 		arg.Description,
 		arg.IsPublished,
 		arg.PublishDate,
+		arg.PublishAt,
+		arg.ExpireAt,
+		arg.Status,
 	)
 	var i Notice
 	err := row.Scan(
@@ -49,6 +75,9 @@ func (q *Queries) CreateNotice(ctx context.Context, arg CreateNoticeParams) (Not
 		&i.IsPublished,
 		&i.PublishDate,
 		&i.CreatedAt,
+		&i.PublishAt,
+		&i.ExpireAt,
+		&i.Status,
 	)
 	return i, err
 }
@@ -64,7 +93,7 @@ func (q *Queries) DeleteNotice(ctx context.Context, id int32) error {
 }
 
 const getNotice = `-- name: GetNotice :one
-SELECT id, institute_id, title, description, is_published, publish_date, created_at
+SELECT id, institute_id, title, description, is_published, publish_date, created_at, publish_at, expire_at, status
 FROM notices
 WHERE id = $1 AND institute_id = $2
 LIMIT 1
@@ -86,19 +115,29 @@ func (q *Queries) GetNotice(ctx context.Context, arg GetNoticeParams) (Notice, e
 		&i.IsPublished,
 		&i.PublishDate,
 		&i.CreatedAt,
+		&i.PublishAt,
+		&i.ExpireAt,
+		&i.Status,
 	)
 	return i, err
 }
 
 const getNoticesByInstitute = `-- name: GetNoticesByInstitute :many
-SELECT id, institute_id, title, description, is_published, publish_date, created_at
+SELECT id, institute_id, title, description, is_published, publish_date, created_at, publish_at, expire_at, status
 FROM notices
 WHERE institute_id = $1
-ORDER BY created_at DESC
+AND ($2::text IS NULL OR status = $2::text)
+ORDER BY COALESCE(publish_at, created_at) DESC, id DESC
 `
 
-func (q *Queries) GetNoticesByInstitute(ctx context.Context, instituteID int32) ([]Notice, error) {
-	rows, err := q.db.Query(ctx, getNoticesByInstitute, instituteID)
+type GetNoticesByInstituteParams struct {
+	InstituteID int32       `json:"institute_id"`
+	Status      pgtype.Text `json:"status"`
+}
+
+// status NULL lists every state
+func (q *Queries) GetNoticesByInstitute(ctx context.Context, arg GetNoticesByInstituteParams) ([]Notice, error) {
+	rows, err := q.db.Query(ctx, getNoticesByInstitute, arg.InstituteID, arg.Status)
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +153,92 @@ func (q *Queries) GetNoticesByInstitute(ctx context.Context, instituteID int32)
 			&i.IsPublished,
 			&i.PublishDate,
 			&i.CreatedAt,
+			&i.PublishAt,
+			&i.ExpireAt,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPublicNotice = `-- name: GetPublicNotice :one
+SELECT id, institute_id, title, description, is_published, publish_date, created_at, publish_at, expire_at, status
+FROM notices
+WHERE id = $1
+AND institute_id = $2
+AND status = 'live'
+AND (publish_at IS NULL OR publish_at <= now())
+AND (expire_at IS NULL OR expire_at > now())
+LIMIT 1
+`
+
+type GetPublicNoticeParams struct {
+	ID          int32 `json:"id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) GetPublicNotice(ctx context.Context, arg GetPublicNoticeParams) (Notice, error) {
+	row := q.db.QueryRow(ctx, getPublicNotice, arg.ID, arg.InstituteID)
+	var i Notice
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Title,
+		&i.Description,
+		&i.IsPublished,
+		&i.PublishDate,
+		&i.CreatedAt,
+		&i.PublishAt,
+		&i.ExpireAt,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getPublicNotices = `-- name: GetPublicNotices :many
+SELECT id, institute_id, title, description, is_published, publish_date, created_at, publish_at, expire_at, status
+FROM notices
+WHERE institute_id = $1
+AND status = 'live'
+AND (publish_at IS NULL OR publish_at <= now())
+AND (expire_at IS NULL OR expire_at > now())
+ORDER BY COALESCE(publish_at, created_at) DESC, id DESC
+LIMIT $2
+`
+
+type GetPublicNoticesParams struct {
+	InstituteID int32 `json:"institute_id"`
+	Limit       int32 `json:"limit"`
+}
+
+// live notices for the public feed; the time checks keep the feed exact
+// between scheduler runs
+func (q *Queries) GetPublicNotices(ctx context.Context, arg GetPublicNoticesParams) ([]Notice, error) {
+	rows, err := q.db.Query(ctx, getPublicNotices, arg.InstituteID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Notice{}
+	for rows.Next() {
+		var i Notice
+		if err := rows.Scan(
+			&i.ID,
+			&i.InstituteID,
+			&i.Title,
+			&i.Description,
+			&i.IsPublished,
+			&i.PublishDate,
+			&i.CreatedAt,
+			&i.PublishAt,
+			&i.ExpireAt,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -126,20 +251,22 @@ func (q *Queries) GetNoticesByInstitute(ctx context.Context, instituteID int32)
 }
 
 const searchNotices = `-- name: SearchNotices :many
-SELECT id, institute_id, title, description, is_published, publish_date, created_at
+SELECT id, institute_id, title, description, is_published, publish_date, created_at, publish_at, expire_at, status
 FROM notices
 WHERE institute_id = $1
-AND title ILIKE '%' || $2 || '%'
-ORDER BY created_at DESC
+AND title ILIKE '%' || $2::text || '%'
+AND ($3::text IS NULL OR status = $3::text)
+ORDER BY COALESCE(publish_at, created_at) DESC, id DESC
 `
 
 type SearchNoticesParams struct {
 	InstituteID int32       `json:"institute_id"`
-	Column2     pgtype.Text `json:"column_2"`
+	Search      string      `json:"search"`
+	Status      pgtype.Text `json:"status"`
 }
 
 func (q *Queries) SearchNotices(ctx context.Context, arg SearchNoticesParams) ([]Notice, error) {
-	rows, err := q.db.Query(ctx, searchNotices, arg.InstituteID, arg.Column2)
+	rows, err := q.db.Query(ctx, searchNotices, arg.InstituteID, arg.Search, arg.Status)
 	if err != nil {
 		return nil, err
 	}
@@ -155,6 +282,9 @@ func (q *Queries) SearchNotices(ctx context.Context, arg SearchNoticesParams) ([
 			&i.IsPublished,
 			&i.PublishDate,
 			&i.CreatedAt,
+			&i.PublishAt,
+			&i.ExpireAt,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -169,29 +299,40 @@ func (q *Queries) SearchNotices(ctx context.Context, arg SearchNoticesParams) ([
 const updateNotice = `-- name: UpdateNotice :one
 UPDATE notices
 SET
-    title = $2,
-    description = $3,
-    is_published = $4,
-    publish_date = $5
-WHERE id = $1
-RETURNING id, institute_id, title, description, is_published, publish_date, created_at
+    title = $3,
+    description = $4,
+    is_published = $5,
+    publish_date = $6,
+    publish_at = $7,
+    expire_at = $8,
+    status = $9
+WHERE id = $1 AND institute_id = $2
+RETURNING id, institute_id, title, description, is_published, publish_date, created_at, publish_at, expire_at, status
 `
 
 type UpdateNoticeParams struct {
-	ID          int32       `json:"id"`
-	Title       string      `json:"title"`
-	Description pgtype.Text `json:"description"`
-	IsPublished pgtype.Bool `json:"is_published"`
-	PublishDate pgtype.Date `json:"publish_date"`
+	ID          int32              `json:"id"`
+	InstituteID int32              `json:"institute_id"`
+	Title       string             `json:"title"`
+	Description pgtype.Text        `json:"description"`
+	IsPublished pgtype.Bool        `json:"is_published"`
+	PublishDate pgtype.Date        `json:"publish_date"`
+	PublishAt   pgtype.Timestamptz `json:"publish_at"`
+	ExpireAt    pgtype.Timestamptz `json:"expire_at"`
+	Status      string             `json:"status"`
 }
 
 func (q *Queries) UpdateNotice(ctx context.Context, arg UpdateNoticeParams) (Notice, error) {
 	row := q.db.QueryRow(ctx, updateNotice,
 		arg.ID,
+		arg.InstituteID,
 		arg.Title,
 		arg.Description,
 		arg.IsPublished,
 		arg.PublishDate,
+		arg.PublishAt,
+		arg.ExpireAt,
+		arg.Status,
 	)
 	var i Notice
 	err := row.Scan(
@@ -202,6 +343,9 @@ func (q *Queries) UpdateNotice(ctx context.Context, arg UpdateNoticeParams) (Not
 		&i.IsPublished,
 		&i.PublishDate,
 		&i.CreatedAt,
+		&i.PublishAt,
+		&i.ExpireAt,
+		&i.Status,
 	)
 	return i, err
 }
@@ -0,0 +1,222 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: notice_attachment.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const countNoticeAttachments = `-- name: CountNoticeAttachments :one
+SELECT COUNT(*)
+FROM notice_attachments
+WHERE notice_id = $1
+`
+
+func (q *Queries) CountNoticeAttachments(ctx context.Context, noticeID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countNoticeAttachments, noticeID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createNoticeAttachment = `-- name: CreateNoticeAttachment :one
+INSERT INTO notice_attachments (
+    notice_id,
+    institute_id,
+    file_name,
+    content_type,
+    size_bytes,
+    url,
+    public_id,
+    resource_type,
+    uploaded_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING id, notice_id, institute_id, file_name, content_type, size_bytes, url, public_id, resource_type, uploaded_by, created_at
+`
+
+type CreateNoticeAttachmentParams struct {
+	NoticeID     int32       `json:"notice_id"`
+	InstituteID  int32       `json:"institute_id"`
+	FileName     string      `json:"file_name"`
+	ContentType  string      `json:"content_type"`
+	SizeBytes    int64       `json:"size_bytes"`
+	Url          string      `json:"url"`
+	PublicID     string      `json:"public_id"`
+	ResourceType string      `json:"resource_type"`
+	UploadedBy   pgtype.Int4 `json:"uploaded_by"`
+}
+
+func (q *Queries) CreateNoticeAttachment(ctx context.Context, arg CreateNoticeAttachmentParams) (NoticeAttachment, error) {
+	row := q.db.QueryRow(ctx, createNoticeAttachment,
+		arg.NoticeID,
+		arg.InstituteID,
+		arg.FileName,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.Url,
+		arg.PublicID,
+		arg.ResourceType,
+		arg.UploadedBy,
+	)
+	var i NoticeAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.NoticeID,
+		&i.InstituteID,
+		&i.FileName,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Url,
+		&i.PublicID,
+		&i.ResourceType,
+		&i.UploadedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteNoticeAttachment = `-- name: DeleteNoticeAttachment :exec
+DELETE FROM notice_attachments
+WHERE id = $1 AND institute_id = $2
+`
+
+type DeleteNoticeAttachmentParams struct {
+	ID          int32 `json:"id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) DeleteNoticeAttachment(ctx context.Context, arg DeleteNoticeAttachmentParams) error {
+	_, err := q.db.Exec(ctx, deleteNoticeAttachment, arg.ID, arg.InstituteID)
+	return err
+}
+
+const getNoticeAttachment = `-- name: GetNoticeAttachment :one
+SELECT id, notice_id, institute_id, file_name, content_type, size_bytes, url, public_id, resource_type, uploaded_by, created_at
+FROM notice_attachments
+WHERE id = $1 AND notice_id = $2 AND institute_id = $3
+LIMIT 1
+`
+
+type GetNoticeAttachmentParams struct {
+	ID          int32 `json:"id"`
+	NoticeID    int32 `json:"notice_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) GetNoticeAttachment(ctx context.Context, arg GetNoticeAttachmentParams) (NoticeAttachment, error) {
+	row := q.db.QueryRow(ctx, getNoticeAttachment, arg.ID, arg.NoticeID, arg.InstituteID)
+	var i NoticeAttachment
+	err := row.Scan(
+		&i.ID,
+		&i.NoticeID,
+		&i.InstituteID,
+		&i.FileName,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Url,
+		&i.PublicID,
+		&i.ResourceType,
+		&i.UploadedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getNoticeAttachments = `-- name: GetNoticeAttachments :many
+SELECT id, notice_id, institute_id, file_name, content_type, size_bytes, url, public_id, resource_type, uploaded_by, created_at
+FROM notice_attachments
+WHERE notice_id = $1
+ORDER BY id
+`
+
+func (q *Queries) GetNoticeAttachments(ctx context.Context, noticeID int32) ([]NoticeAttachment, error) {
+	rows, err := q.db.Query(ctx, getNoticeAttachments, noticeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NoticeAttachment{}
+	for rows.Next() {
+		var i NoticeAttachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoticeID,
+			&i.InstituteID,
+			&i.FileName,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.Url,
+			&i.PublicID,
+			&i.ResourceType,
+			&i.UploadedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNoticeAttachmentsByNotices = `-- name: GetNoticeAttachmentsByNotices :many
+SELECT id, notice_id, institute_id, file_name, content_type, size_bytes, url, public_id, resource_type, uploaded_by, created_at
+FROM notice_attachments
+WHERE notice_id = ANY($1::int[])
+ORDER BY notice_id, id
+`
+
+func (q *Queries) GetNoticeAttachmentsByNotices(ctx context.Context, noticeIds []int32) ([]NoticeAttachment, error) {
+	rows, err := q.db.Query(ctx, getNoticeAttachmentsByNotices, noticeIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NoticeAttachment{}
+	for rows.Next() {
+		var i NoticeAttachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.NoticeID,
+			&i.InstituteID,
+			&i.FileName,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.Url,
+			&i.PublicID,
+			&i.ResourceType,
+			&i.UploadedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockNotice = `-- name: LockNotice :one
+SELECT id
+FROM notices
+WHERE id = $1
+FOR UPDATE
+`
+
+// serializes uploads to one notice, so the attachment limit holds
+func (q *Queries) LockNotice(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, lockNotice, id)
+	err := row.Scan(&id)
+	return id, err
+}
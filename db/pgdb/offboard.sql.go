@@ -0,0 +1,120 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: offboard.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const anonymizeUser = `-- name: AnonymizeUser :one
+UPDATE users
+SET
+    name = $2,
+    email = $3,
+    password = $4,
+    phone = '',
+    avatar_url = NULL,
+    avatar_public_id = NULL,
+    is_active = false,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, institute_id, name, email, is_active, updated_at
+`
+
+type AnonymizeUserParams struct {
+	ID       int32  `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type AnonymizeUserRow struct {
+	ID          int32              `json:"id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	IsActive    pgtype.Bool        `json:"is_active"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+// keeps the row (and its id) but drops everything that identifies the person
+func (q *Queries) AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (AnonymizeUserRow, error) {
+	row := q.db.QueryRow(ctx, anonymizeUser,
+		arg.ID,
+		arg.Name,
+		arg.Email,
+		arg.Password,
+	)
+	var i AnonymizeUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.IsActive,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deletePasswordHistory = `-- name: DeletePasswordHistory :exec
+DELETE FROM user_password_history
+WHERE user_id = $1
+`
+
+func (q *Queries) DeletePasswordHistory(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deletePasswordHistory, userID)
+	return err
+}
+
+const deleteUserInvites = `-- name: DeleteUserInvites :exec
+DELETE FROM user_invites
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserInvites(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, deleteUserInvites, userID)
+	return err
+}
+
+const reassignUserPhotos = `-- name: ReassignUserPhotos :execrows
+UPDATE photos
+SET
+    uploaded_by = $1,
+    updated_at = now()
+WHERE uploaded_by = $2
+  AND institute_id = $3
+`
+
+type ReassignUserPhotosParams struct {
+	ToUserID    int32 `json:"to_user_id"`
+	FromUserID  int32 `json:"from_user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) ReassignUserPhotos(ctx context.Context, arg ReassignUserPhotosParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignUserPhotos, arg.ToUserID, arg.FromUserID, arg.InstituteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const scrubUserSecurityEvents = `-- name: ScrubUserSecurityEvents :exec
+UPDATE security_events
+SET
+    email = '',
+    ip = '',
+    user_agent = ''
+WHERE user_id = $1
+`
+
+func (q *Queries) ScrubUserSecurityEvents(ctx context.Context, userID pgtype.Int4) error {
+	_, err := q.db.Exec(ctx, scrubUserSecurityEvents, userID)
+	return err
+}
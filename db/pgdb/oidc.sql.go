@@ -0,0 +1,313 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: oidc.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const approveInstituteOIDCProvider = `-- name: ApproveInstituteOIDCProvider :one
+UPDATE institute_oidc_providers
+SET
+    approved_issuer_url = issuer_url,
+    allowed_domains = $1,
+    approved_by = $2,
+    approved_at = now(),
+    updated_at = now()
+WHERE institute_id = $3
+  AND issuer_url = $4
+RETURNING institute_id, issuer_url, client_id, client_secret, scopes, auto_provision, default_role, enabled, created_at, updated_at, approved_issuer_url, allowed_domains, approved_by, approved_at
+`
+
+type ApproveInstituteOIDCProviderParams struct {
+	AllowedDomains []string    `json:"allowed_domains"`
+	ApprovedBy     pgtype.Int4 `json:"approved_by"`
+	InstituteID    int32       `json:"institute_id"`
+	IssuerUrl      string      `json:"issuer_url"`
+}
+
+// approves the issuer that is configured right now; an admin changing it
+// afterwards needs a new approval
+func (q *Queries) ApproveInstituteOIDCProvider(ctx context.Context, arg ApproveInstituteOIDCProviderParams) (InstituteOidcProvider, error) {
+	row := q.db.QueryRow(ctx, approveInstituteOIDCProvider,
+		arg.AllowedDomains,
+		arg.ApprovedBy,
+		arg.InstituteID,
+		arg.IssuerUrl,
+	)
+	var i InstituteOidcProvider
+	err := row.Scan(
+		&i.InstituteID,
+		&i.IssuerUrl,
+		&i.ClientID,
+		&i.ClientSecret,
+		&i.Scopes,
+		&i.AutoProvision,
+		&i.DefaultRole,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ApprovedIssuerUrl,
+		&i.AllowedDomains,
+		&i.ApprovedBy,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const consumeOIDCLoginState = `-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+  AND expires_at > now()
+RETURNING state_hash, institute_id, code_verifier, nonce, expires_at, created_at, link_user_id
+`
+
+// single use: the row is removed whether or not the login succeeds
+func (q *Queries) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error) {
+	row := q.db.QueryRow(ctx, consumeOIDCLoginState, stateHash)
+	var i OidcLoginState
+	err := row.Scan(
+		&i.StateHash,
+		&i.InstituteID,
+		&i.CodeVerifier,
+		&i.Nonce,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.LinkUserID,
+	)
+	return i, err
+}
+
+const createOIDCLoginState = `-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (
+    state_hash,
+    institute_id,
+    code_verifier,
+    nonce,
+    expires_at,
+    link_user_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+`
+
+type CreateOIDCLoginStateParams struct {
+	StateHash    string             `json:"state_hash"`
+	InstituteID  int32              `json:"institute_id"`
+	CodeVerifier string             `json:"code_verifier"`
+	Nonce        string             `json:"nonce"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	LinkUserID   pgtype.Int4        `json:"link_user_id"`
+}
+
+func (q *Queries) CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error {
+	_, err := q.db.Exec(ctx, createOIDCLoginState,
+		arg.StateHash,
+		arg.InstituteID,
+		arg.CodeVerifier,
+		arg.Nonce,
+		arg.ExpiresAt,
+		arg.LinkUserID,
+	)
+	return err
+}
+
+const createUserOIDCIdentity = `-- name: CreateUserOIDCIdentity :exec
+INSERT INTO user_oidc_identities (
+    institute_id,
+    issuer_url,
+    subject,
+    user_id
+) VALUES (
+    $1, $2, $3, $4
+)
+`
+
+type CreateUserOIDCIdentityParams struct {
+	InstituteID int32  `json:"institute_id"`
+	IssuerUrl   string `json:"issuer_url"`
+	Subject     string `json:"subject"`
+	UserID      int32  `json:"user_id"`
+}
+
+func (q *Queries) CreateUserOIDCIdentity(ctx context.Context, arg CreateUserOIDCIdentityParams) error {
+	_, err := q.db.Exec(ctx, createUserOIDCIdentity,
+		arg.InstituteID,
+		arg.IssuerUrl,
+		arg.Subject,
+		arg.UserID,
+	)
+	return err
+}
+
+const deleteExpiredOIDCLoginStates = `-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= now()
+`
+
+func (q *Queries) DeleteExpiredOIDCLoginStates(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredOIDCLoginStates)
+	return err
+}
+
+const deleteInstituteOIDCProvider = `-- name: DeleteInstituteOIDCProvider :exec
+DELETE FROM institute_oidc_providers
+WHERE institute_id = $1
+`
+
+func (q *Queries) DeleteInstituteOIDCProvider(ctx context.Context, instituteID int32) error {
+	_, err := q.db.Exec(ctx, deleteInstituteOIDCProvider, instituteID)
+	return err
+}
+
+const deleteUserOIDCIdentities = `-- name: DeleteUserOIDCIdentities :execrows
+DELETE FROM user_oidc_identities
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserOIDCIdentities(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUserOIDCIdentities, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getInstituteOIDCProvider = `-- name: GetInstituteOIDCProvider :one
+SELECT institute_id, issuer_url, client_id, client_secret, scopes, auto_provision, default_role, enabled, created_at, updated_at, approved_issuer_url, allowed_domains, approved_by, approved_at
+FROM institute_oidc_providers
+WHERE institute_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetInstituteOIDCProvider(ctx context.Context, instituteID int32) (InstituteOidcProvider, error) {
+	row := q.db.QueryRow(ctx, getInstituteOIDCProvider, instituteID)
+	var i InstituteOidcProvider
+	err := row.Scan(
+		&i.InstituteID,
+		&i.IssuerUrl,
+		&i.ClientID,
+		&i.ClientSecret,
+		&i.Scopes,
+		&i.AutoProvision,
+		&i.DefaultRole,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ApprovedIssuerUrl,
+		&i.AllowedDomains,
+		&i.ApprovedBy,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
+
+const getUserByOIDCIdentity = `-- name: GetUserByOIDCIdentity :one
+SELECT u.id, u.institute_id, u.name, u.email, u.password, u.role, u.is_active, u.created_at, u.updated_at, u.phone, u.avatar_url, u.avatar_public_id
+FROM user_oidc_identities i
+JOIN users u ON u.id = i.user_id
+WHERE i.institute_id = $1
+  AND i.issuer_url = $2
+  AND i.subject = $3
+  AND u.institute_id = $1
+LIMIT 1
+`
+
+type GetUserByOIDCIdentityParams struct {
+	InstituteID int32  `json:"institute_id"`
+	IssuerUrl   string `json:"issuer_url"`
+	Subject     string `json:"subject"`
+}
+
+func (q *Queries) GetUserByOIDCIdentity(ctx context.Context, arg GetUserByOIDCIdentityParams) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByOIDCIdentity, arg.InstituteID, arg.IssuerUrl, arg.Subject)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
+	)
+	return i, err
+}
+
+const upsertInstituteOIDCProvider = `-- name: UpsertInstituteOIDCProvider :one
+INSERT INTO institute_oidc_providers (
+    institute_id,
+    issuer_url,
+    client_id,
+    client_secret,
+    scopes,
+    auto_provision,
+    default_role,
+    enabled
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (institute_id) DO UPDATE
+SET
+    issuer_url = EXCLUDED.issuer_url,
+    client_id = EXCLUDED.client_id,
+    client_secret = EXCLUDED.client_secret,
+    scopes = EXCLUDED.scopes,
+    auto_provision = EXCLUDED.auto_provision,
+    default_role = EXCLUDED.default_role,
+    enabled = EXCLUDED.enabled,
+    updated_at = now()
+RETURNING institute_id, issuer_url, client_id, client_secret, scopes, auto_provision, default_role, enabled, created_at, updated_at, approved_issuer_url, allowed_domains, approved_by, approved_at
+`
+
+type UpsertInstituteOIDCProviderParams struct {
+	InstituteID   int32       `json:"institute_id"`
+	IssuerUrl     string      `json:"issuer_url"`
+	ClientID      string      `json:"client_id"`
+	ClientSecret  pgtype.Text `json:"client_secret"`
+	Scopes        []string    `json:"scopes"`
+	AutoProvision bool        `json:"auto_provision"`
+	DefaultRole   string      `json:"default_role"`
+	Enabled       bool        `json:"enabled"`
+}
+
+func (q *Queries) UpsertInstituteOIDCProvider(ctx context.Context, arg UpsertInstituteOIDCProviderParams) (InstituteOidcProvider, error) {
+	row := q.db.QueryRow(ctx, upsertInstituteOIDCProvider,
+		arg.InstituteID,
+		arg.IssuerUrl,
+		arg.ClientID,
+		arg.ClientSecret,
+		arg.Scopes,
+		arg.AutoProvision,
+		arg.DefaultRole,
+		arg.Enabled,
+	)
+	var i InstituteOidcProvider
+	err := row.Scan(
+		&i.InstituteID,
+		&i.IssuerUrl,
+		&i.ClientID,
+		&i.ClientSecret,
+		&i.Scopes,
+		&i.AutoProvision,
+		&i.DefaultRole,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ApprovedIssuerUrl,
+		&i.AllowedDomains,
+		&i.ApprovedBy,
+		&i.ApprovedAt,
+	)
+	return i, err
+}
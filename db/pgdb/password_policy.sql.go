@@ -0,0 +1,172 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: password_policy.sql
+
+package pgdb
+
+import (
+	"context"
+)
+
+const addPasswordHistory = `-- name: AddPasswordHistory :exec
+INSERT INTO user_password_history (
+    user_id,
+    password_hash
+) VALUES (
+    $1, $2
+)
+`
+
+type AddPasswordHistoryParams struct {
+	UserID       int32  `json:"user_id"`
+	PasswordHash string `json:"password_hash"`
+}
+
+func (q *Queries) AddPasswordHistory(ctx context.Context, arg AddPasswordHistoryParams) error {
+	_, err := q.db.Exec(ctx, addPasswordHistory, arg.UserID, arg.PasswordHash)
+	return err
+}
+
+const getPasswordPolicy = `-- name: GetPasswordPolicy :one
+SELECT institute_id, min_length, require_upper, require_lower, require_digit, require_symbol, history_size, check_breached, updated_at
+FROM institute_password_policies
+WHERE institute_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetPasswordPolicy(ctx context.Context, instituteID int32) (InstitutePasswordPolicy, error) {
+	row := q.db.QueryRow(ctx, getPasswordPolicy, instituteID)
+	var i InstitutePasswordPolicy
+	err := row.Scan(
+		&i.InstituteID,
+		&i.MinLength,
+		&i.RequireUpper,
+		&i.RequireLower,
+		&i.RequireDigit,
+		&i.RequireSymbol,
+		&i.HistorySize,
+		&i.CheckBreached,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getRecentPasswordHashes = `-- name: GetRecentPasswordHashes :many
+SELECT password_hash
+FROM user_password_history
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2
+`
+
+type GetRecentPasswordHashesParams struct {
+	UserID int32 `json:"user_id"`
+	Limit  int32 `json:"limit"`
+}
+
+func (q *Queries) GetRecentPasswordHashes(ctx context.Context, arg GetRecentPasswordHashesParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, getRecentPasswordHashes, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var password_hash string
+		if err := rows.Scan(&password_hash); err != nil {
+			return nil, err
+		}
+		items = append(items, password_hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const prunePasswordHistory = `-- name: PrunePasswordHistory :exec
+DELETE FROM user_password_history h
+WHERE h.user_id = $1
+  AND h.id NOT IN (
+    SELECT r.id
+    FROM user_password_history r
+    WHERE r.user_id = $1
+    ORDER BY r.created_at DESC, r.id DESC
+    LIMIT $2
+  )
+`
+
+type PrunePasswordHistoryParams struct {
+	UserID int32 `json:"user_id"`
+	Keep   int32 `json:"keep"`
+}
+
+// keeps only the newest entries
+func (q *Queries) PrunePasswordHistory(ctx context.Context, arg PrunePasswordHistoryParams) error {
+	_, err := q.db.Exec(ctx, prunePasswordHistory, arg.UserID, arg.Keep)
+	return err
+}
+
+const upsertPasswordPolicy = `-- name: UpsertPasswordPolicy :one
+INSERT INTO institute_password_policies (
+    institute_id,
+    min_length,
+    require_upper,
+    require_lower,
+    require_digit,
+    require_symbol,
+    history_size,
+    check_breached
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (institute_id) DO UPDATE
+SET
+    min_length = EXCLUDED.min_length,
+    require_upper = EXCLUDED.require_upper,
+    require_lower = EXCLUDED.require_lower,
+    require_digit = EXCLUDED.require_digit,
+    require_symbol = EXCLUDED.require_symbol,
+    history_size = EXCLUDED.history_size,
+    check_breached = EXCLUDED.check_breached,
+    updated_at = now()
+RETURNING institute_id, min_length, require_upper, require_lower, require_digit, require_symbol, history_size, check_breached, updated_at
+`
+
+type UpsertPasswordPolicyParams struct {
+	InstituteID   int32 `json:"institute_id"`
+	MinLength     int32 `json:"min_length"`
+	RequireUpper  bool  `json:"require_upper"`
+	RequireLower  bool  `json:"require_lower"`
+	RequireDigit  bool  `json:"require_digit"`
+	RequireSymbol bool  `json:"require_symbol"`
+	HistorySize   int32 `json:"history_size"`
+	CheckBreached bool  `json:"check_breached"`
+}
+
+func (q *Queries) UpsertPasswordPolicy(ctx context.Context, arg UpsertPasswordPolicyParams) (InstitutePasswordPolicy, error) {
+	row := q.db.QueryRow(ctx, upsertPasswordPolicy,
+		arg.InstituteID,
+		arg.MinLength,
+		arg.RequireUpper,
+		arg.RequireLower,
+		arg.RequireDigit,
+		arg.RequireSymbol,
+		arg.HistorySize,
+		arg.CheckBreached,
+	)
+	var i InstitutePasswordPolicy
+	err := row.Scan(
+		&i.InstituteID,
+		&i.MinLength,
+		&i.RequireUpper,
+		&i.RequireLower,
+		&i.RequireDigit,
+		&i.RequireSymbol,
+		&i.HistorySize,
+		&i.CheckBreached,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
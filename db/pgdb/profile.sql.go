@@ -0,0 +1,178 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: profile.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getUserProfile = `-- name: GetUserProfile :one
+SELECT
+    id,
+    institute_id,
+    name,
+    email,
+    phone,
+    role,
+    avatar_url,
+    avatar_public_id,
+    created_at,
+    updated_at
+FROM users
+WHERE id = $1
+  AND is_active = true
+LIMIT 1
+`
+
+type GetUserProfileRow struct {
+	ID             int32              `json:"id"`
+	InstituteID    pgtype.Int4        `json:"institute_id"`
+	Name           string             `json:"name"`
+	Email          string             `json:"email"`
+	Phone          string             `json:"phone"`
+	Role           pgtype.Text        `json:"role"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	AvatarPublicID pgtype.Text        `json:"avatar_public_id"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) GetUserProfile(ctx context.Context, id int32) (GetUserProfileRow, error) {
+	row := q.db.QueryRow(ctx, getUserProfile, id)
+	var i GetUserProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setUserAvatar = `-- name: SetUserAvatar :one
+UPDATE users
+SET
+    avatar_url = $2,
+    avatar_public_id = $3,
+    updated_at = now()
+WHERE id = $1
+  AND is_active = true
+RETURNING
+    id,
+    institute_id,
+    name,
+    email,
+    phone,
+    role,
+    avatar_url,
+    avatar_public_id,
+    created_at,
+    updated_at
+`
+
+type SetUserAvatarParams struct {
+	ID             int32       `json:"id"`
+	AvatarUrl      pgtype.Text `json:"avatar_url"`
+	AvatarPublicID pgtype.Text `json:"avatar_public_id"`
+}
+
+type SetUserAvatarRow struct {
+	ID             int32              `json:"id"`
+	InstituteID    pgtype.Int4        `json:"institute_id"`
+	Name           string             `json:"name"`
+	Email          string             `json:"email"`
+	Phone          string             `json:"phone"`
+	Role           pgtype.Text        `json:"role"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	AvatarPublicID pgtype.Text        `json:"avatar_public_id"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+// NULLs remove the avatar
+func (q *Queries) SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) (SetUserAvatarRow, error) {
+	row := q.db.QueryRow(ctx, setUserAvatar, arg.ID, arg.AvatarUrl, arg.AvatarPublicID)
+	var i SetUserAvatarRow
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    name = $2,
+    phone = $3,
+    updated_at = now()
+WHERE id = $1
+  AND is_active = true
+RETURNING
+    id,
+    institute_id,
+    name,
+    email,
+    phone,
+    role,
+    avatar_url,
+    avatar_public_id,
+    created_at,
+    updated_at
+`
+
+type UpdateUserProfileParams struct {
+	ID    int32  `json:"id"`
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+}
+
+type UpdateUserProfileRow struct {
+	ID             int32              `json:"id"`
+	InstituteID    pgtype.Int4        `json:"institute_id"`
+	Name           string             `json:"name"`
+	Email          string             `json:"email"`
+	Phone          string             `json:"phone"`
+	Role           pgtype.Text        `json:"role"`
+	AvatarUrl      pgtype.Text        `json:"avatar_url"`
+	AvatarPublicID pgtype.Text        `json:"avatar_public_id"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (UpdateUserProfileRow, error) {
+	row := q.db.QueryRow(ctx, updateUserProfile, arg.ID, arg.Name, arg.Phone)
+	var i UpdateUserProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Phone,
+		&i.Role,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -6,48 +6,203 @@ package pgdb
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
+	AcceptInstituteMembership(ctx context.Context, arg AcceptInstituteMembershipParams) (int64, error)
+	AcceptUserInvite(ctx context.Context, id int32) (UserInvite, error)
+	// only a pending (inactive) user with this still-open invite, so an active
+	// account's password is never reset through an invite link
+	ActivateInvitedUser(ctx context.Context, arg ActivateInvitedUserParams) (ActivateInvitedUserRow, error)
+	AddPasswordHistory(ctx context.Context, arg AddPasswordHistoryParams) error
+	// scheduled notices go live at publish_at, live ones expire at expire_at
+	// (a scheduled notice whose window already passed goes straight to expired)
+	AdvanceNoticeStatuses(ctx context.Context) (int64, error)
+	// keeps the row (and its id) but drops everything that identifies the person
+	AnonymizeUser(ctx context.Context, arg AnonymizeUserParams) (AnonymizeUserRow, error)
+	// approves the issuer that is configured right now; an admin changing it
+	// afterwards needs a new approval
+	ApproveInstituteOIDCProvider(ctx context.Context, arg ApproveInstituteOIDCProviderParams) (InstituteOidcProvider, error)
+	// an immediate change overrides whatever was scheduled before it
+	CancelPendingUserStatusChanges(ctx context.Context, arg CancelPendingUserStatusChangesParams) (int64, error)
+	CancelUserStatusChange(ctx context.Context, arg CancelUserStatusChangeParams) (UserStatusChange, error)
+	ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) (UserTotp, error)
+	// single use: the row is removed whether or not the login succeeds
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error)
+	CountNoticeAttachments(ctx context.Context, noticeID int32) (int64, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID int32) (int64, error)
+	CountUsersByInstitute(ctx context.Context, arg CountUsersByInstituteParams) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateCarousel(ctx context.Context, arg CreateCarouselParams) (Carousel, error)
 	CreateCarouselPhoto(ctx context.Context, arg CreateCarouselPhotoParams) (CarouselPhoto, error)
+	CreateEmailChangeRequest(ctx context.Context, arg CreateEmailChangeRequestParams) (EmailChangeRequest, error)
 	CreateInstitute(ctx context.Context, arg CreateInstituteParams) (Institute, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
 	CreateNotice(ctx context.Context, arg CreateNoticeParams) (Notice, error)
+	CreateNoticeAttachment(ctx context.Context, arg CreateNoticeAttachmentParams) (NoticeAttachment, error)
+	CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) error
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
 	CreatePhoto(ctx context.Context, arg CreatePhotoParams) (Photo, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
+	CreateSecurityEvent(ctx context.Context, arg CreateSecurityEventParams) error
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateSuperAdmin(ctx context.Context, arg CreateSuperAdminParams) (User, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateUserInvite(ctx context.Context, arg CreateUserInviteParams) (UserInvite, error)
+	CreateUserOIDCIdentity(ctx context.Context, arg CreateUserOIDCIdentityParams) error
+	CreateUserStatusChange(ctx context.Context, arg CreateUserStatusChangeParams) (UserStatusChange, error)
+	DeclineInstituteMembership(ctx context.Context, arg DeclineInstituteMembershipParams) (int64, error)
 	DeleteCarousel(ctx context.Context, arg DeleteCarouselParams) error
 	DeleteCarouselPhoto(ctx context.Context, id int32) error
-	DeleteInstitute(ctx context.Context, id int32) error
+	DeleteExpiredLoginChallenges(ctx context.Context) error
+	DeleteExpiredOIDCLoginStates(ctx context.Context) error
+	DeleteExpiredRevokedTokens(ctx context.Context) error
+	DeleteInstitute(ctx context.Context, id int32) (int64, error)
+	DeleteInstituteMembership(ctx context.Context, arg DeleteInstituteMembershipParams) (int64, error)
+	DeleteInstituteOIDCProvider(ctx context.Context, instituteID int32) error
 	DeleteNotice(ctx context.Context, id int32) error
+	DeleteNoticeAttachment(ctx context.Context, arg DeleteNoticeAttachmentParams) error
+	// a user activated without the invite must not be able to use it later
+	DeleteOpenUserInvites(ctx context.Context, userID int32) error
+	DeletePasswordHistory(ctx context.Context, userID int32) error
 	DeletePhoto(ctx context.Context, arg DeletePhotoParams) error
+	DeleteRecoveryCodes(ctx context.Context, userID int32) error
+	DeleteStaleLoginThrottles(ctx context.Context, windowStart pgtype.Timestamptz) error
 	DeleteUser(ctx context.Context, id int32) error
+	DeleteUserInvites(ctx context.Context, userID int32) error
+	DeleteUserOIDCIdentities(ctx context.Context, userID int32) (int64, error)
+	DeleteUserTOTP(ctx context.Context, userID int32) error
 	DisableInstitute(ctx context.Context, id int32) error
 	DisableUser(ctx context.Context, arg DisableUserParams) (DisableUserRow, error)
+	GetAPIKeysByInstitute(ctx context.Context, instituteID int32) ([]ApiKey, error)
+	// usable keys only: not revoked, not expired, institute still active
+	GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
 	GetAllInstitutes(ctx context.Context) ([]Institute, error)
+	GetAllInstitutesAnyStatus(ctx context.Context) ([]Institute, error)
 	GetCarouselPhotoWithImage(ctx context.Context, id int32) (GetCarouselPhotoWithImageRow, error)
 	GetCarouselPhotosByCarouselID(ctx context.Context, carouselID int32) ([]GetCarouselPhotosByCarouselIDRow, error)
 	GetCarouselWithPhotos(ctx context.Context, arg GetCarouselWithPhotosParams) ([]GetCarouselWithPhotosRow, error)
 	GetCarouselsByInstitute(ctx context.Context, instituteID int32) ([]Carousel, error)
+	// locked so that only one replica applies a change
+	GetDueUserStatusChanges(ctx context.Context, limit int32) ([]UserStatusChange, error)
+	GetEmailChangeRequestByHash(ctx context.Context, tokenHash string) (EmailChangeRequest, error)
+	// emails are unique across institutes and regardless of case, so this is not
+	// institute scoped; returns the lowercased matches
+	GetExistingUserEmails(ctx context.Context, emails []string) ([]string, error)
 	GetInstituteByCode(ctx context.Context, code string) (Institute, error)
 	GetInstituteByID(ctx context.Context, id int32) (Institute, error)
+	// platform admins also see disabled institutes
+	GetInstituteByIDAnyStatus(ctx context.Context, id int32) (Institute, error)
+	// pending invitations stay hidden, they would tell which emails exist
+	GetInstituteMembers(ctx context.Context, instituteID int32) ([]GetInstituteMembersRow, error)
+	// memberships of disabled institutes don't count
+	GetInstituteMembershipRole(ctx context.Context, arg GetInstituteMembershipRoleParams) (string, error)
+	GetInstituteOIDCProvider(ctx context.Context, instituteID int32) (InstituteOidcProvider, error)
+	GetInstituteTimezone(ctx context.Context, id int32) (string, error)
+	// home users and members, everyone who can hold a token for the institute
+	GetInstituteUserIDs(ctx context.Context, instituteID int32) ([]int32, error)
+	GetLoginChallengeByHash(ctx context.Context, tokenHash string) (LoginChallenge, error)
+	GetLoginThrottles(ctx context.Context, keys []string) ([]LoginThrottle, error)
 	GetNotice(ctx context.Context, arg GetNoticeParams) (Notice, error)
-	GetNoticesByInstitute(ctx context.Context, instituteID int32) ([]Notice, error)
+	GetNoticeAttachment(ctx context.Context, arg GetNoticeAttachmentParams) (NoticeAttachment, error)
+	GetNoticeAttachments(ctx context.Context, noticeID int32) ([]NoticeAttachment, error)
+	GetNoticeAttachmentsByNotices(ctx context.Context, noticeIds []int32) ([]NoticeAttachment, error)
+	// status NULL lists every state
+	GetNoticesByInstitute(ctx context.Context, arg GetNoticesByInstituteParams) ([]Notice, error)
+	GetPasswordPolicy(ctx context.Context, instituteID int32) (InstitutePasswordPolicy, error)
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	GetPendingMemberships(ctx context.Context, userID int32) ([]GetPendingMembershipsRow, error)
+	GetPendingUserInvite(ctx context.Context, arg GetPendingUserInviteParams) (GetPendingUserInviteRow, error)
+	GetPendingUserInvitesByInstitute(ctx context.Context, instituteID int32) ([]GetPendingUserInvitesByInstituteRow, error)
 	GetPhotoByID(ctx context.Context, arg GetPhotoByIDParams) (Photo, error)
 	GetPhotosByInstitute(ctx context.Context, instituteID int32) ([]Photo, error)
 	GetPhotosByUser(ctx context.Context, arg GetPhotosByUserParams) ([]Photo, error)
+	GetPublicNotice(ctx context.Context, arg GetPublicNoticeParams) (Notice, error)
+	// live notices for the public feed; the time checks keep the feed exact
+	// between scheduler runs
+	GetPublicNotices(ctx context.Context, arg GetPublicNoticesParams) ([]Notice, error)
+	GetRecentPasswordHashes(ctx context.Context, arg GetRecentPasswordHashesParams) ([]string, error)
+	GetSessionByTokenHash(ctx context.Context, refreshTokenHash string) (Session, error)
+	// emails match case-insensitively; an exact match wins over legacy rows
+	// that differ only in case
 	GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error)
+	GetUserByEmailAnyInstitute(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, arg GetUserByIDParams) (User, error)
+	GetUserByIDAnyInstitute(ctx context.Context, id int32) (User, error)
+	GetUserByOIDCIdentity(ctx context.Context, arg GetUserByOIDCIdentityParams) (User, error)
+	// the home institute first, then the memberships
+	GetUserInstitutes(ctx context.Context, userID int32) ([]GetUserInstitutesRow, error)
+	GetUserInviteByHash(ctx context.Context, tokenHash string) (UserInvite, error)
+	GetUserProfile(ctx context.Context, id int32) (GetUserProfileRow, error)
+	// newest first; before_id pages back through older events
+	GetUserSecurityEvents(ctx context.Context, arg GetUserSecurityEventsParams) ([]GetUserSecurityEventsRow, error)
+	GetUserStatusChanges(ctx context.Context, arg GetUserStatusChangesParams) ([]GetUserStatusChangesRow, error)
+	GetUserTOTP(ctx context.Context, userID int32) (UserTotp, error)
 	GetUsersByInstitute(ctx context.Context, instituteID int32) ([]User, error)
+	// claims an attempt before the code is checked, so parallel guesses cannot
+	// exceed max_attempts; no row once the challenge is used up
+	IncrementLoginChallengeAttempts(ctx context.Context, arg IncrementLoginChallengeAttemptsParams) (LoginChallenge, error)
+	InvalidateUserEmailChangeRequests(ctx context.Context, userID int32) error
+	InvalidateUserPasswordResetTokens(ctx context.Context, userID int32) error
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	// keyset pagination: the cursor is the sort value and id of the last row seen,
+	// sort is one of created_at, name, email with a leading "-" for descending
+	ListUsersByInstitute(ctx context.Context, arg ListUsersByInstituteParams) ([]User, error)
+	// serializes uploads to one notice, so the attachment limit holds
+	LockNotice(ctx context.Context, id int32) (int32, error)
 	LoginUser(ctx context.Context, arg LoginUserParams) (User, error)
+	MarkUserStatusChangeApplied(ctx context.Context, id int32) error
+	// keeps only the newest entries
+	PrunePasswordHistory(ctx context.Context, arg PrunePasswordHistoryParams) error
+	ReassignUserPhotos(ctx context.Context, arg ReassignUserPhotosParams) (int64, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error)
+	// resend: the previous link stops working
+	RenewUserInvite(ctx context.Context, arg RenewUserInviteParams) (UserInvite, error)
 	ReorderCarouselPhoto(ctx context.Context, arg ReorderCarouselPhotoParams) error
+	ResetLoginThrottle(ctx context.Context, key string) error
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (ApiKey, error)
+	RevokeSessionFamily(ctx context.Context, familyID string) error
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	// the pending user never signed in, so it is removed together with the
+	// invite; an account that was activated some other way stays
+	RevokeUserInvite(ctx context.Context, arg RevokeUserInviteParams) (int32, error)
+	RevokeUserSessions(ctx context.Context, userID int32) error
+	// revoked_before comes from the app server, the clock token issued_at uses;
+	// a cutoff never moves back
+	RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) (UserTokenRevocation, error)
+	RotateSession(ctx context.Context, id int32) (Session, error)
+	ScrubUserSecurityEvents(ctx context.Context, userID pgtype.Int4) error
 	SearchNotices(ctx context.Context, arg SearchNoticesParams) ([]Notice, error)
+	SetInstituteRequire2FA(ctx context.Context, arg SetInstituteRequire2FAParams) (Institute, error)
+	SetInstituteTimezone(ctx context.Context, arg SetInstituteTimezoneParams) (Institute, error)
+	SetLoginBlockedUntil(ctx context.Context, arg SetLoginBlockedUntilParams) error
+	SetUserActive(ctx context.Context, arg SetUserActiveParams) (SetUserActiveRow, error)
+	// NULLs remove the avatar
+	SetUserAvatar(ctx context.Context, arg SetUserAvatarParams) (SetUserAvatarRow, error)
+	// last_used_at is only written once a minute to keep hot keys cheap
+	TouchAPIKey(ctx context.Context, id int32) error
 	UpdateCarousel(ctx context.Context, arg UpdateCarouselParams) (Carousel, error)
 	UpdateCarouselPhoto(ctx context.Context, arg UpdateCarouselPhotoParams) (CarouselPhoto, error)
+	// is_active stays as it is when NULL
 	UpdateInstitute(ctx context.Context, arg UpdateInstituteParams) (Institute, error)
 	UpdateNotice(ctx context.Context, arg UpdateNoticeParams) (Notice, error)
 	UpdatePhotoImage(ctx context.Context, arg UpdatePhotoImageParams) (Photo, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (UpdateUserEmailRow, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (UpdateUserPasswordRow, error)
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (UpdateUserProfileRow, error)
+	// new memberships start pending; a role change keeps accepted_at
+	UpsertInstituteMembership(ctx context.Context, arg UpsertInstituteMembershipParams) (InstituteMembership, error)
+	UpsertInstituteOIDCProvider(ctx context.Context, arg UpsertInstituteOIDCProviderParams) (InstituteOidcProvider, error)
+	UpsertPasswordPolicy(ctx context.Context, arg UpsertPasswordPolicyParams) (InstitutePasswordPolicy, error)
+	UpsertUserTOTP(ctx context.Context, arg UpsertUserTOTPParams) (UserTotp, error)
+	UseEmailChangeRequest(ctx context.Context, id int32) (EmailChangeRequest, error)
+	UseLoginChallenge(ctx context.Context, id int32) (LoginChallenge, error)
+	UsePasswordResetToken(ctx context.Context, id int32) (PasswordResetToken, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (UserRecoveryCode, error)
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (UserTotp, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,107 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: security_event.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createSecurityEvent = `-- name: CreateSecurityEvent :exec
+INSERT INTO security_events (
+    user_id,
+    institute_id,
+    event_type,
+    email,
+    ip,
+    user_agent,
+    detail
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+`
+
+type CreateSecurityEventParams struct {
+	UserID      pgtype.Int4 `json:"user_id"`
+	InstituteID pgtype.Int4 `json:"institute_id"`
+	EventType   string      `json:"event_type"`
+	Email       string      `json:"email"`
+	Ip          string      `json:"ip"`
+	UserAgent   string      `json:"user_agent"`
+	Detail      string      `json:"detail"`
+}
+
+func (q *Queries) CreateSecurityEvent(ctx context.Context, arg CreateSecurityEventParams) error {
+	_, err := q.db.Exec(ctx, createSecurityEvent,
+		arg.UserID,
+		arg.InstituteID,
+		arg.EventType,
+		arg.Email,
+		arg.Ip,
+		arg.UserAgent,
+		arg.Detail,
+	)
+	return err
+}
+
+const getUserSecurityEvents = `-- name: GetUserSecurityEvents :many
+SELECT
+    id,
+    event_type,
+    ip,
+    user_agent,
+    detail,
+    created_at
+FROM security_events
+WHERE user_id = $1
+  AND ($2::bigint IS NULL OR id < $2::bigint)
+ORDER BY id DESC
+LIMIT $3
+`
+
+type GetUserSecurityEventsParams struct {
+	UserID   pgtype.Int4 `json:"user_id"`
+	BeforeID pgtype.Int8 `json:"before_id"`
+	RowLimit int32       `json:"row_limit"`
+}
+
+type GetUserSecurityEventsRow struct {
+	ID        int64              `json:"id"`
+	EventType string             `json:"event_type"`
+	Ip        string             `json:"ip"`
+	UserAgent string             `json:"user_agent"`
+	Detail    string             `json:"detail"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+// newest first; before_id pages back through older events
+func (q *Queries) GetUserSecurityEvents(ctx context.Context, arg GetUserSecurityEventsParams) ([]GetUserSecurityEventsRow, error) {
+	rows, err := q.db.Query(ctx, getUserSecurityEvents, arg.UserID, arg.BeforeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetUserSecurityEventsRow{}
+	for rows.Next() {
+		var i GetUserSecurityEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Detail,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
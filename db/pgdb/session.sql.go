@@ -0,0 +1,147 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: session.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createSession = `-- name: CreateSession :one
+INSERT INTO sessions (
+    user_id,
+    family_id,
+    refresh_token_hash,
+    user_agent,
+    client_ip,
+    expires_at,
+    institute_id,
+    family_created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, user_id, family_id, refresh_token_hash, user_agent, client_ip, is_revoked, rotated_at, expires_at, created_at, institute_id, family_created_at
+`
+
+type CreateSessionParams struct {
+	UserID           int32              `json:"user_id"`
+	FamilyID         string             `json:"family_id"`
+	RefreshTokenHash string             `json:"refresh_token_hash"`
+	UserAgent        string             `json:"user_agent"`
+	ClientIp         string             `json:"client_ip"`
+	ExpiresAt        pgtype.Timestamptz `json:"expires_at"`
+	InstituteID      pgtype.Int4        `json:"institute_id"`
+	FamilyCreatedAt  pgtype.Timestamptz `json:"family_created_at"`
+}
+
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, createSession,
+		arg.UserID,
+		arg.FamilyID,
+		arg.RefreshTokenHash,
+		arg.UserAgent,
+		arg.ClientIp,
+		arg.ExpiresAt,
+		arg.InstituteID,
+		arg.FamilyCreatedAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FamilyID,
+		&i.RefreshTokenHash,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsRevoked,
+		&i.RotatedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.InstituteID,
+		&i.FamilyCreatedAt,
+	)
+	return i, err
+}
+
+const getSessionByTokenHash = `-- name: GetSessionByTokenHash :one
+SELECT id, user_id, family_id, refresh_token_hash, user_agent, client_ip, is_revoked, rotated_at, expires_at, created_at, institute_id, family_created_at
+FROM sessions
+WHERE refresh_token_hash = $1
+LIMIT 1
+`
+
+func (q *Queries) GetSessionByTokenHash(ctx context.Context, refreshTokenHash string) (Session, error) {
+	row := q.db.QueryRow(ctx, getSessionByTokenHash, refreshTokenHash)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FamilyID,
+		&i.RefreshTokenHash,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsRevoked,
+		&i.RotatedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.InstituteID,
+		&i.FamilyCreatedAt,
+	)
+	return i, err
+}
+
+const revokeSessionFamily = `-- name: RevokeSessionFamily :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE family_id = $1
+`
+
+func (q *Queries) RevokeSessionFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.Exec(ctx, revokeSessionFamily, familyID)
+	return err
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE user_id = $1
+  AND is_revoked = false
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, revokeUserSessions, userID)
+	return err
+}
+
+const rotateSession = `-- name: RotateSession :one
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1
+  AND rotated_at IS NULL
+  AND is_revoked = false
+RETURNING id, user_id, family_id, refresh_token_hash, user_agent, client_ip, is_revoked, rotated_at, expires_at, created_at, institute_id, family_created_at
+`
+
+func (q *Queries) RotateSession(ctx context.Context, id int32) (Session, error) {
+	row := q.db.QueryRow(ctx, rotateSession, id)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.FamilyID,
+		&i.RefreshTokenHash,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsRevoked,
+		&i.RotatedAt,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.InstituteID,
+		&i.FamilyCreatedAt,
+	)
+	return i, err
+}
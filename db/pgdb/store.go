@@ -1,9 +1,28 @@
 package pgdb
 
-import "github.com/jackc/pgx/v5/pgxpool"
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
 
 type Store interface {
 	Querier
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (UpdateUserPasswordRow, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (UserTotp, error)
+	ReplaceRecoveryCodesTx(ctx context.Context, userID int32, codeHashes []string) error
+	DisableTOTPTx(ctx context.Context, userID int32) error
+	InviteUserTx(ctx context.Context, arg InviteUserTxParams) (InviteUserTxResult, error)
+	AcceptInviteTx(ctx context.Context, arg AcceptInviteTxParams) (ActivateInvitedUserRow, error)
+	RecordPasswordHistoryTx(ctx context.Context, userID int32, passwordHash string) error
+	ImportUsersTx(ctx context.Context, arg ImportUsersTxParams) ([]User, error)
+	OffboardUserTx(ctx context.Context, arg OffboardUserTxParams) (OffboardUserTxResult, error)
+	ChangeUserStatusTx(ctx context.Context, arg ChangeUserStatusTxParams) (ChangeUserStatusTxResult, error)
+	ApplyDueUserStatusChangesTx(ctx context.Context, limit int32) ([]UserStatusChange, error)
+	ChangeEmailTx(ctx context.Context, arg ChangeEmailTxParams) (UpdateUserEmailRow, error)
+	AddNoticeAttachmentsTx(ctx context.Context, arg AddNoticeAttachmentsTxParams) ([]NoticeAttachment, error)
 }
 
 type SqlStore struct {
@@ -17,3 +36,21 @@ func NewStore(db *pgxpool.Pool) Store {
 		Querier: New(db),
 	}
 }
+
+// execTx runs fn inside a database transaction
+func (store *SqlStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	q := New(tx)
+	if err := fn(q); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
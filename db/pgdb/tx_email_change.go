@@ -0,0 +1,35 @@
+package pgdb
+
+import "context"
+
+type ChangeEmailTxParams struct {
+	RequestID int32
+	UserID    int32
+	NewEmail  string
+}
+
+// ChangeEmailTx consumes a confirmed email change request and swaps the
+// user's email. It fails with ErrorNoRow if the request was already used or
+// has expired, and with ErrorDuplicateKey if the address was taken meanwhile.
+func (store *SqlStore) ChangeEmailTx(ctx context.Context, arg ChangeEmailTxParams) (UpdateUserEmailRow, error) {
+	var user UpdateUserEmailRow
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.UseEmailChangeRequest(ctx, arg.RequestID); err != nil {
+			return err
+		}
+
+		var err error
+		user, err = q.UpdateUserEmail(ctx, UpdateUserEmailParams{
+			ID:    arg.UserID,
+			Email: arg.NewEmail,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.InvalidateUserEmailChangeRequests(ctx, arg.UserID)
+	})
+
+	return user, err
+}
@@ -0,0 +1,54 @@
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ImportUserRow struct {
+	User CreateUserParams
+	// set when the user is invited instead of given a password
+	InviteTokenHash string
+}
+
+type ImportUsersTxParams struct {
+	Users           []ImportUserRow
+	InvitedBy       pgtype.Int4
+	InviteExpiresAt pgtype.Timestamptz
+}
+
+// ImportUsersTx creates all users of a bulk import or none of them. Invited
+// rows also get their invite, the others have their password recorded.
+func (store *SqlStore) ImportUsersTx(ctx context.Context, arg ImportUsersTxParams) ([]User, error) {
+	users := make([]User, 0, len(arg.Users))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		for _, row := range arg.Users {
+			user, err := q.CreateUser(ctx, row.User)
+			if err != nil {
+				return err
+			}
+
+			if row.InviteTokenHash != "" {
+				_, err = q.CreateUserInvite(ctx, CreateUserInviteParams{
+					UserID:      user.ID,
+					InstituteID: row.User.InstituteID,
+					TokenHash:   row.InviteTokenHash,
+					InvitedBy:   arg.InvitedBy,
+					ExpiresAt:   arg.InviteExpiresAt,
+				})
+			} else {
+				err = recordPasswordHistory(ctx, q, user.ID, row.User.Password)
+			}
+			if err != nil {
+				return err
+			}
+
+			users = append(users, user)
+		}
+		return nil
+	})
+
+	return users, err
+}
@@ -0,0 +1,77 @@
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type InviteUserTxParams struct {
+	User      CreateUserParams
+	TokenHash string
+	InvitedBy pgtype.Int4
+	ExpiresAt pgtype.Timestamptz
+}
+
+type InviteUserTxResult struct {
+	User   User
+	Invite UserInvite
+}
+
+// InviteUserTx creates the pending (inactive) user together with its invite
+func (store *SqlStore) InviteUserTx(ctx context.Context, arg InviteUserTxParams) (InviteUserTxResult, error) {
+	var result InviteUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = q.CreateUser(ctx, arg.User)
+		if err != nil {
+			return err
+		}
+
+		result.Invite, err = q.CreateUserInvite(ctx, CreateUserInviteParams{
+			UserID:      result.User.ID,
+			InstituteID: arg.User.InstituteID,
+			TokenHash:   arg.TokenHash,
+			InvitedBy:   arg.InvitedBy,
+			ExpiresAt:   arg.ExpiresAt,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type AcceptInviteTxParams struct {
+	InviteID int32
+	UserID   int32
+	Password string
+}
+
+// AcceptInviteTx consumes the invite and activates the user with their own
+// password. It fails with ErrorNoRow if the invite was used or has expired,
+// or the user is no longer pending.
+func (store *SqlStore) AcceptInviteTx(ctx context.Context, arg AcceptInviteTxParams) (ActivateInvitedUserRow, error) {
+	var user ActivateInvitedUserRow
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// activate while the invite is still pending, then consume it
+		var err error
+		user, err = q.ActivateInvitedUser(ctx, ActivateInvitedUserParams{
+			ID:       arg.UserID,
+			Password: arg.Password,
+			InviteID: arg.InviteID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.AcceptUserInvite(ctx, arg.InviteID); err != nil {
+			return err
+		}
+
+		return recordPasswordHistory(ctx, q, arg.UserID, arg.Password)
+	})
+
+	return user, err
+}
@@ -0,0 +1,48 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTooManyAttachments is returned when an upload would take a notice over
+// its attachment limit
+var ErrTooManyAttachments = errors.New("too many attachments")
+
+type AddNoticeAttachmentsTxParams struct {
+	NoticeID       int32
+	MaxAttachments int
+	Attachments    []CreateNoticeAttachmentParams
+}
+
+// AddNoticeAttachmentsTx saves all files of one upload or none of them. The
+// notice row stays locked while the limit is checked, so concurrent uploads
+// can't both pass it.
+func (store *SqlStore) AddNoticeAttachmentsTx(ctx context.Context, arg AddNoticeAttachmentsTxParams) ([]NoticeAttachment, error) {
+	saved := make([]NoticeAttachment, 0, len(arg.Attachments))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.LockNotice(ctx, arg.NoticeID); err != nil {
+			return err
+		}
+
+		count, err := q.CountNoticeAttachments(ctx, arg.NoticeID)
+		if err != nil {
+			return err
+		}
+		if int(count)+len(arg.Attachments) > arg.MaxAttachments {
+			return ErrTooManyAttachments
+		}
+
+		for _, params := range arg.Attachments {
+			attachment, err := q.CreateNoticeAttachment(ctx, params)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, attachment)
+		}
+		return nil
+	})
+
+	return saved, err
+}
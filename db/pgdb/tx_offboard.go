@@ -0,0 +1,108 @@
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type OffboardUserTxParams struct {
+	UserID      int32
+	InstituteID int32
+	// user that takes over the photos; 0 means the institute system account
+	ReassignTo int32
+	// created the first time the institute needs a system account
+	SystemUser CreateUserParams
+	// nil deletes the user row instead of anonymizing it
+	Anonymize *AnonymizeUserParams
+	// account_offboarded audit event; a deleted user's event is written
+	// before the row goes, so it is recorded under the real user ID
+	Event CreateSecurityEventParams
+}
+
+type OffboardUserTxResult struct {
+	ReassignedTo     User
+	ReassignedPhotos int64
+}
+
+// OffboardUserTx hands the user's photos over to another user and then
+// anonymizes or deletes the user, all or nothing
+func (store *SqlStore) OffboardUserTx(ctx context.Context, arg OffboardUserTxParams) (OffboardUserTxResult, error) {
+	var result OffboardUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		if arg.ReassignTo != 0 {
+			result.ReassignedTo, err = q.GetUserByID(ctx, GetUserByIDParams{
+				ID:          arg.ReassignTo,
+				InstituteID: arg.InstituteID,
+			})
+		} else {
+			result.ReassignedTo, err = systemUser(ctx, q, arg.SystemUser)
+		}
+		if err != nil {
+			return err
+		}
+
+		result.ReassignedPhotos, err = q.ReassignUserPhotos(ctx, ReassignUserPhotosParams{
+			ToUserID:    result.ReassignedTo.ID,
+			FromUserID:  arg.UserID,
+			InstituteID: arg.InstituteID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.Anonymize == nil {
+			if err := q.CreateSecurityEvent(ctx, arg.Event); err != nil {
+				return err
+			}
+			return q.DeleteUser(ctx, arg.UserID)
+		}
+
+		if _, err := q.AnonymizeUser(ctx, *arg.Anonymize); err != nil {
+			return err
+		}
+		if err := q.DeleteRecoveryCodes(ctx, arg.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserTOTP(ctx, arg.UserID); err != nil {
+			return err
+		}
+		if err := q.DeleteUserInvites(ctx, arg.UserID); err != nil {
+			return err
+		}
+		if err := q.DeletePasswordHistory(ctx, arg.UserID); err != nil {
+			return err
+		}
+		if err := q.InvalidateUserEmailChangeRequests(ctx, arg.UserID); err != nil {
+			return err
+		}
+		// a scheduled enable must not bring the anonymized account back
+		_, err = q.CancelPendingUserStatusChanges(ctx, CancelPendingUserStatusChangesParams{
+			UserID:      arg.UserID,
+			InstituteID: arg.InstituteID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := q.ScrubUserSecurityEvents(ctx, pgtype.Int4{Int32: arg.UserID, Valid: true}); err != nil {
+			return err
+		}
+		return q.CreateSecurityEvent(ctx, arg.Event)
+	})
+
+	return result, err
+}
+
+// systemUser returns the institute system account, creating it if needed
+func systemUser(ctx context.Context, q *Queries, arg CreateUserParams) (User, error) {
+	user, err := q.GetUserByEmail(ctx, GetUserByEmailParams{
+		Email:       arg.Email,
+		InstituteID: arg.InstituteID,
+	})
+	if err != nil && ErrorCode(err) == ErrorNoRow {
+		return q.CreateUser(ctx, arg)
+	}
+	return user, err
+}
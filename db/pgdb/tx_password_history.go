@@ -0,0 +1,30 @@
+package pgdb
+
+import "context"
+
+// PasswordHistoryLimit caps how many previous password hashes are kept per
+// user; every one of them costs an argon2id hash on each password change
+const PasswordHistoryLimit = 5
+
+// RecordPasswordHistoryTx remembers a newly set password hash and drops the
+// oldest entries beyond PasswordHistoryLimit
+func (store *SqlStore) RecordPasswordHistoryTx(ctx context.Context, userID int32, passwordHash string) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		return recordPasswordHistory(ctx, q, userID, passwordHash)
+	})
+}
+
+func recordPasswordHistory(ctx context.Context, q *Queries, userID int32, passwordHash string) error {
+	err := q.AddPasswordHistory(ctx, AddPasswordHistoryParams{
+		UserID:       userID,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	return q.PrunePasswordHistory(ctx, PrunePasswordHistoryParams{
+		UserID: userID,
+		Keep:   PasswordHistoryLimit,
+	})
+}
@@ -0,0 +1,44 @@
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ResetPasswordTxParams struct {
+	TokenID     int32
+	UserID      int32
+	InstituteID pgtype.Int4
+	Password    string
+}
+
+// ResetPasswordTx consumes a reset token and stores the new password hash.
+// It fails with ErrorNoRow if the token was already used or has expired.
+func (store *SqlStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (UpdateUserPasswordRow, error) {
+	var user UpdateUserPasswordRow
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.UsePasswordResetToken(ctx, arg.TokenID); err != nil {
+			return err
+		}
+
+		var err error
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Password:    arg.Password,
+			ID:          arg.UserID,
+			InstituteID: arg.InstituteID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordPasswordHistory(ctx, q, arg.UserID, arg.Password); err != nil {
+			return err
+		}
+
+		return q.InvalidateUserPasswordResetTokens(ctx, arg.UserID)
+	})
+
+	return user, err
+}
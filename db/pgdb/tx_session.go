@@ -0,0 +1,27 @@
+package pgdb
+
+import "context"
+
+type RotateSessionTxParams struct {
+	OldSessionID int32
+	NewSession   CreateSessionParams
+}
+
+// RotateSessionTx marks the old refresh token as used and issues its
+// replacement in the same family. It fails with ErrorNoRow if the old
+// session was already rotated or revoked by a concurrent request.
+func (store *SqlStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error) {
+	var session Session
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if _, err := q.RotateSession(ctx, arg.OldSessionID); err != nil {
+			return err
+		}
+
+		var err error
+		session, err = q.CreateSession(ctx, arg.NewSession)
+		return err
+	})
+
+	return session, err
+}
@@ -0,0 +1,68 @@
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type EnableTOTPTxParams struct {
+	UserID     int32
+	Step       int64
+	CodeHashes []string
+}
+
+// EnableTOTPTx confirms a pending TOTP enrolment and stores a fresh set of
+// recovery codes. It fails with ErrorNoRow if the enrolment was already confirmed.
+func (store *SqlStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (UserTotp, error) {
+	var totp UserTotp
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		totp, err = q.ConfirmUserTOTP(ctx, ConfirmUserTOTPParams{
+			UserID:       arg.UserID,
+			LastUsedStep: pgtype.Int8{Int64: arg.Step, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, q, arg.UserID, arg.CodeHashes)
+	})
+
+	return totp, err
+}
+
+// ReplaceRecoveryCodesTx invalidates all recovery codes of a user and stores new ones
+func (store *SqlStore) ReplaceRecoveryCodesTx(ctx context.Context, userID int32, codeHashes []string) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		return replaceRecoveryCodes(ctx, q, userID, codeHashes)
+	})
+}
+
+// DisableTOTPTx removes the TOTP secret and every recovery code of a user
+func (store *SqlStore) DisableTOTPTx(ctx context.Context, userID int32) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+		return q.DeleteUserTOTP(ctx, userID)
+	})
+}
+
+func replaceRecoveryCodes(ctx context.Context, q *Queries, userID int32, codeHashes []string) error {
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ChangeUserStatusTxParams struct {
+	UserID      int32
+	InstituteID int32
+	IsActive    bool
+	Reason      string
+	ChangedBy   pgtype.Int4
+}
+
+type ChangeUserStatusTxResult struct {
+	User   SetUserActiveRow
+	Change UserStatusChange
+	// scheduled changes dropped because this one supersedes them
+	CanceledChanges int64
+}
+
+// ChangeUserStatusTx enables or disables a user right away, cancels the
+// user's pending scheduled changes and records the change in the status
+// history. Enabling closes open invites.
+func (store *SqlStore) ChangeUserStatusTx(ctx context.Context, arg ChangeUserStatusTxParams) (ChangeUserStatusTxResult, error) {
+	var result ChangeUserStatusTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = q.SetUserActive(ctx, SetUserActiveParams{
+			ID:          arg.UserID,
+			InstituteID: arg.InstituteID,
+			IsActive:    pgtype.Bool{Bool: arg.IsActive, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if arg.IsActive {
+			if err := q.DeleteOpenUserInvites(ctx, arg.UserID); err != nil {
+				return err
+			}
+		}
+
+		result.CanceledChanges, err = q.CancelPendingUserStatusChanges(ctx, CancelPendingUserStatusChangesParams{
+			UserID:      arg.UserID,
+			InstituteID: arg.InstituteID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Change, err = q.CreateUserStatusChange(ctx, CreateUserStatusChangeParams{
+			UserID:      arg.UserID,
+			InstituteID: arg.InstituteID,
+			IsActive:    arg.IsActive,
+			Reason:      arg.Reason,
+			ChangedBy:   arg.ChangedBy,
+			EffectiveAt: result.User.UpdatedAt,
+			AppliedAt:   result.User.UpdatedAt,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ApplyDueUserStatusChangesTx applies up to limit scheduled changes whose time
+// has come and returns them. Changes of users that moved institute are only
+// marked as applied.
+func (store *SqlStore) ApplyDueUserStatusChangesTx(ctx context.Context, limit int32) ([]UserStatusChange, error) {
+	var applied []UserStatusChange
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		changes, err := q.GetDueUserStatusChanges(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			_, err := q.SetUserActive(ctx, SetUserActiveParams{
+				ID:          change.UserID,
+				InstituteID: change.InstituteID,
+				IsActive:    pgtype.Bool{Bool: change.IsActive, Valid: true},
+			})
+			if err != nil && ErrorCode(err) != ErrorNoRow {
+				return err
+			}
+			if err == nil {
+				applied = append(applied, change)
+				if change.IsActive {
+					if err := q.DeleteOpenUserInvites(ctx, change.UserID); err != nil {
+						return err
+					}
+				}
+			}
+
+			if err := q.MarkUserStatusChangeApplied(ctx, change.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return applied, err
+}
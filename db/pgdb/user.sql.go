@@ -11,6 +11,75 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countUsersByInstitute = `-- name: CountUsersByInstitute :one
+SELECT count(*)
+FROM users
+WHERE institute_id = $1::int
+  AND ($2::text IS NULL OR role = $2::text)
+  AND ($3::boolean IS NULL OR is_active = $3::boolean)
+  AND ($4::text IS NULL OR name ILIKE $4::text OR email ILIKE $4::text)
+`
+
+type CountUsersByInstituteParams struct {
+	InstituteID int32       `json:"institute_id"`
+	Role        pgtype.Text `json:"role"`
+	IsActive    pgtype.Bool `json:"is_active"`
+	Search      pgtype.Text `json:"search"`
+}
+
+func (q *Queries) CountUsersByInstitute(ctx context.Context, arg CountUsersByInstituteParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countUsersByInstitute,
+		arg.InstituteID,
+		arg.Role,
+		arg.IsActive,
+		arg.Search,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createSuperAdmin = `-- name: CreateSuperAdmin :one
+INSERT INTO users (
+    institute_id,
+    name,
+    email,
+    password,
+    role,
+    is_active
+) VALUES (
+    NULL, $1, $2, $3, 'superadmin', true
+)
+ON CONFLICT (email) DO NOTHING
+RETURNING id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
+`
+
+type CreateSuperAdminParams struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (q *Queries) CreateSuperAdmin(ctx context.Context, arg CreateSuperAdminParams) (User, error) {
+	row := q.db.QueryRow(ctx, createSuperAdmin, arg.Name, arg.Email, arg.Password)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     institute_id,
@@ -20,9 +89,14 @@ INSERT INTO users (
     role,
     is_active
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1::int,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
 )
-RETURNING id, institute_id, name, email, password, role, is_active, created_at, updated_at
+RETURNING id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
 `
 
 type CreateUserParams struct {
@@ -54,6 +128,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
 	)
 	return i, err
 }
@@ -75,7 +152,7 @@ SET
     updated_at = now()
 WHERE
     id = $1
-    AND institute_id = $2
+    AND institute_id = $2::int
 RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at
 `
 
@@ -86,7 +163,7 @@ type DisableUserParams struct {
 
 type DisableUserRow struct {
 	ID          int32              `json:"id"`
-	InstituteID int32              `json:"institute_id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
 	Name        string             `json:"name"`
 	Email       string             `json:"email"`
 	Role        pgtype.Text        `json:"role"`
@@ -111,11 +188,43 @@ func (q *Queries) DisableUser(ctx context.Context, arg DisableUserParams) (Disab
 	return i, err
 }
 
+const getExistingUserEmails = `-- name: GetExistingUserEmails :many
+SELECT lower(email)::text AS email
+FROM users
+WHERE lower(email) = ANY(
+    SELECT lower(e)
+    FROM unnest($1::text[]) AS e
+)
+`
+
+// emails are unique across institutes and regardless of case, so this is not
+// institute scoped; returns the lowercased matches
+func (q *Queries) GetExistingUserEmails(ctx context.Context, emails []string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getExistingUserEmails, emails)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		items = append(items, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at
+SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
 FROM users
-WHERE email = $1
-  AND institute_id = $2
+WHERE lower(email) = lower($1)
+  AND institute_id = $2::int
+ORDER BY email = $1 DESC
 LIMIT 1
 `
 
@@ -124,6 +233,8 @@ type GetUserByEmailParams struct {
 	InstituteID int32  `json:"institute_id"`
 }
 
+// emails match case-insensitively; an exact match wins over legacy rows
+// that differ only in case
 func (q *Queries) GetUserByEmail(ctx context.Context, arg GetUserByEmailParams) (User, error) {
 	row := q.db.QueryRow(ctx, getUserByEmail, arg.Email, arg.InstituteID)
 	var i User
@@ -137,15 +248,18 @@ func (q *Queries) GetUserByEmail(ctx context.Context, arg GetUserByEmailParams)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at
+SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
 FROM users
 WHERE id = $1
-AND institute_id = $2
+AND institute_id = $2::int
 LIMIT 1
 `
 
@@ -167,14 +281,44 @@ func (q *Queries) GetUserByID(ctx context.Context, arg GetUserByIDParams) (User,
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
+	)
+	return i, err
+}
+
+const getUserByIDAnyInstitute = `-- name: GetUserByIDAnyInstitute :one
+SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
+FROM users
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserByIDAnyInstitute(ctx context.Context, id int32) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByIDAnyInstitute, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Password,
+		&i.Role,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
 	)
 	return i, err
 }
 
 const getUsersByInstitute = `-- name: GetUsersByInstitute :many
-SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at
+SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
 FROM users
-WHERE institute_id = $1
+WHERE institute_id = $1::int
 ORDER BY created_at DESC
 `
 
@@ -197,6 +341,94 @@ func (q *Queries) GetUsersByInstitute(ctx context.Context, instituteID int32) ([
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Phone,
+			&i.AvatarUrl,
+			&i.AvatarPublicID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByInstitute = `-- name: ListUsersByInstitute :many
+SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
+FROM users
+WHERE institute_id = $1::int
+  AND ($2::text IS NULL OR role = $2::text)
+  AND ($3::boolean IS NULL OR is_active = $3::boolean)
+  AND ($4::text IS NULL OR name ILIKE $4::text OR email ILIKE $4::text)
+  AND (
+    $5::int IS NULL
+    OR ($6::text = 'created_at' AND (created_at, id) > ($7::timestamptz, $5::int))
+    OR ($6::text = '-created_at' AND (created_at, id) < ($7::timestamptz, $5::int))
+    OR ($6::text = 'name' AND (name, id) > ($8::text, $5::int))
+    OR ($6::text = '-name' AND (name, id) < ($8::text, $5::int))
+    OR ($6::text = 'email' AND (email, id) > ($8::text, $5::int))
+    OR ($6::text = '-email' AND (email, id) < ($8::text, $5::int))
+  )
+ORDER BY
+    CASE WHEN $6::text = 'created_at' THEN created_at END ASC,
+    CASE WHEN $6::text = '-created_at' THEN created_at END DESC,
+    CASE WHEN $6::text = 'name' THEN name END ASC,
+    CASE WHEN $6::text = '-name' THEN name END DESC,
+    CASE WHEN $6::text = 'email' THEN email END ASC,
+    CASE WHEN $6::text = '-email' THEN email END DESC,
+    CASE WHEN left($6::text, 1) = '-' THEN id END DESC,
+    id ASC
+LIMIT $9
+`
+
+type ListUsersByInstituteParams struct {
+	InstituteID int32              `json:"institute_id"`
+	Role        pgtype.Text        `json:"role"`
+	IsActive    pgtype.Bool        `json:"is_active"`
+	Search      pgtype.Text        `json:"search"`
+	CursorID    pgtype.Int4        `json:"cursor_id"`
+	Sort        string             `json:"sort"`
+	CursorTime  pgtype.Timestamptz `json:"cursor_time"`
+	CursorText  pgtype.Text        `json:"cursor_text"`
+	RowLimit    int32              `json:"row_limit"`
+}
+
+// keyset pagination: the cursor is the sort value and id of the last row seen,
+// sort is one of created_at, name, email with a leading "-" for descending
+func (q *Queries) ListUsersByInstitute(ctx context.Context, arg ListUsersByInstituteParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersByInstitute,
+		arg.InstituteID,
+		arg.Role,
+		arg.IsActive,
+		arg.Search,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorTime,
+		arg.CursorText,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.InstituteID,
+			&i.Name,
+			&i.Email,
+			&i.Password,
+			&i.Role,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Phone,
+			&i.AvatarUrl,
+			&i.AvatarPublicID,
 		); err != nil {
 			return nil, err
 		}
@@ -209,7 +441,7 @@ func (q *Queries) GetUsersByInstitute(ctx context.Context, instituteID int32) ([
 }
 
 const loginUser = `-- name: LoginUser :one
-SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at
+SELECT id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
 FROM users
 WHERE (id = $1 OR email = $2)
 AND is_active = true
@@ -234,6 +466,9 @@ func (q *Queries) LoginUser(ctx context.Context, arg LoginUserParams) (User, err
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
 	)
 	return i, err
 }
@@ -241,29 +476,29 @@ func (q *Queries) LoginUser(ctx context.Context, arg LoginUserParams) (User, err
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
-    name = $2,
-    role = $3,
-    is_active = $4,
+    name = $1,
+    role = $2,
+    is_active = $3,
     updated_at = now()
-WHERE id = $1
-  AND institute_id = $5
-RETURNING id, institute_id, name, email, password, role, is_active, created_at, updated_at
+WHERE id = $4
+  AND institute_id = $5::int
+RETURNING id, institute_id, name, email, password, role, is_active, created_at, updated_at, phone, avatar_url, avatar_public_id
 `
 
 type UpdateUserParams struct {
-	ID          int32       `json:"id"`
 	Name        string      `json:"name"`
 	Role        pgtype.Text `json:"role"`
 	IsActive    pgtype.Bool `json:"is_active"`
+	ID          int32       `json:"id"`
 	InstituteID int32       `json:"institute_id"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
 	row := q.db.QueryRow(ctx, updateUser,
-		arg.ID,
 		arg.Name,
 		arg.Role,
 		arg.IsActive,
+		arg.ID,
 		arg.InstituteID,
 	)
 	var i User
@@ -277,6 +512,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Phone,
+		&i.AvatarUrl,
+		&i.AvatarPublicID,
 	)
 	return i, err
 }
@@ -288,19 +526,19 @@ SET
     updated_at = now()
 WHERE
     id = $2
-    AND institute_id = $3
+    AND institute_id IS NOT DISTINCT FROM $3
 RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at
 `
 
 type UpdateUserPasswordParams struct {
-	Password    string `json:"password"`
-	ID          int32  `json:"id"`
-	InstituteID int32  `json:"institute_id"`
+	Password    string      `json:"password"`
+	ID          int32       `json:"id"`
+	InstituteID pgtype.Int4 `json:"institute_id"`
 }
 
 type UpdateUserPasswordRow struct {
 	ID          int32              `json:"id"`
-	InstituteID int32              `json:"institute_id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
 	Name        string             `json:"name"`
 	Email       string             `json:"email"`
 	Role        pgtype.Text        `json:"role"`
@@ -0,0 +1,284 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: user_status.sql
+
+package pgdb
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const cancelPendingUserStatusChanges = `-- name: CancelPendingUserStatusChanges :execrows
+UPDATE user_status_changes
+SET canceled_at = now()
+WHERE user_id = $1
+  AND institute_id = $2
+  AND applied_at IS NULL
+  AND canceled_at IS NULL
+`
+
+type CancelPendingUserStatusChangesParams struct {
+	UserID      int32 `json:"user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+// an immediate change overrides whatever was scheduled before it
+func (q *Queries) CancelPendingUserStatusChanges(ctx context.Context, arg CancelPendingUserStatusChangesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelPendingUserStatusChanges, arg.UserID, arg.InstituteID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const cancelUserStatusChange = `-- name: CancelUserStatusChange :one
+UPDATE user_status_changes
+SET canceled_at = now()
+WHERE id = $1
+  AND user_id = $2
+  AND institute_id = $3
+  AND applied_at IS NULL
+  AND canceled_at IS NULL
+RETURNING id, user_id, institute_id, is_active, reason, changed_by, effective_at, applied_at, canceled_at, created_at
+`
+
+type CancelUserStatusChangeParams struct {
+	ID          int32 `json:"id"`
+	UserID      int32 `json:"user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+func (q *Queries) CancelUserStatusChange(ctx context.Context, arg CancelUserStatusChangeParams) (UserStatusChange, error) {
+	row := q.db.QueryRow(ctx, cancelUserStatusChange, arg.ID, arg.UserID, arg.InstituteID)
+	var i UserStatusChange
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.InstituteID,
+		&i.IsActive,
+		&i.Reason,
+		&i.ChangedBy,
+		&i.EffectiveAt,
+		&i.AppliedAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createUserStatusChange = `-- name: CreateUserStatusChange :one
+INSERT INTO user_status_changes (
+    user_id,
+    institute_id,
+    is_active,
+    reason,
+    changed_by,
+    effective_at,
+    applied_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, user_id, institute_id, is_active, reason, changed_by, effective_at, applied_at, canceled_at, created_at
+`
+
+type CreateUserStatusChangeParams struct {
+	UserID      int32              `json:"user_id"`
+	InstituteID int32              `json:"institute_id"`
+	IsActive    bool               `json:"is_active"`
+	Reason      string             `json:"reason"`
+	ChangedBy   pgtype.Int4        `json:"changed_by"`
+	EffectiveAt pgtype.Timestamptz `json:"effective_at"`
+	AppliedAt   pgtype.Timestamptz `json:"applied_at"`
+}
+
+func (q *Queries) CreateUserStatusChange(ctx context.Context, arg CreateUserStatusChangeParams) (UserStatusChange, error) {
+	row := q.db.QueryRow(ctx, createUserStatusChange,
+		arg.UserID,
+		arg.InstituteID,
+		arg.IsActive,
+		arg.Reason,
+		arg.ChangedBy,
+		arg.EffectiveAt,
+		arg.AppliedAt,
+	)
+	var i UserStatusChange
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.InstituteID,
+		&i.IsActive,
+		&i.Reason,
+		&i.ChangedBy,
+		&i.EffectiveAt,
+		&i.AppliedAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getDueUserStatusChanges = `-- name: GetDueUserStatusChanges :many
+SELECT id, user_id, institute_id, is_active, reason, changed_by, effective_at, applied_at, canceled_at, created_at
+FROM user_status_changes
+WHERE applied_at IS NULL
+  AND canceled_at IS NULL
+  AND effective_at <= now()
+ORDER BY effective_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+// locked so that only one replica applies a change
+func (q *Queries) GetDueUserStatusChanges(ctx context.Context, limit int32) ([]UserStatusChange, error) {
+	rows, err := q.db.Query(ctx, getDueUserStatusChanges, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserStatusChange{}
+	for rows.Next() {
+		var i UserStatusChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.InstituteID,
+			&i.IsActive,
+			&i.Reason,
+			&i.ChangedBy,
+			&i.EffectiveAt,
+			&i.AppliedAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserStatusChanges = `-- name: GetUserStatusChanges :many
+SELECT
+    c.id,
+    c.is_active,
+    c.reason,
+    c.changed_by,
+    u.name AS changed_by_name,
+    c.effective_at,
+    c.applied_at,
+    c.canceled_at,
+    c.created_at
+FROM user_status_changes c
+LEFT JOIN users u ON u.id = c.changed_by
+WHERE c.user_id = $1
+  AND c.institute_id = $2
+ORDER BY c.effective_at DESC, c.id DESC
+`
+
+type GetUserStatusChangesParams struct {
+	UserID      int32 `json:"user_id"`
+	InstituteID int32 `json:"institute_id"`
+}
+
+type GetUserStatusChangesRow struct {
+	ID            int32              `json:"id"`
+	IsActive      bool               `json:"is_active"`
+	Reason        string             `json:"reason"`
+	ChangedBy     pgtype.Int4        `json:"changed_by"`
+	ChangedByName pgtype.Text        `json:"changed_by_name"`
+	EffectiveAt   pgtype.Timestamptz `json:"effective_at"`
+	AppliedAt     pgtype.Timestamptz `json:"applied_at"`
+	CanceledAt    pgtype.Timestamptz `json:"canceled_at"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+}
+
+func (q *Queries) GetUserStatusChanges(ctx context.Context, arg GetUserStatusChangesParams) ([]GetUserStatusChangesRow, error) {
+	rows, err := q.db.Query(ctx, getUserStatusChanges, arg.UserID, arg.InstituteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetUserStatusChangesRow{}
+	for rows.Next() {
+		var i GetUserStatusChangesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IsActive,
+			&i.Reason,
+			&i.ChangedBy,
+			&i.ChangedByName,
+			&i.EffectiveAt,
+			&i.AppliedAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markUserStatusChangeApplied = `-- name: MarkUserStatusChangeApplied :exec
+UPDATE user_status_changes
+SET applied_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) MarkUserStatusChangeApplied(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, markUserStatusChangeApplied, id)
+	return err
+}
+
+const setUserActive = `-- name: SetUserActive :one
+UPDATE users
+SET
+    is_active = $1,
+    updated_at = now()
+WHERE
+    id = $2
+    AND institute_id = $3::int
+RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at
+`
+
+type SetUserActiveParams struct {
+	IsActive    pgtype.Bool `json:"is_active"`
+	ID          int32       `json:"id"`
+	InstituteID int32       `json:"institute_id"`
+}
+
+type SetUserActiveRow struct {
+	ID          int32              `json:"id"`
+	InstituteID pgtype.Int4        `json:"institute_id"`
+	Name        string             `json:"name"`
+	Email       string             `json:"email"`
+	Role        pgtype.Text        `json:"role"`
+	IsActive    pgtype.Bool        `json:"is_active"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) SetUserActive(ctx context.Context, arg SetUserActiveParams) (SetUserActiveRow, error) {
+	row := q.db.QueryRow(ctx, setUserActive, arg.IsActive, arg.ID, arg.InstituteID)
+	var i SetUserActiveRow
+	err := row.Scan(
+		&i.ID,
+		&i.InstituteID,
+		&i.Name,
+		&i.Email,
+		&i.Role,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
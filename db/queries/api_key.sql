@@ -0,0 +1,49 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (
+    institute_id,
+    name,
+    prefix,
+    key_hash,
+    scopes,
+    created_by,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+
+-- name: GetActiveAPIKeyByHash :one
+-- usable keys only: not revoked, not expired, institute still active
+SELECT k.*
+FROM api_keys k
+JOIN institutes i ON i.id = k.institute_id
+WHERE k.key_hash = $1
+  AND k.revoked_at IS NULL
+  AND (k.expires_at IS NULL OR k.expires_at > now())
+  AND i.is_active = true
+LIMIT 1;
+
+
+-- name: GetAPIKeysByInstitute :many
+SELECT *
+FROM api_keys
+WHERE institute_id = $1
+ORDER BY created_at DESC;
+
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1
+  AND institute_id = $2
+  AND revoked_at IS NULL
+RETURNING *;
+
+
+-- name: TouchAPIKey :exec
+-- last_used_at is only written once a minute to keep hot keys cheap
+UPDATE api_keys
+SET last_used_at = now()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
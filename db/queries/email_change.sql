@@ -0,0 +1,43 @@
+-- name: CreateEmailChangeRequest :one
+INSERT INTO email_change_requests (
+    user_id,
+    new_email,
+    token_hash,
+    requested_by,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+
+-- name: GetEmailChangeRequestByHash :one
+SELECT *
+FROM email_change_requests
+WHERE token_hash = $1
+LIMIT 1;
+
+
+-- name: UseEmailChangeRequest :one
+UPDATE email_change_requests
+SET used_at = now()
+WHERE id = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+RETURNING *;
+
+
+-- name: InvalidateUserEmailChangeRequests :exec
+UPDATE email_change_requests
+SET used_at = now()
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+
+-- name: UpdateUserEmail :one
+UPDATE users
+SET
+    email = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, institute_id, name, email, role, is_active, updated_at;
@@ -34,16 +34,31 @@ WHERE is_active = true
 ORDER BY created_at DESC;
 
 
+-- name: GetInstituteByIDAnyStatus :one
+-- platform admins also see disabled institutes
+SELECT *
+FROM institutes
+WHERE id = $1
+LIMIT 1;
+
+
+-- name: GetAllInstitutesAnyStatus :many
+SELECT *
+FROM institutes
+ORDER BY created_at DESC;
+
+
 -- name: UpdateInstitute :one
+-- is_active stays as it is when NULL
 UPDATE institutes
 SET
-    name = $2,
-    code = $3,
-    email = $4,
-    phone = $5,
-    address = $6,
-    is_active = $7
-WHERE id = $1
+    name = sqlc.arg(name),
+    code = sqlc.arg(code),
+    email = sqlc.arg(email),
+    phone = sqlc.arg(phone),
+    address = sqlc.arg(address),
+    is_active = COALESCE(sqlc.narg(is_active), is_active)
+WHERE id = sqlc.arg(id)
 RETURNING *;
 
 
@@ -53,6 +68,42 @@ SET is_active = false
 WHERE id = $1;
 
 
--- name: DeleteInstitute :exec
+-- name: DeleteInstitute :execrows
 DELETE FROM institutes
 WHERE id = $1;
+
+
+-- name: GetInstituteUserIDs :many
+-- home users and members, everyone who can hold a token for the institute
+SELECT id AS user_id
+FROM users
+WHERE institute_id = sqlc.arg(institute_id)::int
+UNION
+SELECT user_id
+FROM institute_memberships
+WHERE institute_id = sqlc.arg(institute_id)::int;
+
+
+-- name: SetInstituteRequire2FA :one
+UPDATE institutes
+SET
+    require_2fa = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: GetInstituteTimezone :one
+SELECT timezone
+FROM institutes
+WHERE id = $1
+LIMIT 1;
+
+
+-- name: SetInstituteTimezone :one
+UPDATE institutes
+SET
+    timezone = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
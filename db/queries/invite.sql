@@ -0,0 +1,120 @@
+-- name: CreateUserInvite :one
+INSERT INTO user_invites (
+    user_id,
+    institute_id,
+    token_hash,
+    invited_by,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+
+-- name: GetUserInviteByHash :one
+SELECT *
+FROM user_invites
+WHERE token_hash = $1
+LIMIT 1;
+
+
+-- name: GetPendingUserInvite :one
+SELECT
+    i.id,
+    i.user_id,
+    u.name,
+    u.email,
+    u.role,
+    i.invited_by,
+    i.expires_at,
+    i.created_at
+FROM user_invites i
+JOIN users u ON u.id = i.user_id
+WHERE i.id = $1
+  AND i.institute_id = $2
+  AND i.accepted_at IS NULL
+LIMIT 1;
+
+
+-- name: GetPendingUserInvitesByInstitute :many
+SELECT
+    i.id,
+    i.user_id,
+    u.name,
+    u.email,
+    u.role,
+    i.invited_by,
+    i.expires_at,
+    i.created_at
+FROM user_invites i
+JOIN users u ON u.id = i.user_id
+WHERE i.institute_id = $1
+  AND i.accepted_at IS NULL
+ORDER BY i.created_at DESC;
+
+
+-- name: RenewUserInvite :one
+-- resend: the previous link stops working
+UPDATE user_invites
+SET
+    token_hash = $3,
+    expires_at = $4,
+    updated_at = now()
+WHERE id = $1
+  AND institute_id = $2
+  AND accepted_at IS NULL
+RETURNING *;
+
+
+-- name: AcceptUserInvite :one
+UPDATE user_invites
+SET
+    accepted_at = now(),
+    updated_at = now()
+WHERE id = $1
+  AND accepted_at IS NULL
+  AND expires_at > now()
+RETURNING *;
+
+
+-- name: ActivateInvitedUser :one
+-- only a pending (inactive) user with this still-open invite, so an active
+-- account's password is never reset through an invite link
+UPDATE users
+SET
+    password = sqlc.arg(password),
+    is_active = true,
+    updated_at = now()
+WHERE users.id = sqlc.arg(id)
+  AND users.is_active = false
+  AND EXISTS (
+    SELECT 1
+    FROM user_invites i
+    WHERE i.id = sqlc.arg(invite_id)
+      AND i.user_id = users.id
+      AND i.accepted_at IS NULL
+      AND i.expires_at > now()
+  )
+RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at;
+
+
+-- name: RevokeUserInvite :one
+-- the pending user never signed in, so it is removed together with the
+-- invite; an account that was activated some other way stays
+DELETE FROM users
+WHERE id = (
+    SELECT i.user_id
+    FROM user_invites i
+    WHERE i.id = $1
+      AND i.institute_id = $2
+      AND i.accepted_at IS NULL
+)
+  AND is_active = false
+RETURNING id;
+
+
+-- name: DeleteOpenUserInvites :exec
+-- a user activated without the invite must not be able to use it later
+DELETE FROM user_invites
+WHERE user_id = $1
+  AND accepted_at IS NULL;
@@ -0,0 +1,39 @@
+-- name: GetLoginThrottles :many
+SELECT *
+FROM login_throttles
+WHERE key = ANY(sqlc.arg(keys)::text[]);
+
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (
+    key,
+    failures,
+    last_failure_at
+) VALUES (
+    sqlc.arg(key), 1, now()
+)
+ON CONFLICT (key) DO UPDATE
+SET
+    failures = CASE
+        WHEN login_throttles.last_failure_at < sqlc.arg(window_start) THEN 1
+        ELSE login_throttles.failures + 1
+    END,
+    last_failure_at = now()
+RETURNING *;
+
+
+-- name: SetLoginBlockedUntil :exec
+UPDATE login_throttles
+SET blocked_until = $2
+WHERE key = $1;
+
+
+-- name: ResetLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
+
+
+-- name: DeleteStaleLoginThrottles :exec
+DELETE FROM login_throttles
+WHERE last_failure_at < sqlc.arg(window_start)
+  AND (blocked_until IS NULL OR blocked_until < now());
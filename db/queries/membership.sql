@@ -0,0 +1,121 @@
+-- name: UpsertInstituteMembership :one
+-- new memberships start pending; a role change keeps accepted_at
+INSERT INTO institute_memberships (
+    user_id,
+    institute_id,
+    role,
+    added_by
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id, institute_id) DO UPDATE
+SET
+    role = EXCLUDED.role,
+    updated_at = now()
+RETURNING *;
+
+
+-- name: GetInstituteMembershipRole :one
+-- memberships of disabled institutes don't count
+SELECT m.role
+FROM institute_memberships m
+JOIN institutes i ON i.id = m.institute_id
+WHERE m.user_id = $1
+  AND m.institute_id = $2
+  AND m.accepted_at IS NOT NULL
+  AND i.is_active = true
+LIMIT 1;
+
+
+-- name: GetUserInstitutes :many
+-- the home institute first, then the memberships
+SELECT
+    i.id AS institute_id,
+    i.code,
+    i.name,
+    COALESCE(u.role, '')::text AS role,
+    true AS home
+FROM users u
+JOIN institutes i ON i.id = u.institute_id
+WHERE u.id = sqlc.arg(user_id)
+  AND i.is_active = true
+UNION ALL
+SELECT
+    i.id AS institute_id,
+    i.code,
+    i.name,
+    m.role,
+    false AS home
+FROM institute_memberships m
+JOIN institutes i ON i.id = m.institute_id
+WHERE m.user_id = sqlc.arg(user_id)
+  AND m.accepted_at IS NOT NULL
+  AND i.is_active = true
+ORDER BY home DESC, name;
+
+
+-- name: GetInstituteMembers :many
+-- pending invitations stay hidden, they would tell which emails exist
+SELECT
+    m.user_id,
+    u.name,
+    u.email,
+    u.is_active,
+    m.role,
+    h.code AS home_institute_code,
+    m.created_at,
+    m.accepted_at
+FROM institute_memberships m
+JOIN users u ON u.id = m.user_id
+LEFT JOIN institutes h ON h.id = u.institute_id
+WHERE m.institute_id = $1
+  AND m.accepted_at IS NOT NULL
+ORDER BY u.name;
+
+
+-- name: DeleteInstituteMembership :execrows
+DELETE FROM institute_memberships
+WHERE user_id = $1
+  AND institute_id = $2;
+
+
+-- name: GetPendingMemberships :many
+SELECT
+    m.institute_id,
+    i.code,
+    i.name,
+    m.role,
+    m.created_at
+FROM institute_memberships m
+JOIN institutes i ON i.id = m.institute_id
+WHERE m.user_id = $1
+  AND m.accepted_at IS NULL
+  AND i.is_active = true
+ORDER BY m.created_at DESC;
+
+
+-- name: AcceptInstituteMembership :execrows
+UPDATE institute_memberships m
+SET
+    accepted_at = now(),
+    updated_at = now()
+FROM institutes i
+WHERE i.id = m.institute_id
+  AND m.user_id = $1
+  AND m.institute_id = $2
+  AND m.accepted_at IS NULL
+  AND i.is_active = true;
+
+
+-- name: DeclineInstituteMembership :execrows
+DELETE FROM institute_memberships
+WHERE user_id = $1
+  AND institute_id = $2
+  AND accepted_at IS NULL;
+
+
+-- name: GetUserByEmailAnyInstitute :one
+SELECT *
+FROM users
+WHERE lower(email) = lower(sqlc.arg(email))
+LIMIT 1;
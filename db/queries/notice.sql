@@ -4,9 +4,12 @@ INSERT INTO notices (
     title,
     description,
     is_published,
-    publish_date
+    publish_date,
+    publish_at,
+    expire_at,
+    status
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8
 )
 RETURNING *;
 
@@ -17,19 +20,24 @@ WHERE id = $1 AND institute_id = $2
 LIMIT 1;
 
 -- name: GetNoticesByInstitute :many
+-- status NULL lists every state
 SELECT *
 FROM notices
-WHERE institute_id = $1
-ORDER BY created_at DESC;
+WHERE institute_id = sqlc.arg(institute_id)
+AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY COALESCE(publish_at, created_at) DESC, id DESC;
 
 -- name: UpdateNotice :one
 UPDATE notices
 SET
-    title = $2,
-    description = $3,
-    is_published = $4,
-    publish_date = $5
-WHERE id = $1
+    title = $3,
+    description = $4,
+    is_published = $5,
+    publish_date = $6,
+    publish_at = $7,
+    expire_at = $8,
+    status = $9
+WHERE id = $1 AND institute_id = $2
 RETURNING *;
 
 -- name: DeleteNotice :exec
@@ -39,6 +47,38 @@ WHERE id = $1;
 -- name: SearchNotices :many
 SELECT *
 FROM notices
+WHERE institute_id = sqlc.arg(institute_id)
+AND title ILIKE '%' || sqlc.arg(search)::text || '%'
+AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY COALESCE(publish_at, created_at) DESC, id DESC;
+
+-- name: AdvanceNoticeStatuses :execrows
+-- scheduled notices go live at publish_at, live ones expire at expire_at
+-- (a scheduled notice whose window already passed goes straight to expired)
+UPDATE notices
+SET status = CASE WHEN expire_at <= now() THEN 'expired' ELSE 'live' END
+WHERE (status = 'scheduled' AND publish_at <= now())
+OR (status = 'live' AND expire_at <= now());
+
+
+-- name: GetPublicNotices :many
+-- live notices for the public feed; the time checks keep the feed exact
+-- between scheduler runs
+SELECT *
+FROM notices
 WHERE institute_id = $1
-AND title ILIKE '%' || $2 || '%'
-ORDER BY created_at DESC;
\ No newline at end of file
+AND status = 'live'
+AND (publish_at IS NULL OR publish_at <= now())
+AND (expire_at IS NULL OR expire_at > now())
+ORDER BY COALESCE(publish_at, created_at) DESC, id DESC
+LIMIT $2;
+
+-- name: GetPublicNotice :one
+SELECT *
+FROM notices
+WHERE id = $1
+AND institute_id = $2
+AND status = 'live'
+AND (publish_at IS NULL OR publish_at <= now())
+AND (expire_at IS NULL OR expire_at > now())
+LIMIT 1;
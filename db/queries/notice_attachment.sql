@@ -0,0 +1,49 @@
+-- name: CreateNoticeAttachment :one
+INSERT INTO notice_attachments (
+    notice_id,
+    institute_id,
+    file_name,
+    content_type,
+    size_bytes,
+    url,
+    public_id,
+    resource_type,
+    uploaded_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetNoticeAttachment :one
+SELECT *
+FROM notice_attachments
+WHERE id = $1 AND notice_id = $2 AND institute_id = $3
+LIMIT 1;
+
+-- name: GetNoticeAttachments :many
+SELECT *
+FROM notice_attachments
+WHERE notice_id = $1
+ORDER BY id;
+
+-- name: GetNoticeAttachmentsByNotices :many
+SELECT *
+FROM notice_attachments
+WHERE notice_id = ANY(sqlc.arg(notice_ids)::int[])
+ORDER BY notice_id, id;
+
+-- name: LockNotice :one
+-- serializes uploads to one notice, so the attachment limit holds
+SELECT id
+FROM notices
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountNoticeAttachments :one
+SELECT COUNT(*)
+FROM notice_attachments
+WHERE notice_id = $1;
+
+-- name: DeleteNoticeAttachment :exec
+DELETE FROM notice_attachments
+WHERE id = $1 AND institute_id = $2;
@@ -0,0 +1,42 @@
+-- name: ReassignUserPhotos :execrows
+UPDATE photos
+SET
+    uploaded_by = sqlc.arg(to_user_id),
+    updated_at = now()
+WHERE uploaded_by = sqlc.arg(from_user_id)
+  AND institute_id = sqlc.arg(institute_id);
+
+
+-- name: AnonymizeUser :one
+-- keeps the row (and its id) but drops everything that identifies the person
+UPDATE users
+SET
+    name = $2,
+    email = $3,
+    password = $4,
+    phone = '',
+    avatar_url = NULL,
+    avatar_public_id = NULL,
+    is_active = false,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, institute_id, name, email, is_active, updated_at;
+
+
+-- name: DeleteUserInvites :exec
+DELETE FROM user_invites
+WHERE user_id = $1;
+
+
+-- name: DeletePasswordHistory :exec
+DELETE FROM user_password_history
+WHERE user_id = $1;
+
+
+-- name: ScrubUserSecurityEvents :exec
+UPDATE security_events
+SET
+    email = '',
+    ip = '',
+    user_agent = ''
+WHERE user_id = $1;
@@ -0,0 +1,104 @@
+-- name: UpsertInstituteOIDCProvider :one
+INSERT INTO institute_oidc_providers (
+    institute_id,
+    issuer_url,
+    client_id,
+    client_secret,
+    scopes,
+    auto_provision,
+    default_role,
+    enabled
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (institute_id) DO UPDATE
+SET
+    issuer_url = EXCLUDED.issuer_url,
+    client_id = EXCLUDED.client_id,
+    client_secret = EXCLUDED.client_secret,
+    scopes = EXCLUDED.scopes,
+    auto_provision = EXCLUDED.auto_provision,
+    default_role = EXCLUDED.default_role,
+    enabled = EXCLUDED.enabled,
+    updated_at = now()
+RETURNING *;
+
+
+-- name: GetInstituteOIDCProvider :one
+SELECT *
+FROM institute_oidc_providers
+WHERE institute_id = $1
+LIMIT 1;
+
+
+-- name: ApproveInstituteOIDCProvider :one
+-- approves the issuer that is configured right now; an admin changing it
+-- afterwards needs a new approval
+UPDATE institute_oidc_providers
+SET
+    approved_issuer_url = issuer_url,
+    allowed_domains = sqlc.arg(allowed_domains),
+    approved_by = sqlc.arg(approved_by),
+    approved_at = now(),
+    updated_at = now()
+WHERE institute_id = sqlc.arg(institute_id)
+  AND issuer_url = sqlc.arg(issuer_url)
+RETURNING *;
+
+
+-- name: DeleteInstituteOIDCProvider :exec
+DELETE FROM institute_oidc_providers
+WHERE institute_id = $1;
+
+
+-- name: CreateOIDCLoginState :exec
+INSERT INTO oidc_login_states (
+    state_hash,
+    institute_id,
+    code_verifier,
+    nonce,
+    expires_at,
+    link_user_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+
+-- name: ConsumeOIDCLoginState :one
+-- single use: the row is removed whether or not the login succeeds
+DELETE FROM oidc_login_states
+WHERE state_hash = $1
+  AND expires_at > now()
+RETURNING *;
+
+
+-- name: DeleteExpiredOIDCLoginStates :exec
+DELETE FROM oidc_login_states
+WHERE expires_at <= now();
+
+
+-- name: GetUserByOIDCIdentity :one
+SELECT u.*
+FROM user_oidc_identities i
+JOIN users u ON u.id = i.user_id
+WHERE i.institute_id = sqlc.arg(institute_id)
+  AND i.issuer_url = sqlc.arg(issuer_url)
+  AND i.subject = sqlc.arg(subject)
+  AND u.institute_id = sqlc.arg(institute_id)
+LIMIT 1;
+
+
+-- name: CreateUserOIDCIdentity :exec
+INSERT INTO user_oidc_identities (
+    institute_id,
+    issuer_url,
+    subject,
+    user_id
+) VALUES (
+    $1, $2, $3, $4
+);
+
+
+-- name: DeleteUserOIDCIdentities :execrows
+DELETE FROM user_oidc_identities
+WHERE user_id = $1;
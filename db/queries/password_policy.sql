@@ -0,0 +1,61 @@
+-- name: GetPasswordPolicy :one
+SELECT *
+FROM institute_password_policies
+WHERE institute_id = $1
+LIMIT 1;
+
+
+-- name: UpsertPasswordPolicy :one
+INSERT INTO institute_password_policies (
+    institute_id,
+    min_length,
+    require_upper,
+    require_lower,
+    require_digit,
+    require_symbol,
+    history_size,
+    check_breached
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (institute_id) DO UPDATE
+SET
+    min_length = EXCLUDED.min_length,
+    require_upper = EXCLUDED.require_upper,
+    require_lower = EXCLUDED.require_lower,
+    require_digit = EXCLUDED.require_digit,
+    require_symbol = EXCLUDED.require_symbol,
+    history_size = EXCLUDED.history_size,
+    check_breached = EXCLUDED.check_breached,
+    updated_at = now()
+RETURNING *;
+
+
+-- name: AddPasswordHistory :exec
+INSERT INTO user_password_history (
+    user_id,
+    password_hash
+) VALUES (
+    $1, $2
+);
+
+
+-- name: GetRecentPasswordHashes :many
+SELECT password_hash
+FROM user_password_history
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
+
+
+-- name: PrunePasswordHistory :exec
+-- keeps only the newest entries
+DELETE FROM user_password_history h
+WHERE h.user_id = sqlc.arg(user_id)
+  AND h.id NOT IN (
+    SELECT r.id
+    FROM user_password_history r
+    WHERE r.user_id = sqlc.arg(user_id)
+    ORDER BY r.created_at DESC, r.id DESC
+    LIMIT sqlc.arg(keep)
+  );
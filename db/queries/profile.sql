@@ -0,0 +1,59 @@
+-- name: GetUserProfile :one
+SELECT
+    id,
+    institute_id,
+    name,
+    email,
+    phone,
+    role,
+    avatar_url,
+    avatar_public_id,
+    created_at,
+    updated_at
+FROM users
+WHERE id = $1
+  AND is_active = true
+LIMIT 1;
+
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+    name = $2,
+    phone = $3,
+    updated_at = now()
+WHERE id = $1
+  AND is_active = true
+RETURNING
+    id,
+    institute_id,
+    name,
+    email,
+    phone,
+    role,
+    avatar_url,
+    avatar_public_id,
+    created_at,
+    updated_at;
+
+
+-- name: SetUserAvatar :one
+-- NULLs remove the avatar
+UPDATE users
+SET
+    avatar_url = $2,
+    avatar_public_id = $3,
+    updated_at = now()
+WHERE id = $1
+  AND is_active = true
+RETURNING
+    id,
+    institute_id,
+    name,
+    email,
+    phone,
+    role,
+    avatar_url,
+    avatar_public_id,
+    created_at,
+    updated_at;
@@ -0,0 +1,28 @@
+-- name: CreateSecurityEvent :exec
+INSERT INTO security_events (
+    user_id,
+    institute_id,
+    event_type,
+    email,
+    ip,
+    user_agent,
+    detail
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+
+-- name: GetUserSecurityEvents :many
+-- newest first; before_id pages back through older events
+SELECT
+    id,
+    event_type,
+    ip,
+    user_agent,
+    detail,
+    created_at
+FROM security_events
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(before_id)::bigint IS NULL OR id < sqlc.narg(before_id)::bigint)
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,43 @@
+-- name: CreateSession :one
+INSERT INTO sessions (
+    user_id,
+    family_id,
+    refresh_token_hash,
+    user_agent,
+    client_ip,
+    expires_at,
+    institute_id,
+    family_created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+
+-- name: GetSessionByTokenHash :one
+SELECT *
+FROM sessions
+WHERE refresh_token_hash = $1
+LIMIT 1;
+
+
+-- name: RotateSession :one
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1
+  AND rotated_at IS NULL
+  AND is_revoked = false
+RETURNING *;
+
+
+-- name: RevokeSessionFamily :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE family_id = $1;
+
+
+-- name: RevokeUserSessions :exec
+UPDATE sessions
+SET is_revoked = true
+WHERE user_id = $1
+  AND is_revoked = false;
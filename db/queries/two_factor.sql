@@ -0,0 +1,119 @@
+-- name: UpsertUserTOTP :one
+INSERT INTO user_totp (
+    user_id,
+    secret
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (user_id) DO UPDATE
+SET
+    secret = EXCLUDED.secret,
+    last_used_step = NULL,
+    created_at = now()
+WHERE user_totp.confirmed_at IS NULL
+RETURNING *;
+
+
+-- name: GetUserTOTP :one
+SELECT *
+FROM user_totp
+WHERE user_id = $1
+LIMIT 1;
+
+
+-- name: ConfirmUserTOTP :one
+UPDATE user_totp
+SET
+    confirmed_at = now(),
+    last_used_step = $2
+WHERE user_id = $1
+  AND confirmed_at IS NULL
+RETURNING *;
+
+
+-- name: UseTOTPStep :one
+UPDATE user_totp
+SET last_used_step = $2
+WHERE user_id = $1
+  AND confirmed_at IS NOT NULL
+  AND (last_used_step IS NULL OR last_used_step < $2)
+RETURNING *;
+
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO user_recovery_codes (
+    user_id,
+    code_hash
+) VALUES (
+    $1, $2
+);
+
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1;
+
+
+-- name: UseRecoveryCode :one
+UPDATE user_recovery_codes
+SET used_at = now()
+WHERE user_id = $1
+  AND code_hash = $2
+  AND used_at IS NULL
+RETURNING *;
+
+
+-- name: CountUnusedRecoveryCodes :one
+SELECT count(*)
+FROM user_recovery_codes
+WHERE user_id = $1
+  AND used_at IS NULL;
+
+
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+    user_id,
+    token_hash,
+    expires_at,
+    institute_id
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+
+-- name: GetLoginChallengeByHash :one
+SELECT *
+FROM login_challenges
+WHERE token_hash = $1
+LIMIT 1;
+
+
+-- name: IncrementLoginChallengeAttempts :one
+-- claims an attempt before the code is checked, so parallel guesses cannot
+-- exceed max_attempts; no row once the challenge is used up
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE id = sqlc.arg(id)
+  AND used_at IS NULL
+  AND expires_at > now()
+  AND attempts < sqlc.arg(max_attempts)::int
+RETURNING *;
+
+
+-- name: UseLoginChallenge :one
+UPDATE login_challenges
+SET used_at = now()
+WHERE id = $1
+  AND used_at IS NULL
+RETURNING *;
+
+
+-- name: DeleteExpiredLoginChallenges :exec
+DELETE FROM login_challenges
+WHERE expires_at < now();
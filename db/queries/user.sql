@@ -9,26 +9,75 @@ LIMIT 1;
 -- name: GetUserByID :one
 SELECT *
 FROM users
+WHERE id = sqlc.arg(id)
+AND institute_id = sqlc.arg(institute_id)::int
+LIMIT 1;
+
+
+-- name: GetUserByIDAnyInstitute :one
+SELECT *
+FROM users
 WHERE id = $1
-AND institute_id = $2
 LIMIT 1;
 
 
 -- name: GetUserByEmail :one
+-- emails match case-insensitively; an exact match wins over legacy rows
+-- that differ only in case
 SELECT *
 FROM users
-WHERE email = $1
-  AND institute_id = $2
+WHERE lower(email) = lower(sqlc.arg(email))
+  AND institute_id = sqlc.arg(institute_id)::int
+ORDER BY email = sqlc.arg(email) DESC
 LIMIT 1;
 
 
 -- name: GetUsersByInstitute :many
 SELECT *
 FROM users
-WHERE institute_id = $1
+WHERE institute_id = sqlc.arg(institute_id)::int
 ORDER BY created_at DESC;
 
 
+-- name: ListUsersByInstitute :many
+-- keyset pagination: the cursor is the sort value and id of the last row seen,
+-- sort is one of created_at, name, email with a leading "-" for descending
+SELECT *
+FROM users
+WHERE institute_id = sqlc.arg(institute_id)::int
+  AND (sqlc.narg(role)::text IS NULL OR role = sqlc.narg(role)::text)
+  AND (sqlc.narg(is_active)::boolean IS NULL OR is_active = sqlc.narg(is_active)::boolean)
+  AND (sqlc.narg(search)::text IS NULL OR name ILIKE sqlc.narg(search)::text OR email ILIKE sqlc.narg(search)::text)
+  AND (
+    sqlc.narg(cursor_id)::int IS NULL
+    OR (sqlc.arg(sort)::text = 'created_at' AND (created_at, id) > (sqlc.narg(cursor_time)::timestamptz, sqlc.narg(cursor_id)::int))
+    OR (sqlc.arg(sort)::text = '-created_at' AND (created_at, id) < (sqlc.narg(cursor_time)::timestamptz, sqlc.narg(cursor_id)::int))
+    OR (sqlc.arg(sort)::text = 'name' AND (name, id) > (sqlc.narg(cursor_text)::text, sqlc.narg(cursor_id)::int))
+    OR (sqlc.arg(sort)::text = '-name' AND (name, id) < (sqlc.narg(cursor_text)::text, sqlc.narg(cursor_id)::int))
+    OR (sqlc.arg(sort)::text = 'email' AND (email, id) > (sqlc.narg(cursor_text)::text, sqlc.narg(cursor_id)::int))
+    OR (sqlc.arg(sort)::text = '-email' AND (email, id) < (sqlc.narg(cursor_text)::text, sqlc.narg(cursor_id)::int))
+  )
+ORDER BY
+    CASE WHEN sqlc.arg(sort)::text = 'created_at' THEN created_at END ASC,
+    CASE WHEN sqlc.arg(sort)::text = '-created_at' THEN created_at END DESC,
+    CASE WHEN sqlc.arg(sort)::text = 'name' THEN name END ASC,
+    CASE WHEN sqlc.arg(sort)::text = '-name' THEN name END DESC,
+    CASE WHEN sqlc.arg(sort)::text = 'email' THEN email END ASC,
+    CASE WHEN sqlc.arg(sort)::text = '-email' THEN email END DESC,
+    CASE WHEN left(sqlc.arg(sort)::text, 1) = '-' THEN id END DESC,
+    id ASC
+LIMIT sqlc.arg(row_limit);
+
+
+-- name: CountUsersByInstitute :one
+SELECT count(*)
+FROM users
+WHERE institute_id = sqlc.arg(institute_id)::int
+  AND (sqlc.narg(role)::text IS NULL OR role = sqlc.narg(role)::text)
+  AND (sqlc.narg(is_active)::boolean IS NULL OR is_active = sqlc.narg(is_active)::boolean)
+  AND (sqlc.narg(search)::text IS NULL OR name ILIKE sqlc.narg(search)::text OR email ILIKE sqlc.narg(search)::text);
+
+
 -- name: CreateUser :one
 INSERT INTO users (
     institute_id,
@@ -38,20 +87,40 @@ INSERT INTO users (
     role,
     is_active
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    sqlc.arg(institute_id)::int,
+    sqlc.arg(name),
+    sqlc.arg(email),
+    sqlc.arg(password),
+    sqlc.arg(role),
+    sqlc.arg(is_active)
+)
+RETURNING *;
+
+
+-- name: CreateSuperAdmin :one
+INSERT INTO users (
+    institute_id,
+    name,
+    email,
+    password,
+    role,
+    is_active
+) VALUES (
+    NULL, $1, $2, $3, 'superadmin', true
 )
+ON CONFLICT (email) DO NOTHING
 RETURNING *;
 
 
 -- name: UpdateUser :one
 UPDATE users
 SET
-    name = $2,
-    role = $3,
-    is_active = $4,
+    name = sqlc.arg(name),
+    role = sqlc.arg(role),
+    is_active = sqlc.arg(is_active),
     updated_at = now()
-WHERE id = $1
-  AND institute_id = $5
+WHERE id = sqlc.arg(id)
+  AND institute_id = sqlc.arg(institute_id)::int
 RETURNING *;
 
 
@@ -59,11 +128,11 @@ RETURNING *;
 -- name: UpdateUserPassword :one
 UPDATE users
 SET
-    password = $1,
+    password = sqlc.arg(password),
     updated_at = now()
 WHERE
-    id = $2
-    AND institute_id = $3
+    id = sqlc.arg(id)
+    AND institute_id IS NOT DISTINCT FROM sqlc.narg(institute_id)
 RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at;
 
 
@@ -73,8 +142,8 @@ SET
     is_active = false,
     updated_at = now()
 WHERE
-    id = $1
-    AND institute_id = $2
+    id = sqlc.arg(id)
+    AND institute_id = sqlc.arg(institute_id)::int
 RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at;
 
 
@@ -82,3 +151,14 @@ RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1;
+
+
+-- name: GetExistingUserEmails :many
+-- emails are unique across institutes and regardless of case, so this is not
+-- institute scoped; returns the lowercased matches
+SELECT lower(email)::text AS email
+FROM users
+WHERE lower(email) = ANY(
+    SELECT lower(e)
+    FROM unnest(sqlc.arg(emails)::text[]) AS e
+);
@@ -0,0 +1,81 @@
+-- name: SetUserActive :one
+UPDATE users
+SET
+    is_active = sqlc.arg(is_active),
+    updated_at = now()
+WHERE
+    id = sqlc.arg(id)
+    AND institute_id = sqlc.arg(institute_id)::int
+RETURNING id, institute_id, name, email, role, is_active, created_at, updated_at;
+
+
+-- name: CreateUserStatusChange :one
+INSERT INTO user_status_changes (
+    user_id,
+    institute_id,
+    is_active,
+    reason,
+    changed_by,
+    effective_at,
+    applied_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+
+-- name: GetUserStatusChanges :many
+SELECT
+    c.id,
+    c.is_active,
+    c.reason,
+    c.changed_by,
+    u.name AS changed_by_name,
+    c.effective_at,
+    c.applied_at,
+    c.canceled_at,
+    c.created_at
+FROM user_status_changes c
+LEFT JOIN users u ON u.id = c.changed_by
+WHERE c.user_id = $1
+  AND c.institute_id = $2
+ORDER BY c.effective_at DESC, c.id DESC;
+
+
+-- name: CancelUserStatusChange :one
+UPDATE user_status_changes
+SET canceled_at = now()
+WHERE id = $1
+  AND user_id = $2
+  AND institute_id = $3
+  AND applied_at IS NULL
+  AND canceled_at IS NULL
+RETURNING *;
+
+
+-- name: CancelPendingUserStatusChanges :execrows
+-- an immediate change overrides whatever was scheduled before it
+UPDATE user_status_changes
+SET canceled_at = now()
+WHERE user_id = $1
+  AND institute_id = $2
+  AND applied_at IS NULL
+  AND canceled_at IS NULL;
+
+
+-- name: GetDueUserStatusChanges :many
+-- locked so that only one replica applies a change
+SELECT *
+FROM user_status_changes
+WHERE applied_at IS NULL
+  AND canceled_at IS NULL
+  AND effective_at <= now()
+ORDER BY effective_at, id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+
+-- name: MarkUserStatusChangeApplied :exec
+UPDATE user_status_changes
+SET applied_at = now()
+WHERE id = $1;
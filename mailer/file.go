@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterMailer writes messages to an io.Writer instead of delivering them.
+// Meant for local development and tests.
+type WriterMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewWriterMailer(w io.Writer, from string) *WriterMailer {
+	return &WriterMailer{w: w, from: from}
+}
+
+func (m *WriterMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.w.Write(buildMessage(m.from, msg)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.w, "\r\n\r\n")
+	return err
+}
+
+// FileMailer appends every message to a file
+type FileMailer struct {
+	mu   sync.Mutex
+	path string
+	from string
+}
+
+func NewFileMailer(path string, from string) *FileMailer {
+	return &FileMailer{path: path, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return NewWriterMailer(f, m.from).Send(ctx, msg)
+}
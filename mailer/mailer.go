@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"dashboard/utils"
+	"fmt"
+	"os"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer picks the mailer implementation configured through MAILER.
+// stdout prints reset and invite tokens to the logs, so it is only the
+// default in development; elsewhere MAILER must be set.
+func NewMailer(config utils.Config) (Mailer, error) {
+	switch config.Mailer {
+	case "":
+		if config.Environment != utils.EnvDevelopment {
+			return nil, fmt.Errorf("MAILER is not set (smtp, file or stdout)")
+		}
+		return NewWriterMailer(os.Stdout, config.MailFrom), nil
+	case "smtp":
+		return NewSMTPMailer(
+			config.SMTPHost,
+			config.SMTPPort,
+			config.SMTPUsername,
+			config.SMTPPassword,
+			config.MailFrom,
+		), nil
+	case "file":
+		if config.MailFile == "" {
+			return nil, fmt.Errorf("MAIL_FILE is required for the file mailer")
+		}
+		return NewFileMailer(config.MailFile, config.MailFrom), nil
+	case "stdout":
+		return NewWriterMailer(os.Stdout, config.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", config.Mailer)
+	}
+}
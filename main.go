@@ -4,10 +4,15 @@ import (
 	"context"
 	"dashboard/api"
 	"dashboard/db/pgdb"
+	"dashboard/mailer"
+	"dashboard/password"
 	"dashboard/token"
 	"dashboard/utils"
+	"errors"
 	"log"
+	_ "time/tzdata" // institute timezones without relying on the host's zoneinfo
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -30,13 +35,24 @@ func main() {
 	store := pgdb.NewStore(dbConn)
 	defer dbConn.Close()
 
+	// platform super admin
+	if err := bootstrapSuperAdmin(context.Background(), store, config); err != nil {
+		log.Fatal("failed to create super admin ", err)
+	}
+
 	// token
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	tokenMaker, err := token.NewMaker(config)
 	if err != nil {
 		log.Fatal("failed to create token maker", err)
 	}
 
-	server, err := api.NewServer(config, store, tokenMaker)
+	// mailer
+	mail, err := mailer.NewMailer(config)
+	if err != nil {
+		log.Fatal("failed to create mailer", err)
+	}
+
+	server, err := api.NewServer(config, store, tokenMaker, mail)
 	if err != nil {
 		log.Fatal("cannot start server", err)
 	}
@@ -46,3 +62,31 @@ func main() {
 		log.Fatal("connot start server", err)
 	}
 }
+
+// bootstrapSuperAdmin creates the platform super admin from SUPERADMIN_* env
+// on first start. An existing account with the same email is left untouched.
+func bootstrapSuperAdmin(ctx context.Context, store pgdb.Store, config utils.Config) error {
+	if config.SuperAdminEmail == "" || config.SuperAdminPassword == "" {
+		return nil
+	}
+
+	hashedPassword, err := password.HashPassword(config.SuperAdminPassword)
+	if err != nil {
+		return err
+	}
+
+	name := config.SuperAdminName
+	if name == "" {
+		name = "Super Admin"
+	}
+
+	_, err = store.CreateSuperAdmin(ctx, pgdb.CreateSuperAdminParams{
+		Name:     name,
+		Email:    config.SuperAdminEmail,
+		Password: hashedPassword,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	return nil
+}
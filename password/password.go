@@ -0,0 +1,113 @@
+package password
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"dashboard/token"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters (OWASP recommended minimum)
+const (
+	argonTime    uint32 = 2
+	argonMemory  uint32 = 19 * 1024
+	argonThreads uint8  = 1
+	argonKeyLen  uint32 = 32
+	argonSaltLen        = 16
+
+	hashPrefix = "$argon2id$"
+
+	// bounds for parameters read from stored hashes; a broken row must not
+	// panic argon2 (t or p of 0) or allocate without limit (huge m)
+	maxArgonTime    uint32 = 10
+	maxArgonMemory  uint32 = 256 * 1024
+	maxArgonThreads uint8  = 16
+	minArgonKeyLen         = 16
+	maxArgonKeyLen         = 64
+)
+
+var (
+	ErrMismatchedPassword = errors.New("password does not match")
+	ErrInvalidHash        = errors.New("invalid password hash format")
+)
+
+// HashPassword returns an encoded argon2id hash in the PHC string format:
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>
+func HashPassword(password string) (string, error) {
+	salt, err := token.GenerateRandomBytes(argonSaltLen)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		hashPrefix,
+		argon2.Version,
+		argonMemory,
+		argonTime,
+		argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// CheckPassword verifies password against a stored value in constant time.
+// Stored values that are not argon2id hashes are treated as legacy plaintext,
+// in which case needsRehash is true on success so the caller can upgrade the row.
+func CheckPassword(password string, stored string) (needsRehash bool, err error) {
+	if !IsHashed(stored) {
+		if subtle.ConstantTimeCompare([]byte(password), []byte(stored)) != 1 {
+			return false, ErrMismatchedPassword
+		}
+		return true, nil
+	}
+
+	var version int
+	var memory, iterations uint32
+	var threads uint8
+
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false, ErrInvalidHash
+	}
+	if iterations < 1 || iterations > maxArgonTime ||
+		threads < 1 || threads > maxArgonThreads ||
+		memory < 8*uint32(threads) || memory > maxArgonMemory {
+		return false, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, ErrInvalidHash
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(expected) < minArgonKeyLen || len(expected) > maxArgonKeyLen {
+		return false, ErrInvalidHash
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return false, ErrMismatchedPassword
+	}
+
+	// upgrade hashes created with older parameters
+	needsRehash = memory != argonMemory || iterations != argonTime || threads != argonThreads
+	return needsRehash, nil
+}
+
+func IsHashed(stored string) bool {
+	return strings.HasPrefix(stored, hashPrefix)
+}
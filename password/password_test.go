@@ -0,0 +1,132 @@
+package password
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	if !IsHashed(hash) {
+		t.Fatalf("hash %q has no argon2id prefix", hash)
+	}
+	if want := fmt.Sprintf("$argon2id$v=19$m=%d,t=%d,p=%d$", argonMemory, argonTime, argonThreads); !strings.HasPrefix(hash, want) {
+		t.Fatalf("hash %q does not start with %q", hash, want)
+	}
+
+	needsRehash, err := CheckPassword("correct horse", hash)
+	if err != nil {
+		t.Fatalf("CheckPassword: %v", err)
+	}
+	if needsRehash {
+		t.Fatal("fresh hash should not need a rehash")
+	}
+
+	if _, err := CheckPassword("wrong horse", hash); !errors.Is(err, ErrMismatchedPassword) {
+		t.Fatalf("wrong password: got %v, want ErrMismatchedPassword", err)
+	}
+}
+
+func TestHashPasswordSalted(t *testing.T) {
+	first, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("two hashes of the same password are equal, salt is not random")
+	}
+}
+
+// hashWith builds a PHC string with custom parameters, like rows hashed
+// before the parameters were raised
+func hashWith(password string, memory, iterations uint32, threads uint8) string {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, threads, argonKeyLen)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, memory, iterations, threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func TestCheckPasswordRehash(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      string
+		needsRehash bool
+	}{
+		{"current parameters", hashWith("secret", argonMemory, argonTime, argonThreads), false},
+		{"older memory", hashWith("secret", 8*1024, argonTime, argonThreads), true},
+		{"older iterations", hashWith("secret", argonMemory, 1, argonThreads), true},
+		{"other threads", hashWith("secret", argonMemory, argonTime, 2), true},
+		{"legacy plaintext", "secret", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			needsRehash, err := CheckPassword("secret", tt.stored)
+			if err != nil {
+				t.Fatalf("CheckPassword: %v", err)
+			}
+			if needsRehash != tt.needsRehash {
+				t.Fatalf("needsRehash = %v, want %v", needsRehash, tt.needsRehash)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordTampered(t *testing.T) {
+	valid := hashWith("secret", argonMemory, argonTime, argonThreads)
+	parts := strings.Split(valid, "$")
+
+	replace := func(i int, value string) string {
+		tampered := append([]string(nil), parts...)
+		tampered[i] = value
+		return strings.Join(tampered, "$")
+	}
+	otherKey := base64.RawStdEncoding.EncodeToString(make([]byte, argonKeyLen))
+
+	tests := []struct {
+		name   string
+		stored string
+		want   error
+	}{
+		{"changed key", replace(5, otherKey), ErrMismatchedPassword},
+		{"changed salt", replace(4, base64.RawStdEncoding.EncodeToString([]byte("fedcba9876543210"))), ErrMismatchedPassword},
+		{"changed memory", replace(3, fmt.Sprintf("m=%d,t=%d,p=%d", argonMemory+1, argonTime, argonThreads)), ErrMismatchedPassword},
+		{"wrong version", replace(2, "v=16"), ErrInvalidHash},
+		{"bad parameters", replace(3, "m=x,t=2,p=1"), ErrInvalidHash},
+		{"bad salt encoding", replace(4, "!!!"), ErrInvalidHash},
+		{"bad key encoding", replace(5, "!!!"), ErrInvalidHash},
+		{"missing part", strings.Join(parts[:5], "$"), ErrInvalidHash},
+		{"zero iterations", replace(3, fmt.Sprintf("m=%d,t=0,p=%d", argonMemory, argonThreads)), ErrInvalidHash},
+		{"zero threads", replace(3, fmt.Sprintf("m=%d,t=%d,p=0", argonMemory, argonTime)), ErrInvalidHash},
+		{"huge memory", replace(3, fmt.Sprintf("m=4294967295,t=%d,p=%d", argonTime, argonThreads)), ErrInvalidHash},
+		{"too many iterations", replace(3, fmt.Sprintf("m=%d,t=1000000,p=%d", argonMemory, argonThreads)), ErrInvalidHash},
+		{"memory below threads", replace(3, fmt.Sprintf("m=8,t=%d,p=2", argonTime)), ErrInvalidHash},
+		{"empty key", replace(5, ""), ErrInvalidHash},
+		{"legacy plaintext mismatch", "other", ErrMismatchedPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CheckPassword("secret", tt.stored); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+	"unicode"
+)
+
+// Policy is an institute's password policy
+type Policy struct {
+	MinLength     int  `json:"min_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	HistorySize   int  `json:"history_size"`
+	CheckBreached bool `json:"check_breached"`
+}
+
+// MinLength is the shortest password any policy may allow
+const MinLength = 6
+
+// DefaultPolicy applies to institutes that have not configured one
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:     MinLength,
+		CheckBreached: true,
+	}
+}
+
+// MinimumPolicy is the floor of every institute policy. It applies whenever
+// a password is checked before (or without) its institute's policy.
+func MinimumPolicy() Policy {
+	return Policy{MinLength: MinLength}
+}
+
+// LongEnough reports whether password has at least MinLength characters
+func (p Policy) LongEnough(password string) bool {
+	return len([]rune(password)) >= p.MinLength
+}
+
+// HasRequiredClasses reports whether password contains every required
+// character class
+func (p Policy) HasRequiredClasses(password string) bool {
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	return (!p.RequireUpper || upper) &&
+		(!p.RequireLower || lower) &&
+		(!p.RequireDigit || digit) &&
+		(!p.RequireSymbol || symbol)
+}
+
+// IsReused reports whether password matches any of the given hashes
+func IsReused(password string, hashes []string) bool {
+	for _, hash := range hashes {
+		if _, err := CheckPassword(password, hash); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+//go:embed breached.txt
+var breachedList string
+
+var breached = loadBreached(breachedList)
+
+func loadBreached(list string) map[string]struct{} {
+	set := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set[strings.ToLower(line)] = struct{}{}
+	}
+	return set
+}
+
+// IsBreached reports whether password is on the bundled breached list
+func IsBreached(password string) bool {
+	_, ok := breached[strings.ToLower(password)]
+	return ok
+}
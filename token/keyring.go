@@ -0,0 +1,89 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// ErrUnknownKeyID is returned for tokens signed with a key that is not (or no
+// longer) in the keyring
+var ErrUnknownKeyID = errors.New("token signed with unknown key id")
+
+type tokenFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// keyring holds every key we still accept plus the one used for new tokens.
+// Tokens carry the signing key ID in their footer; tokens without a footer
+// (issued before key IDs existed) are matched against the "" key ID.
+type keyring[K any] struct {
+	signingKeyID string
+	keys         map[string]K
+}
+
+func newKeyring[K any](keys map[string]string, signingKeyID string, parse func(string) (K, error)) (keyring[K], error) {
+	ring := keyring[K]{
+		signingKeyID: signingKeyID,
+		keys:         make(map[string]K, len(keys)),
+	}
+	for keyID, value := range keys {
+		key, err := parse(value)
+		if err != nil {
+			return keyring[K]{}, fmt.Errorf("token key %q: %w", keyID, err)
+		}
+		ring.keys[keyID] = key
+	}
+	if _, ok := ring.keys[signingKeyID]; !ok {
+		return keyring[K]{}, fmt.Errorf("signing key id %q is not in the keyring", signingKeyID)
+	}
+	return ring, nil
+}
+
+func (ring keyring[K]) signingKey() K {
+	return ring.keys[ring.signingKeyID]
+}
+
+func (ring keyring[K]) footer() []byte {
+	if ring.signingKeyID == "" {
+		return nil
+	}
+	footer, _ := json.Marshal(tokenFooter{KeyID: ring.signingKeyID})
+	return footer
+}
+
+// verificationKey picks the key named in the (not yet authenticated) footer.
+// The footer is authenticated when the token is parsed with that key.
+func (ring keyring[K]) verificationKey(protocol paseto.Protocol, tokenString string) (K, error) {
+	var zero K
+
+	footer, err := paseto.NewParser().UnsafeParseFooter(protocol, tokenString)
+	if err != nil {
+		return zero, err
+	}
+
+	var f tokenFooter
+	if len(footer) > 0 {
+		if err := json.Unmarshal(footer, &f); err != nil {
+			return zero, fmt.Errorf("invalid token footer: %w", err)
+		}
+	}
+
+	key, ok := ring.keys[f.KeyID]
+	if !ok {
+		return zero, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+func (ring keyring[K]) keyIDs() []string {
+	ids := make([]string, 0, len(ring.keys))
+	for keyID := range ring.keys {
+		ids = append(ids, keyID)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,177 @@
+package token
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	keyA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	keyB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	keyC = "cccccccccccccccccccccccccccccccc"
+
+	seedA = "1111111111111111111111111111111111111111111111111111111111111111"
+	seedB = "2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// mustMaker unwraps a constructor in table setups; the keys are fixed, so an
+// error is a bug in the test
+func mustMaker(maker Maker, err error) Maker {
+	if err != nil {
+		panic(err)
+	}
+	return maker
+}
+
+func mustToken(t *testing.T, maker Maker) string {
+	t.Helper()
+	token, _, err := maker.CreateToken(7, "user@example.com", "admin", "User", 3, time.Minute)
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	return token
+}
+
+// withFooter swaps the footer of a PASETO token without re-encrypting it
+func withFooter(token string, footer string) string {
+	parts := strings.Split(token, ".")
+	parts = append(parts[:3], base64.RawURLEncoding.EncodeToString([]byte(footer)))
+	return strings.Join(parts, ".")
+}
+
+// flipPayload changes one character in the body of a PASETO token
+func flipPayload(token string) string {
+	parts := strings.Split(token, ".")
+	body := []byte(parts[2])
+	i := len(body) / 2
+	if body[i] == 'A' {
+		body[i] = 'B'
+	} else {
+		body[i] = 'A'
+	}
+	parts[2] = string(body)
+	return strings.Join(parts, ".")
+}
+
+func TestPasetoKeyringRoundTrip(t *testing.T) {
+	maker := mustMaker(NewPasetoKeyringMaker(map[string]string{"a": keyA, "b": keyB}, "b"))
+
+	token, created, err := maker.CreateToken(7, "user@example.com", "admin", "User", 3, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := maker.VerifyToken(token)
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if payload.TokenID != created.TokenID || payload.ID != 7 || payload.Email != "user@example.com" ||
+		payload.Role != "admin" || payload.InstituteID != 3 {
+		t.Fatalf("payload = %+v, want %+v", payload, created)
+	}
+	if !payload.IssuedAt.Equal(created.IssuedAt) {
+		t.Fatalf("IssuedAt = %v, want %v", payload.IssuedAt, created.IssuedAt)
+	}
+}
+
+func TestPasetoKeyringLookup(t *testing.T) {
+	legacy := mustMaker(NewPasetoMaker(keyA))
+	oldKey := mustMaker(NewPasetoKeyringMaker(map[string]string{"a": keyA}, "a"))
+	rotated := mustMaker(NewPasetoKeyringMaker(map[string]string{"": keyA, "a": keyA, "b": keyB}, "b"))
+	retired := mustMaker(NewPasetoKeyringMaker(map[string]string{"b": keyB}, "b"))
+
+	tests := []struct {
+		name    string
+		token   string
+		maker   Maker
+		wantErr error // nil: must verify; errAny: any error
+	}{
+		{"legacy token without footer", mustToken(t, legacy), rotated, nil},
+		{"token of a previous key", mustToken(t, oldKey), rotated, nil},
+		{"token of the signing key", mustToken(t, rotated), rotated, nil},
+		{"token of a retired key", mustToken(t, oldKey), retired, ErrUnknownKeyID},
+		{"legacy token after the legacy key is gone", mustToken(t, legacy), retired, ErrUnknownKeyID},
+		{"unknown key id", withFooter(mustToken(t, oldKey), `{"kid":"z"}`), rotated, ErrUnknownKeyID},
+		{"footer pointing at another key", withFooter(mustToken(t, oldKey), `{"kid":"b"}`), rotated, errAny},
+		{"footer that is not JSON", withFooter(mustToken(t, oldKey), "kid"), rotated, errAny},
+		{"tampered body", flipPayload(mustToken(t, rotated)), rotated, errAny},
+		{"same key id, other key", mustToken(t, mustMaker(NewPasetoKeyringMaker(map[string]string{"b": keyC}, "b"))), rotated, errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.maker.VerifyToken(tt.token)
+			checkVerifyErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPublicPasetoKeyringLookup(t *testing.T) {
+	oldKey := mustMaker(NewPublicPasetoKeyringMaker(map[string]string{"a": seedA}, "a"))
+	rotated := mustMaker(NewPublicPasetoKeyringMaker(map[string]string{"a": seedA, "b": seedB}, "b"))
+	retired := mustMaker(NewPublicPasetoKeyringMaker(map[string]string{"b": seedB}, "b"))
+
+	tests := []struct {
+		name    string
+		token   string
+		maker   Maker
+		wantErr error
+	}{
+		{"token of a previous key", mustToken(t, oldKey), rotated, nil},
+		{"token of the signing key", mustToken(t, rotated), rotated, nil},
+		{"token of a retired key", mustToken(t, oldKey), retired, ErrUnknownKeyID},
+		{"footer pointing at another key", withFooter(mustToken(t, oldKey), `{"kid":"b"}`), rotated, errAny},
+		{"tampered body", flipPayload(mustToken(t, rotated)), rotated, errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.maker.VerifyToken(tt.token)
+			checkVerifyErr(t, err, tt.wantErr)
+		})
+	}
+
+	keys := rotated.(PublicKeySource).PublicKeys()
+	if len(keys) != 2 || keys[0].KeyID != "a" || keys[1].KeyID != "b" {
+		t.Fatalf("PublicKeys = %+v, want keys a and b", keys)
+	}
+}
+
+func TestNewKeyringErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"signing key id missing", second(NewPasetoKeyringMaker(map[string]string{"a": keyA}, "b"))},
+		{"short symmetric key", second(NewPasetoKeyringMaker(map[string]string{"a": "short"}, "a"))},
+		{"short ed25519 key", second(NewPublicPasetoKeyringMaker(map[string]string{"a": "abcd"}, "a"))},
+	}
+
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// errAny marks test cases that only need VerifyToken to fail
+var errAny = errors.New("any error")
+
+func checkVerifyErr(t *testing.T, err error, want error) {
+	t.Helper()
+	switch {
+	case want == nil && err != nil:
+		t.Fatalf("VerifyToken: %v", err)
+	case want == errAny && err == nil:
+		t.Fatal("VerifyToken accepted the token")
+	case want != nil && want != errAny && !errors.Is(err, want):
+		t.Fatalf("VerifyToken: got %v, want %v", err, want)
+	}
+}
+
+func second(_ Maker, err error) error {
+	return err
+}
@@ -1,7 +1,11 @@
 package token
 
 import (
+	"dashboard/utils"
+	"fmt"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Maker interface {
@@ -17,7 +21,24 @@ type Maker interface {
 	VerifyToken(token string) (*TokenPayload, error)
 }
 
+// NewMaker picks the token maker configured through TOKEN_MAKER.
+//
+// Keys rotate without downtime in three rolling deploys: add the new key to
+// TOKEN_*_KEYS, then point TOKEN_SIGNING_KEY_ID at it, then drop the old key
+// once TOKEN_DURATION has passed.
+func NewMaker(config utils.Config) (Maker, error) {
+	switch config.TokenMaker {
+	case "", "local":
+		return NewPasetoKeyringMaker(config.TokenSymmetricKeys, config.TokenSigningKeyID)
+	case "public":
+		return NewPublicPasetoKeyringMaker(config.TokenAsymmetricKeys, config.TokenSigningKeyID)
+	default:
+		return nil, fmt.Errorf("unknown token maker %q", config.TokenMaker)
+	}
+}
+
 type TokenPayload struct {
+	TokenID     string    `json:"jti"`
 	ID          int64     `json:"id"`
 	Email       string    `json:"email"`
 	Role        string    `json:"role"`
@@ -25,10 +46,14 @@ type TokenPayload struct {
 	InstituteID int32     `json:"institute_id"`
 	ExpiredAt   time.Time `json:"expired_at"`
 	Name        string    `json:"name"`
+	// set only for requests authenticated with an API key
+	APIKeyID int32    `json:"api_key_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
 }
 
 func NewTokenPayload(id int64, email string, role string, name string, duration time.Duration) (*TokenPayload, error) {
 	return &TokenPayload{
+		TokenID:   uuid.NewString(),
 		ID:        id,
 		Email:     email,
 		Role:      role,
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
 )
 
 const (
@@ -11,17 +12,24 @@ const (
 )
 
 type PasetoMaker struct {
-	symmetricKey paseto.V4SymmetricKey
+	keys keyring[paseto.V4SymmetricKey]
 }
 
 func NewPasetoMaker(secretKey string) (Maker, error) {
-	keyBytes := []byte(secretKey)
-	v4SymmetricKey, err := paseto.V4SymmetricKeyFromBytes(keyBytes)
+	return NewPasetoKeyringMaker(map[string]string{"": secretKey}, "")
+}
+
+// NewPasetoKeyringMaker accepts every symmetric key (by key ID) that may still
+// verify tokens; new tokens are encrypted with signingKeyID
+func NewPasetoKeyringMaker(keys map[string]string, signingKeyID string) (Maker, error) {
+	ring, err := newKeyring(keys, signingKeyID, func(secretKey string) (paseto.V4SymmetricKey, error) {
+		return paseto.V4SymmetricKeyFromBytes([]byte(secretKey))
+	})
 	if err != nil {
 		return nil, err
 	}
 	maker := &PasetoMaker{
-		symmetricKey: v4SymmetricKey,
+		keys: ring,
 	}
 	return maker, nil
 }
@@ -35,7 +43,39 @@ func (maker *PasetoMaker) CreateToken(
 	duration time.Duration,
 ) (string, *TokenPayload, error) {
 
+	t, payload := newPasetoToken(id, email, role, name, instituteID, duration)
+	t.SetFooter(maker.keys.footer())
+
+	token := t.V4Encrypt(maker.keys.signingKey(), nil)
+	return token, payload, nil
+}
+
+func (maker *PasetoMaker) VerifyToken(tokenString string) (*TokenPayload, error) {
+	key, err := maker.keys.verificationKey(paseto.V4Local, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := paseto.NewParser()
+	t, err := parser.ParseV4Local(key, tokenString, nil)
+	if err != nil {
+		return nil, err
+	}
+	return payloadFromPaseto(t), nil
+}
+
+// newPasetoToken builds the claims shared by the local and public makers
+func newPasetoToken(
+	id int64,
+	email string,
+	role string,
+	name string,
+	instituteID int32,
+	duration time.Duration,
+) (paseto.Token, *TokenPayload) {
+
 	payload := &TokenPayload{
+		TokenID:     uuid.NewString(),
 		ID:          id,
 		Email:       email,
 		Role:        role,
@@ -46,23 +86,24 @@ func (maker *PasetoMaker) CreateToken(
 	}
 
 	t := paseto.NewToken()
+	t.SetJti(payload.TokenID)
 	t.SetIssuedAt(payload.IssuedAt)
 	t.SetExpiration(payload.ExpiredAt)
 	t.Set(PayloadKey, payload)
 
-	token := t.V4Encrypt(maker.symmetricKey, nil)
-	return token, payload, nil
+	return t, payload
 }
 
-func (maker *PasetoMaker) VerifyToken(tokenString string) (*TokenPayload, error) {
-	parser := paseto.NewParser()
-	t, err := parser.ParseV4Local(maker.symmetricKey, tokenString, nil)
-	if err != nil {
-		return nil, err
-	}
+func payloadFromPaseto(t *paseto.Token) *TokenPayload {
 	payload := TokenPayload{}
 	t.Get(PayloadKey, &payload)
 	payload.ExpiredAt, _ = t.GetExpiration()
-	payload.IssuedAt, _ = t.GetIssuedAt()
-	return &payload, nil
+	// the payload keeps sub-second precision, which revocation cutoffs rely on
+	if payload.IssuedAt.IsZero() {
+		payload.IssuedAt, _ = t.GetIssuedAt()
+	}
+	if payload.TokenID == "" {
+		payload.TokenID, _ = t.GetJti()
+	}
+	return &payload
 }
@@ -0,0 +1,108 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// PublicKey is a verification key that other services can fetch to check
+// our tokens without holding any signing material
+type PublicKey struct {
+	KeyID     string `json:"kid,omitempty"`
+	Version   string `json:"version"`
+	Purpose   string `json:"purpose"`
+	PublicKey string `json:"public_key"`
+}
+
+// PublicKeySource is implemented by makers whose tokens can be verified with
+// a published key
+type PublicKeySource interface {
+	PublicKeys() []PublicKey
+}
+
+// PublicPasetoMaker signs v4.public tokens with an Ed25519 key
+type PublicPasetoMaker struct {
+	keys keyring[paseto.V4AsymmetricSecretKey]
+}
+
+// NewPublicPasetoMaker accepts a hex encoded Ed25519 private key, either the
+// 32 byte seed or the 64 byte seed+public key form
+func NewPublicPasetoMaker(secretKeyHex string) (Maker, error) {
+	return NewPublicPasetoKeyringMaker(map[string]string{"": secretKeyHex}, "")
+}
+
+// NewPublicPasetoKeyringMaker accepts every Ed25519 key (by key ID) whose
+// tokens may still be verified; new tokens are signed with signingKeyID
+func NewPublicPasetoKeyringMaker(keys map[string]string, signingKeyID string) (Maker, error) {
+	ring, err := newKeyring(keys, signingKeyID, parseAsymmetricSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	maker := &PublicPasetoMaker{
+		keys: ring,
+	}
+	return maker, nil
+}
+
+func (maker *PublicPasetoMaker) CreateToken(
+	id int64,
+	email string,
+	role string,
+	name string,
+	instituteID int32,
+	duration time.Duration,
+) (string, *TokenPayload, error) {
+
+	t, payload := newPasetoToken(id, email, role, name, instituteID, duration)
+	t.SetFooter(maker.keys.footer())
+
+	token := t.V4Sign(maker.keys.signingKey(), nil)
+	return token, payload, nil
+}
+
+func (maker *PublicPasetoMaker) VerifyToken(tokenString string) (*TokenPayload, error) {
+	secretKey, err := maker.keys.verificationKey(paseto.V4Public, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := paseto.NewParser()
+	t, err := parser.ParseV4Public(secretKey.Public(), tokenString, nil)
+	if err != nil {
+		return nil, err
+	}
+	return payloadFromPaseto(t), nil
+}
+
+// PublicKeys lists every key that still verifies tokens, so clients keep
+// accepting tokens signed before a rotation
+func (maker *PublicPasetoMaker) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(maker.keys.keys))
+	for _, keyID := range maker.keys.keyIDs() {
+		keys = append(keys, PublicKey{
+			KeyID:     keyID,
+			Version:   "v4",
+			Purpose:   "public",
+			PublicKey: maker.keys.keys[keyID].Public().ExportHex(),
+		})
+	}
+	return keys
+}
+
+func parseAsymmetricSecretKey(secretKeyHex string) (paseto.V4AsymmetricSecretKey, error) {
+	secretKeyHex = strings.TrimSpace(secretKeyHex)
+	switch len(secretKeyHex) {
+	case 64:
+		return paseto.NewV4AsymmetricSecretKeyFromSeed(secretKeyHex)
+	case 128:
+		return paseto.NewV4AsymmetricSecretKeyFromHex(secretKeyHex)
+	default:
+		return paseto.V4AsymmetricSecretKey{}, fmt.Errorf(
+			"ed25519 key must be 32 (seed) or 64 bytes hex encoded, got %d hex chars",
+			len(secretKeyHex),
+		)
+	}
+}
@@ -46,12 +46,10 @@ func GenerateRandomStringURLSafe(n int) (string, error) {
 
 func GenerateTokenAndHash(size int) (string, string, error) {
 	token, err := GenerateRandomStringURLSafe(size)
-	hashed := fmt.Sprintf("%x", sha256.Sum256([]byte(token)))
 	if err != nil {
 		return "", "", err
 	}
-	fmt.Println(token)
-	return token, hashed, nil
+	return token, GetTokenHash(token), nil
 }
 
 func GetTokenHash(token string) string {
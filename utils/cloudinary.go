@@ -6,20 +6,25 @@ import (
 	"os"
 
 	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
+func newCloudinary() (*cloudinary.Cloudinary, error) {
+	return cloudinary.NewFromParams(
+		os.Getenv("CLOUDINARY_CLOUD_NAME"),
+		os.Getenv("CLOUDINARY_API_KEY"),
+		os.Getenv("CLOUDINARY_API_SECRET"),
+	)
+}
+
 func UploadImageStream(
 	ctx context.Context,
 	file io.Reader,
 	folder string,
 ) (string, string, error) {
 
-	cld, err := cloudinary.NewFromParams(
-		os.Getenv("CLOUDINARY_CLOUD_NAME"),
-		os.Getenv("CLOUDINARY_API_KEY"),
-		os.Getenv("CLOUDINARY_API_SECRET"),
-	)
+	cld, err := newCloudinary()
 	if err != nil {
 		return "", "", err
 	}
@@ -38,12 +43,46 @@ func UploadImageStream(
 	return resp.SecureURL, resp.PublicID, nil
 }
 
-func DeleteImage(ctx context.Context, publicID string) error {
-	cld, err := cloudinary.NewFromParams(
-		os.Getenv("CLOUDINARY_CLOUD_NAME"),
-		os.Getenv("CLOUDINARY_API_KEY"),
-		os.Getenv("CLOUDINARY_API_SECRET"),
+// UploadFileStream uploads any file as resourceType ("image" or "raw"),
+// keeping fileName (with a unique suffix) so downloads get a sensible name
+func UploadFileStream(
+	ctx context.Context,
+	file io.Reader,
+	folder string,
+	fileName string,
+	resourceType string,
+) (string, string, error) {
+
+	cld, err := newCloudinary()
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := cld.Upload.Upload(
+		ctx,
+		file,
+		uploader.UploadParams{
+			Folder:           folder,
+			ResourceType:     resourceType,
+			FilenameOverride: fileName,
+			UseFilename:      api.Bool(true),
+			UniqueFilename:   api.Bool(true),
+		},
 	)
+	if err != nil {
+		return "", "", err
+	}
+
+	return resp.SecureURL, resp.PublicID, nil
+}
+
+func DeleteImage(ctx context.Context, publicID string) error {
+	return DeleteFile(ctx, publicID, "image")
+}
+
+// DeleteFile removes an asset; resourceType must match the upload
+func DeleteFile(ctx context.Context, publicID string, resourceType string) error {
+	cld, err := newCloudinary()
 	if err != nil {
 		return err
 	}
@@ -51,7 +90,8 @@ func DeleteImage(ctx context.Context, publicID string) error {
 	_, err = cld.Upload.Destroy(
 		ctx,
 		uploader.DestroyParams{
-			PublicID: publicID,
+			PublicID:     publicID,
+			ResourceType: resourceType,
 		},
 	)
 	return err
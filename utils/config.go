@@ -1,19 +1,82 @@
 package utils
 
 import (
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// EnvDevelopment is the APP_ENV of a local setup; conveniences such as
+// printing mails to stdout are only defaulted there
+const EnvDevelopment = "development"
+
 type Config struct {
-	DatabaseURL       string
-	TokenSymmetricKey string
-	Port              int16
-	TokenDuration     time.Duration
-	ProfilesFolder    string
+	Environment          string
+	DatabaseURL          string
+	TokenSymmetricKey    string
+	Port                 int16
+	TokenDuration        time.Duration
+	RefreshTokenDuration time.Duration
+	SessionMaxLifetime   time.Duration // absolute limit for a refresh token family
+	RevocationCacheTTL   time.Duration
+	ProfilesFolder       string
+	AttachmentsFolder    string
+
+	// scheme and host the API is reached at from outside, e.g.
+	// https://api.example.edu; absolute links in public feeds use it
+	PublicBaseURL string
+
+	// Key rotation: every key (by key ID) that still verifies tokens, and the
+	// ID that signs new ones. TOKEN_SYMMETRIC_KEY / TOKEN_ASYMMETRIC_KEY are
+	// kept under the "" key ID so tokens issued before key IDs keep working.
+	TokenMaker          string
+	TokenAsymmetricKey  string
+	TokenSymmetricKeys  map[string]string
+	TokenAsymmetricKeys map[string]string
+	TokenSigningKeyID   string
+
+	PasswordResetDuration time.Duration
+	PasswordResetURL      string
+
+	InviteDuration time.Duration
+	InviteURL      string
+
+	EmailChangeDuration time.Duration
+	EmailChangeURL      string
+
+	Mailer       string
+	MailFrom     string
+	MailFile     string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginFailureWindow   time.Duration
+	LoginBackoffBase     time.Duration
+	LoginBackoffMax      time.Duration
+	LoginLockoutDuration time.Duration
+
+	TOTPIssuer             string
+	LoginChallengeDuration time.Duration
+
+	OIDCRedirectURL   string
+	OIDCStateDuration time.Duration
+
+	// Client IPs (login throttling, security events) are read from
+	// ProxyHeader only on requests coming from one of TrustedProxies
+	TrustedProxies []string
+	ProxyHeader    string
+
+	SuperAdminName     string
+	SuperAdminEmail    string
+	SuperAdminPassword string
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -26,6 +89,60 @@ func LoadConfig(path string) (Config, error) {
 		tokenDuration = time.Hour // default value if not set or invalid
 	}
 
+	refreshTokenDurationStr := os.Getenv("REFRESH_TOKEN_DURATION")
+	refreshTokenDuration, err := time.ParseDuration(refreshTokenDurationStr)
+	if err != nil || refreshTokenDurationStr == "" {
+		refreshTokenDuration = 7 * 24 * time.Hour // default value if not set or invalid
+	}
+
+	revocationCacheTTLStr := os.Getenv("REVOCATION_CACHE_TTL")
+	revocationCacheTTL, err := time.ParseDuration(revocationCacheTTLStr)
+	if err != nil || revocationCacheTTLStr == "" {
+		revocationCacheTTL = 30 * time.Second // default value if not set or invalid
+	}
+
+	passwordResetDurationStr := os.Getenv("PASSWORD_RESET_DURATION")
+	passwordResetDuration, err := time.ParseDuration(passwordResetDurationStr)
+	if err != nil || passwordResetDurationStr == "" {
+		passwordResetDuration = 30 * time.Minute // default value if not set or invalid
+	}
+
+	smtpPortStr := os.Getenv("SMTP_PORT")
+	smtpPort, err := strconv.Atoi(smtpPortStr)
+	if err != nil || smtpPortStr == "" {
+		smtpPort = 587 // default port if not set or invalid
+	}
+
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "no-reply@localhost"
+	}
+
+	loginMaxFailures := envInt("LOGIN_MAX_FAILURES", 5)
+	loginIPMaxFailures := envInt("LOGIN_IP_MAX_FAILURES", 20)
+	loginFailureWindow := envDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute)
+	loginBackoffBase := envDuration("LOGIN_BACKOFF_BASE", time.Second)
+	loginBackoffMax := envDuration("LOGIN_BACKOFF_MAX", time.Minute)
+	loginLockoutDuration := envDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+	if totpIssuer == "" {
+		totpIssuer = "College Dashboard"
+	}
+	profilesFolder := os.Getenv("PROFILES_FOLDER")
+	if profilesFolder == "" {
+		profilesFolder = "users/profiles"
+	}
+	attachmentsFolder := os.Getenv("ATTACHMENTS_FOLDER")
+	if attachmentsFolder == "" {
+		attachmentsFolder = "institutes/notices"
+	}
+	loginChallengeDuration := envDuration("LOGIN_CHALLENGE_DURATION", 5*time.Minute)
+	sessionMaxLifetime := envDuration("SESSION_MAX_LIFETIME", 30*24*time.Hour)
+	inviteDuration := envDuration("INVITE_DURATION", 72*time.Hour)
+	emailChangeDuration := envDuration("EMAIL_CHANGE_DURATION", 24*time.Hour)
+	oidcStateDuration := envDuration("OIDC_STATE_DURATION", 10*time.Minute)
+
 	portStr := os.Getenv("PORT")
 	portInt, err := strconv.Atoi(portStr)
 	if err != nil || portStr == "" {
@@ -34,27 +151,147 @@ func LoadConfig(path string) (Config, error) {
 	port := int16(portInt)
 
 	config := Config{
-		DatabaseURL:       os.Getenv("DATABASE_URL"),
-		TokenSymmetricKey: os.Getenv("TOKEN_SYMMETRIC_KEY"),
-		Port:              port,
-		TokenDuration:     tokenDuration,
-		ProfilesFolder:    os.Getenv("PROFILES_FOLDER"),
+		Environment:          os.Getenv("APP_ENV"),
+		DatabaseURL:          os.Getenv("DATABASE_URL"),
+		TokenSymmetricKey:    os.Getenv("TOKEN_SYMMETRIC_KEY"),
+		Port:                 port,
+		TokenDuration:        tokenDuration,
+		RefreshTokenDuration: refreshTokenDuration,
+		SessionMaxLifetime:   sessionMaxLifetime,
+		RevocationCacheTTL:   revocationCacheTTL,
+		ProfilesFolder:       profilesFolder,
+		AttachmentsFolder:    attachmentsFolder,
+
+		PublicBaseURL: strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"),
+
+		TokenMaker:          os.Getenv("TOKEN_MAKER"),
+		TokenAsymmetricKey:  os.Getenv("TOKEN_ASYMMETRIC_KEY"),
+		TokenSymmetricKeys:  envKeyring("TOKEN_SYMMETRIC_KEYS", "TOKEN_SYMMETRIC_KEY"),
+		TokenAsymmetricKeys: envKeyring("TOKEN_ASYMMETRIC_KEYS", "TOKEN_ASYMMETRIC_KEY"),
+		TokenSigningKeyID:   os.Getenv("TOKEN_SIGNING_KEY_ID"),
+
+		PasswordResetDuration: passwordResetDuration,
+		PasswordResetURL:      os.Getenv("PASSWORD_RESET_URL"),
+
+		InviteDuration: inviteDuration,
+		InviteURL:      os.Getenv("INVITE_URL"),
+
+		EmailChangeDuration: emailChangeDuration,
+		EmailChangeURL:      os.Getenv("EMAIL_CHANGE_URL"),
+
+		Mailer:       os.Getenv("MAILER"),
+		MailFrom:     mailFrom,
+		MailFile:     os.Getenv("MAIL_FILE"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     smtpPort,
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+		LoginMaxFailures:     loginMaxFailures,
+		LoginIPMaxFailures:   loginIPMaxFailures,
+		LoginFailureWindow:   loginFailureWindow,
+		LoginBackoffBase:     loginBackoffBase,
+		LoginBackoffMax:      loginBackoffMax,
+		LoginLockoutDuration: loginLockoutDuration,
+
+		TOTPIssuer:             totpIssuer,
+		LoginChallengeDuration: loginChallengeDuration,
+
+		OIDCRedirectURL:   os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCStateDuration: oidcStateDuration,
+
+		TrustedProxies: envList("TRUSTED_PROXIES"),
+		ProxyHeader:    os.Getenv("PROXY_HEADER"),
+
+		SuperAdminName:     os.Getenv("SUPERADMIN_NAME"),
+		SuperAdminEmail:    os.Getenv("SUPERADMIN_EMAIL"),
+		SuperAdminPassword: os.Getenv("SUPERADMIN_PASSWORD"),
 	}
 	if config.DatabaseURL == "" {
 		return Config{}, &ConfigError{"DATABASE_URL is missing"}
 	}
-	if config.TokenSymmetricKey == "" {
-		return Config{}, &ConfigError{"TOKEN_SYMMETRIC_KEY is missing"}
+	if config.TokenMaker == "public" {
+		if len(config.TokenAsymmetricKeys) == 0 {
+			return Config{}, &ConfigError{"TOKEN_ASYMMETRIC_KEY or TOKEN_ASYMMETRIC_KEYS is missing"}
+		}
+	} else if len(config.TokenSymmetricKeys) == 0 {
+		return Config{}, &ConfigError{"TOKEN_SYMMETRIC_KEY or TOKEN_SYMMETRIC_KEYS is missing"}
+	}
+
+	// without trusted proxies anyone could set the header and pick their IP
+	if config.ProxyHeader != "" && len(config.TrustedProxies) == 0 {
+		return Config{}, &ConfigError{"PROXY_HEADER needs TRUSTED_PROXIES"}
+	}
+
+	// mails and feeds carry links built from these
+	for name, value := range map[string]string{
+		"PUBLIC_BASE_URL":    config.PublicBaseURL,
+		"PASSWORD_RESET_URL": config.PasswordResetURL,
+		"INVITE_URL":         config.InviteURL,
+		"EMAIL_CHANGE_URL":   config.EmailChangeURL,
+	} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return Config{}, &ConfigError{name + " must be an absolute URL"}
+		}
 	}
 
 	// Optionally, check for required variables
-	if config.DatabaseURL == "" || config.TokenSymmetricKey == "" {
+	if config.DatabaseURL == "" {
 		return config, ErrMissingEnv
 	}
 
 	return config, nil
 }
 
+// envDuration reads a duration, falling back to def if not set or invalid
+func envDuration(key string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// envInt reads a positive integer, falling back to def if not set or invalid
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// envList reads a comma separated list, skipping empty entries
+func envList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// envKeyring reads "kid:key,kid:key" pairs from key and adds the single
+// legacy key (if set) under the "" key ID
+func envKeyring(key string, legacyKey string) map[string]string {
+	keys := make(map[string]string)
+	if legacy := os.Getenv(legacyKey); legacy != "" {
+		keys[""] = legacy
+	}
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		keyID, value, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || keyID == "" || value == "" {
+			continue
+		}
+		keys[keyID] = value
+	}
+	return keys
+}
+
 var ErrMissingEnv = &ConfigError{"One or more required environment variables are missing"}
 
 type ConfigError struct {